@@ -2,8 +2,10 @@ package handler
 
 import (
 	"backend/config"
+	"backend/controllers"
 	"backend/models"
 	"backend/routes"
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -12,10 +14,14 @@ import (
 var App *gin.Engine
 
 func init() {
+	config.SetupLogger()
+	config.SetupTracing(context.Background())
+	// a cold start answers 503 right away instead of retrying for seconds
+	config.SkipConnectRetries()
 	config.ConnectDb()
 	config.Redis()
+	config.StartHealthMonitor(context.Background())
 	App = gin.New()
-	App.Use(gin.Recovery())
 
 	router := App.Group("/")
 	router.GET("/", func(ctx *gin.Context) {
@@ -25,7 +31,7 @@ func init() {
 		})
 	})
 
-	routes.Routes(App)
+	routes.Routes(App, controllers.NewServer(config.Db, config.Rdb))
 }
 
 func Handler(w http.ResponseWriter, r *http.Request) {
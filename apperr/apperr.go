@@ -0,0 +1,192 @@
+// Package apperr holds the errors handlers return to clients. A handler calls
+// ctx.Error(err) and middleware.Errors turns it into the response, anything
+// that is not an *Error (database, redis, ...) becomes a 500 with a generic
+// message and the cause only goes to the log.
+package apperr
+
+import (
+	"backend/i18n"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindTooManyRequests
+	KindUnavailable
+	KindTimeout
+)
+
+var statuses = map[Kind]int{
+	KindInternal:        http.StatusInternalServerError,
+	KindValidation:      http.StatusBadRequest,
+	KindUnauthorized:    http.StatusUnauthorized,
+	KindForbidden:       http.StatusForbidden,
+	KindNotFound:        http.StatusNotFound,
+	KindConflict:        http.StatusConflict,
+	KindTooManyRequests: http.StatusTooManyRequests,
+	KindUnavailable:     http.StatusServiceUnavailable,
+	KindTimeout:         http.StatusGatewayTimeout,
+}
+
+func (k Kind) Status() int {
+	return statuses[k]
+}
+
+// FieldError points a validation message at one request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Kind Kind
+	// Message is shown to the client, keep internals out of it. It is in
+	// English, Localize translates it.
+	Message string
+	Fields  []FieldError
+	// Err is the cause, it is logged but never sent
+	Err error
+
+	// format and args of a Newf message, the catalog knows the format
+	format string
+	args   []any
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap returns a copy of e with err as the cause, for sentinels like
+// models.ErrUserNotFound that should keep what went wrong
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// Is matches errors of the same kind and message, so a wrapped copy is
+// still errors.Is the sentinel it came from
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Message == e.Message
+}
+
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Newf is New with a fmt message ("days must be between %d and 365")
+func Newf(kind Kind, format string, args ...any) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...), format: format, args: args}
+}
+
+// Localize returns a copy of e with the message and the field messages in
+// locale (see i18n.Locale). Binding errors are worded again from the
+// validator errors they keep.
+func (e *Error) Localize(locale string) *Error {
+	if e == nil {
+		return nil
+	}
+
+	localized := *e
+	if e.format != "" {
+		localized.Message = i18n.T(locale, e.format, e.args...)
+	} else {
+		localized.Message = i18n.T(locale, e.Message)
+	}
+
+	if fields, ok := bindingFields(locale, e.Err); ok && e.Kind == KindValidation {
+		localized.Fields = fields
+	} else if len(e.Fields) > 0 {
+		localized.Fields = make([]FieldError, len(e.Fields))
+		for i, f := range e.Fields {
+			localized.Fields[i] = FieldError{Field: f.Field, Message: i18n.T(locale, f.Message)}
+		}
+	}
+	return &localized
+}
+
+// Internal is for failures with a message worth showing, the cause should
+// be attached with Wrap
+func Internal(message string) *Error {
+	return New(KindInternal, message)
+}
+
+func NotFound(message string) *Error {
+	return New(KindNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(KindConflict, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(KindUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(KindForbidden, message)
+}
+
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Message: message, Fields: fields}
+}
+
+func TooManyRequests(message string) *Error {
+	return New(KindTooManyRequests, message)
+}
+
+func Unavailable(message string) *Error {
+	return New(KindUnavailable, message)
+}
+
+func Field(field, message string) FieldError {
+	return FieldError{Field: field, Message: message}
+}
+
+// From turns any error into an *Error: typed errors are kept, database and
+// request body errors are translated, the rest is KindInternal
+func From(err error) *Error {
+	var e *Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Error{Kind: KindTimeout, Message: "Database did not answer in time, try again later", Err: err}
+	}
+
+	if e := fromDatabase(err); e != nil {
+		return e
+	}
+	if e := fromBinding(err); e != nil {
+		return e
+	}
+	return &Error{Kind: KindInternal, Message: "Internal server error", Err: err}
+}
+
+// KindOf is From(err).Kind, KindInternal for nil
+func KindOf(err error) Kind {
+	if e := From(err); e != nil {
+		return e.Kind
+	}
+	return KindInternal
+}
@@ -0,0 +1,85 @@
+package apperr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestFrom(t *testing.T) {
+	notFound := NotFound("review not found")
+
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+		field   string
+	}{
+		{"typed", notFound, 404, "review not found", ""},
+		{"wrapped typed", fmt.Errorf("delete review: %w", notFound), 404, "review not found", ""},
+		{"timeout", fmt.Errorf("query: %w", context.DeadlineExceeded), 504, "Database did not answer in time, try again later", ""},
+		{"no rows", pgx.ErrNoRows, 404, "Data not found", ""},
+		{
+			"unique",
+			&pgconn.PgError{Code: "23505", Detail: "Key (email)=(dina@example.com) already exists."},
+			409, "email already exists", "email",
+		},
+		{
+			"missing reference",
+			&pgconn.PgError{Code: "23503", Detail: `Key (category_id)=(99) is not present in table "categories".`},
+			400, "category_id does not exist", "category_id",
+		},
+		{
+			"still referenced",
+			&pgconn.PgError{Code: "23503", Detail: `Key (id)=(1) is still referenced from table "order_items".`},
+			409, "Data is still in use", "",
+		},
+		{"bad json", &json.SyntaxError{}, 400, "Request body is not valid JSON", ""},
+		{"unknown", errors.New("connection reset by peer"), 500, "Internal server error", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := From(tt.err)
+			if e.Kind.Status() != tt.status || e.Message != tt.message {
+				t.Fatalf("From = %d %q, want %d %q", e.Kind.Status(), e.Message, tt.status, tt.message)
+			}
+			if tt.field != "" && (len(e.Fields) != 1 || e.Fields[0].Field != tt.field) {
+				t.Fatalf("fields = %+v, want %s", e.Fields, tt.field)
+			}
+		})
+	}
+}
+
+func TestWrapKeepsSentinel(t *testing.T) {
+	sentinel := NotFound("user not found")
+	wrapped := sentinel.Wrap(pgx.ErrNoRows)
+
+	if !errors.Is(wrapped, sentinel) || !errors.Is(wrapped, pgx.ErrNoRows) {
+		t.Fatalf("wrapped %v lost its chain", wrapped)
+	}
+	if sentinel.Err != nil {
+		t.Fatal("Wrap changed the sentinel")
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	e := From(&pgconn.PgError{Code: "23505", Detail: "Key (email)=(dina@example.com) already exists."})
+
+	id := e.Localize("id")
+	if id.Message != "email sudah ada" || id.Fields[0].Message != "sudah ada" {
+		t.Fatalf("Localize(id) = %q %+v", id.Message, id.Fields)
+	}
+	if e.Message != "email already exists" || e.Fields[0].Message != "already exists" {
+		t.Fatalf("Localize changed the error: %q %+v", e.Message, e.Fields)
+	}
+	if got := Newf(KindValidation, "days must be between %d and 365", 7).Localize("id").Message; got != "days harus antara 7 dan 365" {
+		t.Fatalf("Newf localized = %q", got)
+	}
+}
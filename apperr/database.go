@@ -0,0 +1,74 @@
+package apperr
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATE codes of the postgres errors a client can cause
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgNotNullViolation    = "23502"
+	pgCheckViolation      = "23514"
+	pgInvalidText         = "22P02"
+	pgOutOfRange          = "22003"
+	pgStringTooLong       = "22001"
+)
+
+// "Key (email)=(dina@example.com) already exists.", only the column is kept,
+// the value stays out of the response
+var pgKeyDetail = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+
+// fromDatabase translates pgx.ErrNoRows and the constraint errors, nil for
+// everything else
+func fromDatabase(err error) *Error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Error{Kind: KindNotFound, Message: "Data not found", Err: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+
+	column := pgErr.ColumnName
+	if m := pgKeyDetail.FindStringSubmatch(pgErr.Detail); m != nil {
+		column = m[1]
+	}
+
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		if column == "" {
+			return &Error{Kind: KindConflict, Message: "Data already exists", Err: err}
+		}
+		e := Newf(KindConflict, "%s already exists", column)
+		e.Fields = []FieldError{{Field: column, Message: "already exists"}}
+		e.Err = err
+		return e
+
+	case pgForeignKeyViolation:
+		// deleting a row others still point at
+		if strings.Contains(pgErr.Detail, "is still referenced") || column == "" {
+			return &Error{Kind: KindConflict, Message: "Data is still in use", Err: err}
+		}
+		e := Newf(KindValidation, "%s does not exist", column)
+		e.Fields = []FieldError{{Field: column, Message: "does not exist"}}
+		e.Err = err
+		return e
+
+	case pgNotNullViolation:
+		e := Newf(KindValidation, "%s is required", column)
+		e.Fields = []FieldError{{Field: column, Message: "is required"}}
+		e.Err = err
+		return e
+
+	case pgCheckViolation, pgInvalidText, pgOutOfRange, pgStringTooLong:
+		return &Error{Kind: KindValidation, Message: "Value is not valid", Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package apperr
+
+import (
+	"backend/i18n"
+	"backend/validation"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Binding is the error for a request ShouldBind rejected, a Validation
+// with the failing fields when the binding tags tell them
+func Binding(err error) *Error {
+	if e := fromBinding(err); e != nil {
+		return e
+	}
+	return &Error{Kind: KindValidation, Message: validation.InvalidRequest, Err: err}
+}
+
+// fromBinding covers the errors of ShouldBindJSON: an empty or broken body,
+// a value of the wrong type and the failed binding tags
+func fromBinding(err error) *Error {
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.Is(err, io.EOF):
+		return &Error{Kind: KindValidation, Message: "Request body is required", Err: err}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return &Error{Kind: KindValidation, Message: "Request body is not valid JSON", Err: err}
+	}
+
+	if fields, ok := bindingFields(i18n.English, err); ok {
+		return &Error{Kind: KindValidation, Message: validation.InvalidRequest, Fields: fields, Err: err}
+	}
+	return nil
+}
+
+// bindingFields words the fields of a type error or of the failed binding
+// tags in locale, false for other errors
+func bindingFields(locale string, err error) ([]FieldError, bool) {
+	var typeErr *json.UnmarshalTypeError
+	var errs validator.ValidationErrors
+	switch {
+	case errors.As(err, &typeErr):
+		return []FieldError{{Field: typeErr.Field, Message: validation.TypeMessage(locale, typeErr.Type)}}, true
+	case errors.As(err, &errs):
+		fields := make([]FieldError, 0, len(errs))
+		for _, fe := range errs {
+			fields = append(fields, FieldError{Field: fe.Field(), Message: validation.Message(locale, fe)})
+		}
+		return fields, true
+	}
+	return nil, false
+}
@@ -0,0 +1,275 @@
+// Package cache is a JSON cache on top of config.Rdb with tag based
+// invalidation. Every call degrades to a miss when Redis is unavailable, so
+// callers always fall through to the database.
+package cache
+
+import (
+	"backend/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	keyPrefix = "cache:"
+	tagPrefix = "cache:tag:"
+
+	opTimeout = 200 * time.Millisecond
+	// a shared load outlives the request that started it, see Remember
+	loadTimeout = 15 * time.Second
+	// after a Redis error the cache is skipped for a while instead of
+	// adding a timeout to every request
+	backoff = 5 * time.Second
+)
+
+var (
+	group     singleflight.Group
+	downUntil atomic.Int64
+)
+
+// client is Redis for reads and writes, nil while it is down or backing off
+func client() *redis.Client {
+	if !config.RedisReady() || time.Now().UnixNano() < downUntil.Load() {
+		return nil
+	}
+	return config.Rdb
+}
+
+func fail(err error) {
+	slog.Warn("cache skipped, redis unavailable", "error", err, "retry_in", backoff.String())
+	downUntil.Store(time.Now().Add(backoff).UnixNano())
+}
+
+// Get decodes the cached value of key, ok is false on a miss or any error
+func Get[T any](ctx context.Context, key string) (T, bool) {
+	var value T
+
+	rdb := client()
+	if rdb == nil {
+		record(key, outcomeError)
+		return value, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opTimeout)
+	defer cancel()
+
+	data, err := rdb.Get(ctx, keyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		record(key, outcomeMiss)
+		return value, false
+	}
+	if err != nil {
+		fail(err)
+		record(key, outcomeError)
+		return value, false
+	}
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		record(key, outcomeMiss)
+		return value, false
+	}
+
+	record(key, outcomeHit)
+	return value, true
+}
+
+// Set stores value under key and registers the key on every tag
+func Set[T any](ctx context.Context, key string, value T, ttl time.Duration, tags ...string) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	set(ctx, key, data, ttl, tags)
+}
+
+func set(ctx context.Context, key string, data []byte, ttl time.Duration, tags []string) {
+	rdb := client()
+	if rdb == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opTimeout)
+	defer cancel()
+
+	_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, keyPrefix+key, data, ttl)
+		for _, tag := range tags {
+			pipe.SAdd(ctx, tagPrefix+tag, keyPrefix+key)
+			// the tag set lives at least as long as its newest key
+			pipe.ExpireGT(ctx, tagPrefix+tag, ttl)
+			pipe.ExpireNX(ctx, tagPrefix+tag, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		fail(err)
+	}
+}
+
+// Remember returns the cached value of key or calls load, concurrent misses
+// of the same key share one load (stampede protection). Every caller decodes
+// its own copy so handlers may modify the value. hit reports whether the
+// value came from the cache. load gets the values of the first caller's
+// context but not its cancellation, a client going away does not fail the
+// callers sharing the load. loadTimeout bounds it instead.
+func Remember[T any](ctx context.Context, key string, ttl time.Duration, tags []string, load func(ctx context.Context) (T, error)) (value T, hit bool, err error) {
+	return RememberTTL(ctx, key, tags, func(ctx context.Context) (T, time.Duration, error) {
+		value, err := load(ctx)
+		return value, ttl, err
+	})
+}
+
+// RememberTTL is Remember for values that know how long they stay valid,
+// load returns the ttl with the value. A ttl of 0 or less is not cached.
+func RememberTTL[T any](ctx context.Context, key string, tags []string, load func(ctx context.Context) (T, time.Duration, error)) (value T, hit bool, err error) {
+	if value, ok := Get[T](ctx, key); ok {
+		return value, true, nil
+	}
+
+	data, err, _ := group.Do(key, func() (any, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+
+		value, ttl, err := load(loadCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if ttl > 0 {
+			set(context.WithoutCancel(ctx), key, data, ttl, tags)
+		}
+
+		return data, nil
+	})
+	if err != nil {
+		return value, false, err
+	}
+
+	err = json.Unmarshal(data.([]byte), &value)
+	return value, false, err
+}
+
+// Delete drops single keys. Like Invalidate it ignores the read backoff.
+func Delete(ctx context.Context, keys ...string) {
+	rdb := config.Rdb
+	if rdb == nil || len(keys) == 0 {
+		return
+	}
+
+	full := make([]string, len(keys))
+	for i, key := range keys {
+		full[i] = keyPrefix + key
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opTimeout)
+	defer cancel()
+
+	if err := rdb.Del(ctx, full...).Err(); err != nil {
+		fail(err)
+	}
+}
+
+// invalidate deletes the keys of every tag set in KEYS and the sets
+// themselves in one step, a Set between reading a tag and deleting it would
+// otherwise lose its registration and survive the invalidation. unpack is
+// limited in the number of values, so keys are deleted in chunks.
+var invalidate = redis.NewScript(`
+for _, tag in ipairs(KEYS) do
+	local keys = redis.call('SMEMBERS', tag)
+	for i = 1, #keys, 1000 do
+		redis.call('DEL', unpack(keys, i, math.min(i + 999, #keys)))
+	end
+	redis.call('DEL', tag)
+end
+return 0
+`)
+
+// Invalidate drops every key stored with any of the tags. It is tried even
+// while reads back off or Redis is marked down, a skipped invalidation
+// would serve stale entries for their whole TTL once Redis answers again.
+func Invalidate(ctx context.Context, tags ...string) {
+	rdb := config.Rdb
+	if rdb == nil || len(tags) == 0 {
+		return
+	}
+
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = tagPrefix + tag
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opTimeout)
+	defer cancel()
+
+	if err := invalidate.Run(ctx, rdb, keys).Err(); err != nil {
+		fail(err)
+	}
+}
+
+type outcome int
+
+const (
+	outcomeHit outcome = iota
+	outcomeMiss
+	outcomeError
+)
+
+// Counters are the lookups of one cache name since start
+type Counters struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Errors int64 `json:"errors"`
+}
+
+type counters struct {
+	hits, misses, errors atomic.Int64
+}
+
+var stats sync.Map // name -> *counters
+
+// name groups keys for metrics, "products:page:1" counts as "products"
+func name(key string) string {
+	if i := strings.IndexByte(key, ':'); i > 0 {
+		return key[:i]
+	}
+	return key
+}
+
+func record(key string, o outcome) {
+	c, _ := stats.LoadOrStore(name(key), &counters{})
+	switch o {
+	case outcomeHit:
+		c.(*counters).hits.Add(1)
+	case outcomeMiss:
+		c.(*counters).misses.Add(1)
+	default:
+		c.(*counters).errors.Add(1)
+	}
+}
+
+// Stats returns hit/miss/error counters per cache name
+func Stats() map[string]Counters {
+	out := map[string]Counters{}
+	stats.Range(func(k, v any) bool {
+		c := v.(*counters)
+		out[k.(string)] = Counters{
+			Hits:   c.hits.Load(),
+			Misses: c.misses.Load(),
+			Errors: c.errors.Load(),
+		}
+		return true
+	})
+	return out
+}
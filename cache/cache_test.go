@@ -0,0 +1,268 @@
+package cache
+
+import (
+	"backend/config"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// useRedis points config.Rdb at TEST_REDIS_ADDR (database 1, flushed) and
+// skips the test without it
+func useRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR is not set")
+	}
+
+	rdb := redis.NewClient(&redis.Options{Addr: addr, DB: 1})
+	if err := rdb.FlushDB(t.Context()).Err(); err != nil {
+		t.Fatalf("flush redis: %v", err)
+	}
+	setRedis(t, rdb)
+	if !config.RedisReady() {
+		t.Fatal("redis is not ready")
+	}
+	return rdb
+}
+
+// setRedis swaps config.Rdb for the test and updates the health flag
+func setRedis(t *testing.T, rdb *redis.Client) {
+	t.Helper()
+
+	previous := config.Rdb
+	config.Rdb = rdb
+	config.CheckDependencies(context.Background())
+	downUntil.Store(0)
+
+	t.Cleanup(func() {
+		rdb.Close()
+		config.Rdb = previous
+		config.CheckDependencies(context.Background())
+		downUntil.Store(0)
+	})
+}
+
+// stopRedis points config.Rdb at a port nothing listens on
+func stopRedis(t *testing.T) {
+	t.Helper()
+	setRedis(t, redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1}))
+}
+
+func TestRememberHitAndMiss(t *testing.T) {
+	useRedis(t)
+	ctx := t.Context()
+
+	loads := 0
+	load := func(context.Context) (string, error) {
+		loads++
+		return "kopi", nil
+	}
+
+	for i, wantHit := range []bool{false, true} {
+		value, hit, err := Remember(ctx, "test:remember", time.Minute, nil, load)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != "kopi" || hit != wantHit {
+			t.Fatalf("call %d = %q, hit %v, want kopi, hit %v", i+1, value, hit, wantHit)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("loaded %d times, want 1", loads)
+	}
+
+	// errors are not cached
+	_, _, err := Remember(ctx, "test:failing", time.Minute, nil, func(context.Context) (string, error) {
+		return "", errors.New("boom")
+	})
+	if err == nil {
+		t.Fatal("want the load error")
+	}
+	if _, ok := Get[string](ctx, "test:failing"); ok {
+		t.Fatal("a failed load was cached")
+	}
+}
+
+func TestRememberSharesLoad(t *testing.T) {
+	stopRedis(t)
+
+	var loads atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	load := func(context.Context) (int, error) {
+		if loads.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _, _ = Remember(context.Background(), "test:stampede", time.Minute, nil, load)
+		}()
+	}
+
+	<-started
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("loaded %d times, want 1", n)
+	}
+	for i, v := range results {
+		if v != 42 {
+			t.Fatalf("caller %d got %d", i, v)
+		}
+	}
+}
+
+// the first caller going away does not cancel the load the others wait on
+func TestRememberDetachesLoad(t *testing.T) {
+	stopRedis(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, _, err := Remember(ctx, "test:detached", time.Minute, nil, func(loadCtx context.Context) (bool, error) {
+		cancel()
+		if _, ok := loadCtx.Deadline(); !ok {
+			t.Error("load has no deadline")
+		}
+		return true, loadCtx.Err()
+	})
+	if err != nil {
+		t.Fatalf("load was cancelled with its caller: %v", err)
+	}
+}
+
+func TestInvalidateTags(t *testing.T) {
+	rdb := useRedis(t)
+	ctx := t.Context()
+
+	Set(ctx, "test:a", 1, time.Minute, "tag-a")
+	Set(ctx, "test:b", 2, time.Minute, "tag-b")
+	Set(ctx, "test:ab", 3, time.Minute, "tag-a", "tag-b")
+	Set(ctx, "test:c", 4, time.Minute, "tag-c")
+
+	Invalidate(ctx, "tag-a", "tag-b")
+
+	for _, key := range []string{"test:a", "test:b", "test:ab"} {
+		if _, ok := Get[int](ctx, key); ok {
+			t.Fatalf("%s survived the invalidation", key)
+		}
+	}
+	if v, ok := Get[int](ctx, "test:c"); !ok || v != 4 {
+		t.Fatalf("test:c = %d, %v", v, ok)
+	}
+	if n := rdb.Exists(ctx, tagPrefix+"tag-a", tagPrefix+"tag-b").Val(); n != 0 {
+		t.Fatalf("%d tag sets left", n)
+	}
+}
+
+// the script deletes large tags in chunks, unpack has a limit
+func TestInvalidateLargeTag(t *testing.T) {
+	rdb := useRedis(t)
+	ctx := t.Context()
+
+	for i := range 2500 {
+		Set(ctx, fmt.Sprintf("test:%d", i), i, time.Minute, "tag-large")
+	}
+
+	Invalidate(ctx, "tag-large")
+
+	if n := rdb.DBSize(ctx).Val(); n != 0 {
+		t.Fatalf("%d keys left", n)
+	}
+}
+
+// after a Redis error reads skip the cache, invalidation still runs
+func TestInvalidateDuringBackoff(t *testing.T) {
+	useRedis(t)
+	ctx := t.Context()
+
+	Set(ctx, "test:stale", 1, time.Minute, "tag-stale")
+	fail(errors.New("timeout"))
+
+	if _, ok := Get[int](ctx, "test:stale"); ok {
+		t.Fatal("read during the backoff")
+	}
+	Invalidate(ctx, "tag-stale")
+
+	downUntil.Store(0)
+	if _, ok := Get[int](ctx, "test:stale"); ok {
+		t.Fatal("invalidation was skipped during the backoff")
+	}
+}
+
+// without Redis every call falls through to load and counts as an error
+func TestDegraded(t *testing.T) {
+	stopRedis(t)
+	ctx := t.Context()
+
+	before := Stats()["degraded"].Errors
+	loads := 0
+	for range 2 {
+		value, hit, err := Remember(ctx, "degraded:key", time.Minute, nil, func(context.Context) (string, error) {
+			loads++
+			return "db", nil
+		})
+		if err != nil || hit || value != "db" {
+			t.Fatalf("Remember = %q, hit %v, err %v", value, hit, err)
+		}
+	}
+	if loads != 2 {
+		t.Fatalf("loaded %d times, want 2", loads)
+	}
+	if got := Stats()["degraded"].Errors - before; got != 2 {
+		t.Fatalf("%d errors counted, want 2", got)
+	}
+
+	// nothing to reach, nothing to panic about
+	Set(ctx, "degraded:key", "x", time.Minute, "tag")
+	Invalidate(ctx, "tag")
+	Delete(ctx, "degraded:key")
+}
+
+func TestRememberTTL(t *testing.T) {
+	useRedis(t)
+	ctx := t.Context()
+
+	remember := func(key string, ttl time.Duration) bool {
+		_, hit, err := RememberTTL(ctx, key, nil, func(context.Context) (int, time.Duration, error) {
+			return 1, ttl, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hit
+	}
+
+	// an already passed deadline is not cached, go-redis would store it forever
+	remember("test:expired", 0)
+	if remember("test:expired", 0) {
+		t.Fatal("ttl 0 was cached")
+	}
+
+	remember("test:short", 100*time.Millisecond)
+	if !remember("test:short", 100*time.Millisecond) {
+		t.Fatal("ttl 100ms was not cached")
+	}
+	time.Sleep(150 * time.Millisecond)
+	if remember("test:short", 100*time.Millisecond) {
+		t.Fatal("entry outlived its ttl")
+	}
+}
@@ -0,0 +1,25 @@
+package cache
+
+import "strconv"
+
+// Tags group cached entries that must be dropped together
+const (
+	// every product list (listing, featured, rankings, suggestions)
+	ProductsTag = "products"
+	// featured products (/favorite-product)
+	FeaturedTag = "featured"
+	// category list and everything showing category names
+	CategoriesTag = "categories"
+	// admin user list
+	UsersTag = "users"
+)
+
+// ProductTag is one product, its detail page
+func ProductTag(id int64) string {
+	return "product:" + strconv.FormatInt(id, 10)
+}
+
+// UserTag is data computed for one user
+func UserTag(id int64) string {
+	return "user:" + strconv.FormatInt(id, 10)
+}
@@ -3,41 +3,103 @@ package main
 import (
 	"backend/config"
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
 func main() {
+	// -baseline marks the migrations up to N as applied without running
+	// them, for databases migrated before versions were recorded
+	baseline := flag.Int64("baseline", 0, "mark migrations up to this version as applied")
+	flag.Parse()
+
 	config.ConnectDb()
 	db := config.Db
+	if db == nil || !config.DbReady() {
+		log.Fatal("database is unavailable")
+	}
 	defer db.Close()
 
+	ctx := context.Background()
+
+	_, err := db.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		log.Fatalf("create schema_migrations: %v", err)
+	}
+
 	migrationsDir := "../../db"
 
 	files, err := os.ReadDir(migrationsDir)
 	if err != nil {
-		log.Fatalf("gagal membaca folder migrations: %v", err)
+		log.Fatalf("read migrations folder: %v", err)
 	}
 
 	for _, f := range files {
-		if !f.IsDir() && strings.HasSuffix(f.Name(), ".up.sql") {
-			filePath := filepath.Join(migrationsDir, f.Name())
-			sqlBytes, err := os.ReadFile(filePath)
-			if err != nil {
-				log.Fatalf("gagal membaca file %s: %v", f.Name(), err)
-			}
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".up.sql") {
+			continue
+		}
+
+		version, err := strconv.ParseInt(strings.SplitN(f.Name(), "_", 2)[0], 10, 64)
+		if err != nil {
+			log.Fatalf("file name %s does not start with a version: %v", f.Name(), err)
+		}
 
-			query := string(sqlBytes)
-			fmt.Printf("menjalankan migrasi: %s\n", f.Name())
+		var applied bool
+		err = db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, version).Scan(&applied)
+		if err != nil {
+			log.Fatalf("read schema_migrations: %v", err)
+		}
+		if applied {
+			continue
+		}
 
-			if _, err := db.Exec(context.Background(), query); err != nil {
-				log.Fatalf("gagal %s: %v", f.Name(), err)
+		if version <= *baseline {
+			if _, err := db.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+				log.Fatalf("mark %s as applied: %v", f.Name(), err)
 			}
+			continue
+		}
+
+		filePath := filepath.Join(migrationsDir, f.Name())
+		sqlBytes, err := os.ReadFile(filePath)
+		if err != nil {
+			log.Fatalf("read %s: %v", f.Name(), err)
+		}
+
+		query := string(sqlBytes)
+		fmt.Printf("running migration: %s\n", f.Name())
+
+		// the migration and its version are committed together, a failing
+		// migration leaves nothing behind
+		tx, err := db.Begin(ctx)
+		if err != nil {
+			log.Fatalf("begin %s: %v", f.Name(), err)
+		}
+
+		if _, err := tx.Exec(ctx, query); err != nil {
+			tx.Rollback(ctx)
+			log.Fatalf("run %s: %v", f.Name(), err)
+		}
+
+		if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+			tx.Rollback(ctx)
+			log.Fatalf("record %s: %v", f.Name(), err)
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			log.Fatalf("commit %s: %v", f.Name(), err)
 		}
 	}
 
-	fmt.Println(" .up.sql berhasil dijalankan.")
+	fmt.Println("all .up.sql migrations applied")
 }
@@ -0,0 +1,28 @@
+package config
+
+import "os"
+
+// EmailVerificationPolicy decides where unverified accounts are blocked:
+// "checkout" (default) blocks creating orders, "login" blocks every
+// authenticated route, "off" disables the check.
+func EmailVerificationPolicy() string {
+	switch policy := os.Getenv("EMAIL_VERIFICATION_POLICY"); policy {
+	case "login", "off":
+		return policy
+	default:
+		return "checkout"
+	}
+}
+
+// TwoFactorRequired reports whether accounts with the given role must have
+// TOTP enabled. TWO_FACTOR_POLICY: "admin" (default), "all" or "optional".
+func TwoFactorRequired(role string) bool {
+	switch os.Getenv("TWO_FACTOR_POLICY") {
+	case "optional":
+		return false
+	case "all":
+		return true
+	default:
+		return role == "admin"
+	}
+}
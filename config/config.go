@@ -2,7 +2,7 @@ package config
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
 	"os"
 	"time"
 
@@ -11,6 +11,16 @@ import (
 
 var Db *pgxpool.Pool
 
+// QueryTimeout bounds every query on top of the request deadline.
+// DB_QUERY_TIMEOUT (default 5s), 0 turns it off.
+func QueryTimeout() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("DB_QUERY_TIMEOUT"))
+	if err != nil || d < 0 {
+		return 5 * time.Second
+	}
+	return d
+}
+
 func ConnectDb() {
 	if Db != nil {
 		return
@@ -18,24 +28,32 @@ func ConnectDb() {
 
 	dsn := os.Getenv("DATABASE_URL")
 	if dsn == "" {
-		fmt.Println("DATABASE_URL not found")
+		slog.Error("DATABASE_URL not found")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	conn, err := pgxpool.New(ctx, dsn)
+	poolConfig, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
-		fmt.Println("failed to create pool:", err)
+		slog.Error("failed to create database pool", "error", err)
 		return
 	}
+	poolConfig.ConnConfig.Tracer = queryTracer{timeout: QueryTimeout()}
 
-	if err = conn.Ping(ctx); err != nil {
-		fmt.Println("cannot ping database:", err)
+	conn, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		slog.Error("failed to create database pool", "error", err)
 		return
 	}
 
+	// the pool connects lazily and reconnects by itself, keep it even when
+	// the database is down so requests recover once it is back
 	Db = conn
-	fmt.Println("database connected")
+
+	if err := connectWithRetry("database", conn.Ping); err != nil {
+		slog.Error("cannot ping database, serving 503 until it is reachable", "error", err)
+		return
+	}
+
+	dbReady.Store(true)
+	slog.Info("database connected")
 }
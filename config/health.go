@@ -0,0 +1,130 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const pingTimeout = 3 * time.Second
+
+var (
+	dbReady    atomic.Bool
+	redisReady atomic.Bool
+	// connectOnce skips the startup retries, see SkipConnectRetries
+	connectOnce atomic.Bool
+)
+
+// DbReady reports whether the last database ping succeeded
+func DbReady() bool {
+	return Db != nil && dbReady.Load()
+}
+
+// RedisReady reports whether the last redis ping succeeded
+func RedisReady() bool {
+	return Rdb != nil && redisReady.Load()
+}
+
+// ConnectRetries is how many times startup pings a dependency before serving
+// 503 for it. DEPENDENCY_CONNECT_RETRIES (default 3).
+func ConnectRetries() int {
+	if connectOnce.Load() {
+		return 1
+	}
+	return max(envInt("DEPENDENCY_CONNECT_RETRIES", 3), 1)
+}
+
+// SkipConnectRetries makes startup ping each dependency once, for serverless
+// cold starts that must not sleep through the backoff. The health monitor
+// picks the dependency up later like after any outage.
+func SkipConnectRetries() {
+	connectOnce.Store(true)
+}
+
+// HealthCheckInterval is how often the dependencies are pinged again.
+// HEALTH_CHECK_INTERVAL (default 5s).
+func HealthCheckInterval() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("HEALTH_CHECK_INTERVAL"))
+	if err != nil || d <= 0 {
+		return 5 * time.Second
+	}
+	return d
+}
+
+func PingDb(ctx context.Context) error {
+	if Db == nil {
+		return errors.New("database is not configured")
+	}
+	return Db.Ping(ctx)
+}
+
+func PingRedis(ctx context.Context) error {
+	if Rdb == nil {
+		return errors.New("redis is not configured")
+	}
+	return Rdb.Ping(ctx).Err()
+}
+
+// connectWithRetry pings with exponential backoff (1s, 2s, 4s ...) until it
+// succeeds or ConnectRetries attempts are used
+func connectWithRetry(name string, ping func(ctx context.Context) error) error {
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		err := ping(ctx)
+		cancel()
+
+		if err == nil || attempt >= ConnectRetries() {
+			return err
+		}
+
+		slog.Warn(name+" not reachable, retrying", "attempt", attempt, "error", err, "backoff", backoff.String())
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func setReady(name string, state *atomic.Bool, err error) {
+	ready := err == nil
+	if state.Swap(ready) == ready {
+		return
+	}
+
+	if ready {
+		slog.Info(name + " is reachable again")
+	} else {
+		slog.Error(name+" is down", "error", err)
+	}
+}
+
+// CheckDependencies pings the database and redis once and updates their
+// readiness
+func CheckDependencies(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	setReady("database", &dbReady, PingDb(ctx))
+	setReady("redis", &redisReady, PingRedis(ctx))
+}
+
+// StartHealthMonitor runs CheckDependencies every HEALTH_CHECK_INTERVAL
+// until ctx is done. pgxpool and the redis client reconnect on their own,
+// the monitor only notices so requests are served again.
+func StartHealthMonitor(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(HealthCheckInterval())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				CheckDependencies(ctx)
+			}
+		}
+	}()
+}
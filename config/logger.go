@@ -0,0 +1,59 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// keys whose values never reach the logs
+var redactedKeys = []string{"password", "passwd", "token", "otp", "secret", "authorization", "cookie", "backup_code", "api_key"}
+
+const redacted = "[REDACTED]"
+
+func redact(_ []string, attr slog.Attr) slog.Attr {
+	key := strings.ToLower(attr.Key)
+	for _, k := range redactedKeys {
+		if strings.Contains(key, k) {
+			return slog.String(attr.Key, redacted)
+		}
+	}
+	return attr
+}
+
+// SetupLogger makes slog the default logger. LOG_LEVEL is debug, info
+// (default), warn or error, LOG_FORMAT is json (default) or text.
+func SetupLogger() {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(os.Getenv("LOG_LEVEL"))); err != nil {
+		level = slog.LevelInfo
+	}
+
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redact}
+
+	var handler slog.Handler = slog.NewJSONHandler(os.Stdout, opts)
+	if strings.EqualFold(os.Getenv("LOG_FORMAT"), "text") {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
+
+	slog.SetDefault(slog.New(handler))
+}
+
+type loggerKey struct{}
+
+// WithLogger stores the request logger in ctx
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Logger returns the request logger (with request_id) stored in ctx, or
+// the default logger outside of a request
+func Logger(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// OIDCProvider reads the provider settings from OIDC_<NAME>_* env,
+// ex: OIDC_GOOGLE_ISSUER, OIDC_GOOGLE_CLIENT_ID. ok is false when the
+// provider is not configured.
+func OIDCProvider(name string) (OIDCProviderConfig, bool) {
+	prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+	cfg := OIDCProviderConfig{
+		Name:         strings.ToLower(name),
+		Issuer:       os.Getenv(prefix + "ISSUER"),
+		ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+		ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+		RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+
+	if cfg.Issuer == "" || cfg.ClientID == "" {
+		return OIDCProviderConfig{}, false
+	}
+
+	if cfg.RedirectURL == "" {
+		cfg.RedirectURL = os.Getenv("APP_BASE_URL") + "/auth/oidc/" + cfg.Name + "/callback"
+	}
+
+	if scopes := os.Getenv(prefix + "SCOPES"); scopes != "" {
+		cfg.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+	}
+
+	return cfg, true
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PriceBuckets are the upper bounds of the price facet buckets, ascending.
+// PRODUCT_PRICE_BUCKETS="20000,30000,50000" (default) gives <20000,
+// 20000-29999, 30000-49999 and >=50000.
+func PriceBuckets() []int {
+	bounds := []int{20000, 30000, 50000}
+
+	raw := os.Getenv("PRODUCT_PRICE_BUCKETS")
+	if raw == "" {
+		return bounds
+	}
+
+	var parsed []int
+	for _, part := range strings.Split(raw, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || v <= 0 {
+			return bounds
+		}
+		parsed = append(parsed, v)
+	}
+	slices.Sort(parsed)
+
+	return slices.Compact(parsed)
+}
+
+func envInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v < 0 {
+		return def
+	}
+	return v
+}
+
+// BestSellerDays is the default window of /products/best-sellers and
+// sort=best_selling, 0 counts all time. BEST_SELLER_DAYS (default 30).
+func BestSellerDays() int {
+	return envInt("BEST_SELLER_DAYS", 30)
+}
+
+// TrendingDays compares sales of the last N days with the N days before.
+// TRENDING_DAYS (default 7).
+func TrendingDays() int {
+	days := envInt("TRENDING_DAYS", 7)
+	if days == 0 {
+		return 7
+	}
+	return days
+}
+
+// BestSellerRefresh is how often the background job recomputes the cached
+// best seller and trending lists. BEST_SELLER_REFRESH (default 10m).
+func BestSellerRefresh() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("BEST_SELLER_REFRESH"))
+	if err != nil || d <= 0 {
+		return 10 * time.Minute
+	}
+	return d
+}
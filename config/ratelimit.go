@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RateLimitPolicy struct {
+	Name    string
+	Limit   int
+	Window  time.Duration
+	PerUser bool
+}
+
+// RateLimit builds a policy with the given defaults, overridable with
+// RATE_LIMIT_<NAME>="<limit>/<window>" (ex: RATE_LIMIT_PRODUCTS=60/1m).
+// perUser keys the bucket by the logged in user id, falling back to client ip.
+func RateLimit(name string, limit int, window time.Duration, perUser bool) RateLimitPolicy {
+	policy := RateLimitPolicy{
+		Name:    name,
+		Limit:   limit,
+		Window:  window,
+		PerUser: perUser,
+	}
+
+	raw := os.Getenv("RATE_LIMIT_" + strings.ToUpper(name))
+	if raw == "" {
+		return policy
+	}
+
+	parts := strings.SplitN(raw, "/", 2)
+	if l, err := strconv.Atoi(strings.TrimSpace(parts[0])); err == nil && l > 0 {
+		policy.Limit = l
+	}
+	if len(parts) == 2 {
+		if w, err := time.ParseDuration(strings.TrimSpace(parts[1])); err == nil && w > 0 {
+			policy.Window = w
+		}
+	}
+
+	return policy
+}
+
+func RateLimitEnabled() bool {
+	return os.Getenv("RATE_LIMIT_ENABLED") != "false"
+}
+
+// TrustedProxies are the proxies allowed to set X-Forwarded-For, so the
+// rate limit keys on the real client ip. TRUSTED_PROXIES is a comma
+// separated list of ips / CIDRs, empty (default) trusts none and uses the
+// connection address.
+func TrustedProxies() []string {
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+// TrustedPlatform is a header set by the hosting platform with the client
+// ip (ex: X-Real-IP on Vercel, CF-Connecting-IP on Cloudflare), trusted
+// as is. TRUSTED_PLATFORM, empty by default.
+func TrustedPlatform() string {
+	return os.Getenv("TRUSTED_PLATFORM")
+}
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
 	"os"
 	"strconv"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -14,9 +18,28 @@ func Redis() {
 	redisPassword := os.Getenv("PASSWORD_REDIS")
 	dbRedis := os.Getenv("DB")
 	dbFinal,_ := strconv.Atoi(dbRedis)
+	redis.SetLogger(redisLogger{})
 	Rdb = redis.NewClient(&redis.Options{
 		Addr:     redisUrl,
 		Password: redisPassword,
 		DB:       dbFinal,
 	})
+	if err := redisotel.InstrumentTracing(Rdb); err != nil {
+		slog.Warn("redis tracing disabled", "error", err)
+	}
+
+	if err := connectWithRetry("redis", PingRedis); err != nil {
+		slog.Error("cannot ping redis, cache is skipped until it is reachable", "error", err)
+		return
+	}
+
+	redisReady.Store(true)
+	slog.Info("redis connected")
+}
+
+// redisLogger sends go-redis internal messages (dial errors, ...) to slog
+type redisLogger struct{}
+
+func (redisLogger) Printf(ctx context.Context, format string, v ...any) {
+	Logger(ctx).Warn(fmt.Sprintf(format, v...), "component", "redis")
 }
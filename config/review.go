@@ -0,0 +1,12 @@
+package config
+
+import "os"
+
+// NewReviewStatus is the status of a newly posted or edited review.
+// With REVIEW_REQUIRE_APPROVAL=true reviews wait for an admin to approve them.
+func NewReviewStatus() string {
+	if os.Getenv("REVIEW_REQUIRE_APPROVAL") == "true" {
+		return "pending"
+	}
+	return "approved"
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName names this service in traces. OTEL_SERVICE_NAME (default
+// coffeeshop-backend).
+func ServiceName() string {
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		return name
+	}
+	return "coffeeshop-backend"
+}
+
+// SetupTracing installs the global tracer provider. OTEL_TRACES_EXPORTER is
+// otlp (OTEL_EXPORTER_OTLP_ENDPOINT, http/protobuf), stdout or none; it
+// defaults to otlp when an endpoint is set and none otherwise. The returned
+// function flushes pending spans.
+func SetupTracing(ctx context.Context) func(context.Context) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	exporterName := strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER"))
+	if exporterName == "" && os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
+		exporterName = "otlp"
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch exporterName {
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return func(context.Context) error { return nil }
+	}
+	if err != nil {
+		slog.Error("tracing disabled, cannot create exporter", "exporter", exporterName, "error", err)
+		return func(context.Context) error { return nil }
+	}
+
+	res, _ := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", ServiceName()),
+	))
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	slog.Info("tracing enabled", "exporter", exporterName)
+
+	return provider.Shutdown
+}
+
+// TraceID returns the trace id of the span in ctx, empty when not traced
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
+
+// queryTracer creates a span for every query run on the pool and bounds it
+// with the query timeout. pgx runs the query with the returned context, the
+// timeout is released in TraceQueryEnd once the rows are closed.
+type queryTracer struct {
+	timeout time.Duration
+}
+
+type queryCancelKey struct{}
+
+func (t queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		ctx = context.WithValue(ctx, queryCancelKey{}, cancel)
+	}
+
+	ctx, _ = otel.Tracer("backend/config").Start(ctx, "db.query",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.query.text", data.SQL),
+		),
+	)
+	return ctx
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	if cancel, ok := ctx.Value(queryCancelKey{}).(context.CancelFunc); ok {
+		defer cancel()
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.SetAttributes(attribute.Int64("db.response.rows", data.CommandTag.RowsAffected()))
+	span.End()
+}
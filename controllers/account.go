@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"archive/zip"
+	"backend/apperr"
+	"backend/cache"
+	"backend/config"
+	"backend/lib"
+	"backend/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) exportResponse(ctx *gin.Context, userID int64) {
+	data, err := s.Accounts.Export(ctx, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	filename := fmt.Sprintf("user-%d-export-%s", userID, time.Now().Format("20060102"))
+
+	if ctx.DefaultQuery("format", "json") != "zip" {
+		ctx.Header("Content-Disposition", "attachment; filename="+filename+".json")
+		ctx.JSON(200, models.Response{
+			Success: true,
+			Message: translate(ctx, "user data export"),
+			Data:    data,
+		})
+		return
+	}
+
+	files := map[string]any{
+		"profile.json":   data.Profile,
+		"orders.json":    data.Orders,
+		"cart.json":      data.Cart,
+		"favorites.json": data.Favorites,
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(content); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", "attachment; filename="+filename+".zip")
+	ctx.Data(200, "application/zip", buf.Bytes())
+}
+
+// deleteAccount revokes the tokens first, an account is not deleted while
+// its tokens would stay valid
+func (s *Server) deleteAccount(ctx *gin.Context, userID int64) {
+	if err := lib.RevokeUserTokens(int(userID)); err != nil {
+		config.Logger(ctx).Error("revoke tokens of deleted account", "user_id", userID, "error", err)
+		serviceUnavailable(ctx, "Redis")
+		return
+	}
+
+	err := s.Accounts.Delete(ctx, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	cache.Invalidate(ctx.Request.Context(), cache.UsersTag, cache.UserTag(userID))
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "account deleted"),
+	})
+}
+
+// ExportUserData godoc
+// @Summary Export my data
+// @Description Download profile, orders, cart and favorites as JSON (default) or ZIP (?format=zip)
+// @Tags User
+// @Produce json
+// @Produce application/zip
+// @Security BearerAuth
+// @Param format query string false "json or zip"
+// @Success 200 {object} models.Response
+// @Router /user/export [get]
+func (s *Server) ExportUserData(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+	s.exportResponse(ctx, userID)
+}
+
+// DeleteAccount godoc
+// @Summary Delete my account
+// @Description Delete profile and cart, anonymize orders and revoke tokens
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.DeleteAccountRequest true "Password confirmation"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 503 {object} models.Response
+// @Router /user/account [delete]
+func (s *Server) DeleteAccount(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+
+	var req models.DeleteAccountRequest
+	_ = ctx.ShouldBindJSON(&req)
+
+	// accounts created with social login have no password to confirm
+	hashed, err := s.Users.PasswordHash(ctx, userID)
+	if err != nil {
+		ctx.Error(notFound(err, models.ErrUserNotFound))
+		return
+	}
+	if hashed != "" && !lib.VerifyPassword(req.Password, hashed) {
+		ctx.Error(apperr.Validation("wrong password"))
+		return
+	}
+
+	s.deleteAccount(ctx, userID)
+}
+
+// AdminExportUserData godoc
+// @Summary Export user data (Admin)
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param format query string false "json or zip"
+// @Success 200 {object} models.Response
+// @Router /admin/user/{id}/export [get]
+func (s *Server) AdminExportUserData(ctx *gin.Context) {
+	userID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		ctx.Error(apperr.Validation("invalid user id"))
+		return
+	}
+	s.exportResponse(ctx, userID)
+}
+
+// AdminDeleteUser godoc
+// @Summary Delete user account (Admin)
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 503 {object} models.Response
+// @Router /admin/user/{id} [delete]
+func (s *Server) AdminDeleteUser(ctx *gin.Context) {
+	userID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		ctx.Error(apperr.Validation("invalid user id"))
+		return
+	}
+
+	if userID == ctx.MustGet("user_id").(int64) {
+		ctx.Error(apperr.Validation("admin cannot delete their own account here"))
+		return
+	}
+
+	s.deleteAccount(ctx, userID)
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"archive/zip"
+	"backend/config"
+	"backend/models"
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestExportUserData(t *testing.T) {
+	s := newTestServer()
+	user, _ := s.users.Register(t.Context(), models.RegisterRequest{Email: "dina@example.com", Password: "rahasia123", Username: "dina"})
+	s.products.Create(t.Context(), models.CreateProductRequest{Name: "Americano"})
+	s.favorites.Add(t.Context(), user.ID, 1)
+	s.carts.Add(t.Context(), models.ReqCart{UserID: user.ID, ProductID: 1, Qty: 2})
+
+	r := newRouter()
+	r.GET("/user/export", asUser(user.ID, "user"), s.ExportUserData)
+	r.GET("/admin/user/:id/export", asUser(1, "admin"), s.AdminExportUserData)
+
+	w := serve(t, r, http.MethodGet, "/user/export", "")
+	assertStatus(t, w, 200)
+	_, export := decodeResponse[models.UserExport](t, w.Body.Bytes())
+	if export.Profile.Email != "dina@example.com" || len(export.Cart) != 1 || len(export.Favorites) != 1 {
+		t.Fatalf("export = %+v", export)
+	}
+
+	w = serve(t, r, http.MethodGet, "/user/export?format=zip", "")
+	assertStatus(t, w, 200)
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 4 {
+		t.Fatalf("zip has %d files, want 4", len(zr.File))
+	}
+
+	assertStatus(t, serve(t, r, http.MethodGet, "/admin/user/x/export", ""), 400)
+	assertStatus(t, serve(t, r, http.MethodGet, "/admin/user/99/export", ""), 404)
+	assertStatus(t, serve(t, r, http.MethodGet, "/admin/user/1/export", ""), 200)
+}
+
+// the account is only deleted after its tokens are revoked, without Redis
+// nothing is deleted
+func TestDeleteAccountWithoutRedis(t *testing.T) {
+	previous := config.Rdb
+	config.Rdb = redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	t.Cleanup(func() {
+		config.Rdb.Close()
+		config.Rdb = previous
+	})
+
+	s := newTestServer()
+	user, _ := s.users.Register(t.Context(), models.RegisterRequest{Email: "dina@example.com", Password: "rahasia123", Username: "dina"})
+
+	r := newRouter()
+	r.DELETE("/user/account", asUser(user.ID, "user"), s.DeleteAccount)
+	r.DELETE("/admin/user/:id", asUser(user.ID, "admin"), s.AdminDeleteUser)
+
+	assertStatus(t, serve(t, r, http.MethodDelete, "/user/account", `{"password": "salah"}`), 400)
+	assertStatus(t, serve(t, r, http.MethodDelete, "/admin/user/1", ""), 400)
+
+	assertStatus(t, serve(t, r, http.MethodDelete, "/user/account", `{"password": "rahasia123"}`), 503)
+	if _, err := s.users.Get(t.Context(), user.ID); err != nil {
+		t.Fatalf("account deleted without revoking its tokens: %v", err)
+	}
+}
@@ -1,11 +1,15 @@
 package controllers
 
 import (
+	"backend/apperr"
+	"backend/cache"
 	"backend/lib"
 	"backend/models"
+	"context"
 	"net/url"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,10 +20,11 @@ import (
 // @Tags Admin - Orders
 // @Produce json
 // @Security BearerAuth
+// @Param cursor query string false "Cursor pagination, empty for the first page then next_cursor / prev_cursor"
 // @Success 200 {object} models.Response
 // @Failure 500 {object} models.Response
 // @Router /admin/orders [get]
-func AdminOrderList(ctx *gin.Context) {
+func (s *Server) AdminOrderList(ctx *gin.Context) {
 	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
 
@@ -27,15 +32,32 @@ func AdminOrderList(ctx *gin.Context) {
 		page = 1
 	}
 
-	orders, totalItems, err := models.GetAllOrders(page, limit)
-	if err != nil {
-		ctx.JSON(500, models.Response{
-			Success: false,
-			Message: err.Error(),
+	cursor, cursorMode, ok := cursorParam(ctx)
+	if !ok {
+		return
+	}
+	if cursorMode {
+		orders, next, prev, err := s.Orders.ListCursor(ctx, cursor, limit)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(200, models.Response{
+			Success:    true,
+			Message:    translate(ctx, "list all order"),
+			Pagination: cursorPagination(ctx, limit, next, prev),
+			Data:       orders,
 		})
 		return
 	}
 
+	orders, totalItems, err := s.Orders.List(ctx, page, limit)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
 	totalPage := int((totalItems + int64(limit) - 1) / int64(limit))
 
 	rawQuery := ctx.Request.URL.Query()
@@ -52,7 +74,7 @@ func AdminOrderList(ctx *gin.Context) {
 
 	ctx.JSON(200, models.Response{
 		Success:    true,
-		Message:    "list all order",
+		Message:    translate(ctx, "list all order"),
 		Pagination: pagination,
 		Data:       orders,
 	})
@@ -71,118 +93,118 @@ func AdminOrderList(ctx *gin.Context) {
 // @Failure 400 {object} models.Response
 // @Failure 500 {object} models.Response
 // @Router /admin/orders/{id}/status [put]
-func UpdateOrderStatus(ctx *gin.Context) {
+func (s *Server) UpdateOrderStatus(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	orderID, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(400, models.Response{Success: false, Message: "Invalid order ID"})
+		ctx.Error(apperr.Validation("Invalid order ID"))
 		return
 	}
 
 	var req models.UpdateOrderStatusRequest
-	if err := ctx.BindJSON(&req); err != nil {
-		ctx.JSON(400, models.Response{Success: false, Message: err.Error()})
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperr.Binding(err))
 		return
 	}
 
-	err = models.UpdateOrderStatus(int64(orderID), req.Status)
+	err = s.Orders.UpdateStatus(ctx, int64(orderID), req.Status)
 	if err != nil {
-		ctx.JSON(500, models.Response{Success: false, Message: err.Error()})
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(200, models.Response{
 		Success: true,
-		Message: "Order status updated",
+		Message: translate(ctx, "Order status updated"),
 	})
 }
 
 
 // create category
-func CreateCategoryController(ctx *gin.Context){
+func (s *Server) CreateCategoryController(ctx *gin.Context){
 	var c models.Categories
 	if err := ctx.ShouldBindJSON(&c); err !=nil {
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		ctx.Error(apperr.Binding(err))
 		return
 	}
 
-	category, err := models.CreateCategory(c.Name)
+	category, err := s.Categories.Create(ctx, c)
 	if err != nil {
-		ctx.JSON(500, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
+	cache.Invalidate(ctx.Request.Context(), cache.CategoriesTag)
+
 	ctx.JSON(201, models.Response{
 		Success: true,
-		Message: "Category Created",
+		Message: translate(ctx, "Category Created"),
 		Data: category,
 	})
 }
 
 // get all categories
-func GetAllCategoriesController(c *gin.Context) {
-	data, err := models.GetAllCategories()
-	if err != nil {
-		c.JSON(500, models.Response{
-			Success: false,
-			Message: err.Error(),
+func (s *Server) GetAllCategoriesController(c *gin.Context) {
+	data, _, err := cache.Remember(c.Request.Context(), "categories", time.Hour,
+		[]string{cache.CategoriesTag}, func(loadCtx context.Context) ([]models.Categories, error) {
+			return s.Categories.List(loadCtx)
 		})
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(200, models.Response{
 		Success: true,
-		Message: "list all categories",
+		Message: translate(c, "list all categories"),
 		Data:    data,
 	})
 }
 
 
 // update category
-func UpdateCategoryController(ctx *gin.Context) {
+func (s *Server) UpdateCategoryController(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, _ := strconv.Atoi(idStr)
 
 	var body models.Categories
 
 	if err := ctx.ShouldBindJSON(&body); err != nil {
-		ctx.JSON(400, models.Response{Success: false, Message: err.Error()})
+		ctx.Error(apperr.Binding(err))
 		return
 	}
 
-	updated, err := models.UpdateCategory(id, body.Name)
+	updated, err := s.Categories.Update(ctx, id, body)
 	if err != nil {
-		ctx.JSON(500, models.Response{Success: false, Message: err.Error()})
+		ctx.Error(err)
 		return
 	}
 
+	cache.Invalidate(ctx.Request.Context(), cache.CategoriesTag)
+
 	ctx.JSON(200, models.Response{
 		Success: true,
-		Message: "Category updated",
+		Message: translate(ctx, "Category updated"),
 		Data:    updated,
 	})
 }
 
 
 // delete 
-func DeleteCategoryController(ctx *gin.Context) {
+func (s *Server) DeleteCategoryController(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, _ := strconv.Atoi(idStr)
 
-	err := models.DeleteCategory(id)
+	err := s.Categories.Delete(ctx, id)
 	if err != nil {
-		ctx.JSON(500, models.Response{Success: false, Message: err.Error()})
+		ctx.Error(err)
 		return
 	}
 
+	cache.Invalidate(ctx.Request.Context(), cache.CategoriesTag)
+
 	ctx.JSON(200, models.Response{
 		Success: true,
-		Message: "Category deleted",
+		Message: translate(ctx, "Category deleted"),
 	})
 }
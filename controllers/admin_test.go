@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"backend/models"
+	"net/http"
+	"testing"
+)
+
+func TestCategories(t *testing.T) {
+	s := newTestServer()
+
+	r := newRouter()
+	r.GET("/categories", s.GetAllCategoriesController)
+	admin := r.Group("/admin/category", asUser(1, "admin"))
+	admin.POST("", s.CreateCategoryController)
+	admin.PUT("/:id", s.UpdateCategoryController)
+	admin.DELETE("/:id", s.DeleteCategoryController)
+
+	assertStatus(t, serve(t, r, http.MethodPost, "/admin/category", `{}`), 400)
+	assertStatus(t, serve(t, r, http.MethodPost, "/admin/category", `{"name": "Coffee"}`), 201)
+	assertStatus(t, serve(t, r, http.MethodPost, "/admin/category", `{"name": "Non Coffee"}`), 201)
+	assertStatus(t, serve(t, r, http.MethodPost, "/admin/category", `{"name": "Coffee"}`), 409)
+
+	assertStatus(t, serve(t, r, http.MethodPut, "/admin/category/99", `{"name": "Tea"}`), 404)
+	assertStatus(t, serve(t, r, http.MethodPut, "/admin/category/2", `{"name": "Tea"}`), 200)
+	assertStatus(t, serve(t, r, http.MethodDelete, "/admin/category/1", ""), 200)
+
+	w := serve(t, r, http.MethodGet, "/categories", "")
+	assertStatus(t, w, 200)
+	if _, categories := decodeResponse[[]models.Categories](t, w.Body.Bytes()); len(categories) != 1 || categories[0].Name != "Tea" {
+		t.Fatalf("categories = %+v", categories)
+	}
+}
@@ -1,11 +1,12 @@
 package controllers
 
 import (
+	"backend/apperr"
+	"backend/cache"
 	"backend/config"
 	"backend/lib"
 	"backend/models"
 	"context"
-	"encoding/json"
 	"fmt"
 	"math/rand"
 	"net/url"
@@ -19,6 +20,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+var errWrongCredentials = apperr.Unauthorized("wrong email or password")
+
 // RegisterUser godoc
 // @Summary Register a new user
 // @Description Create a new user account
@@ -29,14 +32,11 @@ import (
 // @Success 200 {object} models.Response
 // @Failure 400 {object} models.Response
 // @Router /auth/register [post]
-func RegisterUser(ctx *gin.Context) {
+func (s *Server) RegisterUser(ctx *gin.Context) {
 	var req models.RegisterRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		ctx.Error(apperr.Binding(err))
 		return
 	}
 
@@ -53,24 +53,23 @@ func RegisterUser(ctx *gin.Context) {
 		}
 	}
 
-	user, err := models.Register(req)
+	user, err := s.Users.Register(ctx, req)
 	if err != nil {
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
-	redisCtx := context.Background()
-	iter := config.Rdb.Scan(redisCtx, 0, "/admin/user*", 0).Iterator()
-	for iter.Next(redisCtx) {
-		config.Rdb.Del(redisCtx, iter.Val())
+	cache.Invalidate(ctx.Request.Context(), cache.UsersTag)
+
+	message := "Register success, please check your email to verify your account"
+	if err := s.sendVerificationEmail(user); err != nil {
+		config.Logger(ctx).Error("send verification email", "user_id", user.ID, "error", err)
+		message = "Register success, but failed to send verification email"
 	}
 
 	ctx.JSON(200, models.Response{
 		Success: true,
-		Message: "Register success",
+		Message: translate(ctx, message),
 		Data:    user,
 	})
 }
@@ -85,45 +84,25 @@ func RegisterUser(ctx *gin.Context) {
 // @Success 200 {object} models.Response
 // @Failure 400 {object} models.Response
 // @Router /auth/login [post]
-func LoginUser(ctx *gin.Context) {
+func (s *Server) LoginUser(ctx *gin.Context) {
 	var req models.LoginRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		ctx.Error(apperr.Binding(err))
 		return
 	}
 
-	user, err := models.Login(req.Email)
+	user, err := s.Users.FindByEmail(ctx, req.Email)
 	if err != nil {
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: "wrong email or password",
-		})
+		ctx.Error(notFound(err, errWrongCredentials))
 		return
 	}
 
 	if !lib.VerifyPassword(req.Password, user.Password) {
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: "wrong email or password",
-		})
+		ctx.Error(errWrongCredentials)
 		return
 	}
-	intId := int(user.ID)
-	token := lib.GeneratedTokens(intId, user.Role)
-
-	ctx.JSON(200, models.Response{
-		Success: true,
-		Message: "Login success",
-		Data: map[string]any{
-			"user":  user,
-			"token": token,
-		},
-	})
-
+	s.loginResponse(ctx, user)
 }
 
 // UpdateUser godoc
@@ -138,115 +117,110 @@ func LoginUser(ctx *gin.Context) {
 // @Failure 400 {object} models.Response
 // @Failure 403 {object} models.Response
 // @Router /user/{id} [put]
-func AdminUpdateUser(ctx *gin.Context) {
+func (s *Server) AdminUpdateUser(ctx *gin.Context) {
     idParam := ctx.Param("id")
     targetID, err := strconv.ParseInt(idParam, 10, 64)
     if err != nil {
-        ctx.JSON(400, models.Response{Success: false, Message: "invalid user id"})
+        ctx.Error(apperr.Validation("invalid user id"))
         return
     }
 
     role := ctx.MustGet("role").(string)
     if role != "admin" {
-        ctx.JSON(403, models.Response{
-            Success: false,
-            Message: "only admin can update user",
-        })
+        ctx.Error(apperr.Forbidden("only admin can update user"))
         return
     }
 
     var req models.AdminUpdateUserRequest
     if err := ctx.ShouldBindJSON(&req); err != nil {
-        ctx.JSON(400, models.Response{Success: false, Message: err.Error()})
+        ctx.Error(apperr.Binding(err))
         return
     }
 
-    updated, err := models.AdminUpdateUserByID(targetID, req)
+    updated, err := s.Users.AdminUpdate(ctx, targetID, req)
     if err != nil {
-        ctx.JSON(500, models.Response{Success: false, Message: err.Error()})
+        ctx.Error(err)
         return
     }
 
-    redisCtx := context.Background()
-	iter := config.Rdb.Scan(redisCtx, 0, "/admin/user*", 0).Iterator()
-	for iter.Next(redisCtx) {
-		config.Rdb.Del(redisCtx, iter.Val())
-	}
+    cache.Invalidate(ctx.Request.Context(), cache.UsersTag)
 
     ctx.JSON(200, models.Response{
         Success: true,
-        Message: "admin update user successfully",
+        Message: translate(ctx, "admin update user successfully"),
         Data:    updated,
     })
 }
 
 //user
-func UploadUserPicture(ctx *gin.Context) {
+func (s *Server) UploadUserPicture(ctx *gin.Context) {
 	userID := ctx.MustGet("user_id").(int64)
 
 	file, err := ctx.FormFile("picture")
 	if err != nil {
-		ctx.JSON(400, models.Response{Success: false, Message: "file not provided"})
+		ctx.Error(apperr.Validation("file not provided"))
 		return
 	}
 
 	ext := strings.ToLower(filepath.Ext(file.Filename))
 	allowed := []string{".jpg", ".jpeg", ".png"}
 	if !slices.Contains(allowed, ext) {
-		ctx.JSON(400, models.Response{Success: false, Message: "invalid file format"})
+		ctx.Error(apperr.Validation("invalid file format"))
 		return
 	}
 
 	src, err := file.Open()
 	if err != nil {
-		ctx.JSON(500, models.Response{Success: false, Message: "cannot open file: " + err.Error()})
+		ctx.Error(apperr.Internal("cannot open file").Wrap(err))
 		return
 	}
 	defer src.Close()
 
 	uploadedURL, err := lib.UploadImage(src)
 	if err != nil {
-		ctx.JSON(500, models.Response{Success: false, Message: "failed upload to cloudinary: " + err.Error()})
+		ctx.Error(apperr.Internal("failed upload to cloudinary").Wrap(err))
 		return
 	}
 
-	if err := models.UpdateUserProfilePicture(userID, uploadedURL); err != nil {
-		ctx.JSON(500, models.Response{Success: false, Message: err.Error()})
+	if err := s.Users.SetProfilePicture(ctx, userID, uploadedURL); err != nil {
+		ctx.Error(err)
 		return
 	}
 
+	cache.Invalidate(ctx.Request.Context(), cache.UsersTag)
+
 	ctx.JSON(200, models.Response{
 		Success: true,
-		Message: "upload success",
+		Message: translate(ctx, "upload success"),
 		Data:    gin.H{"profile_picture": uploadedURL},
 	})
 }
 
 //admin
-func AdminUploadUserPicture(ctx *gin.Context) {
+func (s *Server) AdminUploadUserPicture(ctx *gin.Context) {
 	role := ctx.MustGet("role").(string)
 	if role != "admin" {
-		ctx.JSON(403, models.Response{Success: false, Message: "forbidden"})
+		ctx.Error(apperr.Forbidden("forbidden"))
 		return
 	}
 
 	param := ctx.Param("id")
 	targetUserID, err := strconv.ParseInt(param, 10, 64)
 	if err != nil || targetUserID <= 0 {
-		ctx.JSON(400, models.Response{Success: false, Message: "invalid user id"})
+		ctx.Error(apperr.Validation("invalid user id"))
 		return
 	}
 
 	file, err := ctx.FormFile("picture")
 	if err != nil {
-		ctx.JSON(400, models.Response{Success: false, Message: "file not provided"})
+		ctx.Error(apperr.Validation("file not provided"))
 		return
 	}
 
 	ext := strings.ToLower(filepath.Ext(file.Filename))
 	allowed := []string{".jpg", ".jpeg", ".png"}
 	if !slices.Contains(allowed, ext) {
-		ctx.JSON(400, models.Response{Success: false, Message: "invalid file format"})
+		ctx.Error(apperr.Validation("invalid file format"))
 		return
 	}
 
@@ -255,19 +229,21 @@ func AdminUploadUserPicture(ctx *gin.Context) {
 	os.MkdirAll("./uploads/profile", 0755)
 
 	if err := ctx.SaveUploadedFile(file, uploadPath); err != nil {
-		ctx.JSON(500, models.Response{Success: false, Message: "failed to save file"})
+		ctx.Error(apperr.Internal("failed to save file").Wrap(err))
 		return
 	}
 
-	if err := models.AdminUpdateUserProfilePicture(targetUserID, newFilename); err != nil {
+	if err := s.Users.AdminSetProfilePicture(ctx, targetUserID, newFilename); err != nil {
 		os.Remove(uploadPath)
-		ctx.JSON(500, models.Response{Success: false, Message: err.Error()})
+		ctx.Error(err)
 		return
 	}
 
+	cache.Invalidate(ctx.Request.Context(), cache.UsersTag)
+
 	ctx.JSON(200, models.Response{
 		Success: true,
-		Message: "admin updated user picture successfully",
+		Message: translate(ctx, "admin updated user picture successfully"),
 		Data: gin.H{"profile_picture": newFilename},
 	})
 }
@@ -278,33 +254,49 @@ func AdminUploadUserPicture(ctx *gin.Context) {
 // @Description Only admin can view all user list
 // @Tags Admin
 // @Produce json
+// @Param cursor query string false "Cursor pagination, empty for the first page then next_cursor / prev_cursor"
 // @Success 200 {object} models.Response
 // @Failure 400 {object} models.Response
 // @Router /admin/users [get]
-func ListUser(ctx *gin.Context) {
+func (s *Server) ListUser(ctx *gin.Context) {
 	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
 
 	if page < 1 {
 		page = 1
 	}
+	if limit < 1 {
+		limit = 10
+	}
 
-	key := fmt.Sprintf("%s?page=%d&limit=%d", ctx.Request.URL.Path, page, limit)
-	
-	var users []models.ListUserStruct
-	var totalItems int64
-
-	cache, err := config.Rdb.Get(context.Background(), key).Result()
-	if err != nil || cache == "" {
-		users, totalItems, err = models.ListUser(page, limit)
+	cursor, cursorMode, ok := cursorParam(ctx)
+	if !ok {
+		return
+	}
+	if cursorMode {
+		users, next, prev, err := s.Users.ListCursor(ctx, cursor, limit)
 		if err != nil {
-			ctx.JSON(400, models.Response{
-				Success: false,
-				Message: err.Error(),
-			})
+			ctx.Error(err)
 			return
 		}
 
+		ctx.JSON(200, models.Response{
+			Success:    true,
+			Message:    translate(ctx, "success data from db"),
+			Pagination: cursorPagination(ctx, limit, next, prev),
+			Data:       users,
+		})
+		return
+	}
+
+	key := fmt.Sprintf("users:page:%d:limit:%d", page, limit)
+
+	response, fromCache, err := cache.Remember(ctx.Request.Context(), key, time.Minute, []string{cache.UsersTag}, func(loadCtx context.Context) (models.Response, error) {
+		users, totalItems, err := s.Users.List(loadCtx, page, limit)
+		if err != nil {
+			return models.Response{}, err
+		}
+
 		totalPage := int((totalItems + int64(limit) - 1) / int64(limit))
 
 		rawQuery := ctx.Request.URL.Query()
@@ -319,129 +311,132 @@ func ListUser(ctx *gin.Context) {
 		links := lib.Hateoas(baseURL, path, page, limit, totalPage, extraQuery)
 		pagination := lib.Pagination(page, limit, totalPage, totalItems, links)
 
-		response := models.Response{
+		return models.Response{
 			Success:    true,
-			Message:    "success data from db",
+			Message:    translate(ctx, "success data from db"),
 			Pagination: pagination,
 			Data:       users,
-		}
-
-		data, _ := json.Marshal(response)
-		config.Rdb.Set(context.Background(), key, data, 5*time.Second)
-
-		ctx.JSON(200, response)
+		}, nil
+	})
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	var cachedResponse models.Response
-	json.Unmarshal([]byte(cache), &cachedResponse)
-	cachedResponse.Message = "data from cache"
-	
-	ctx.JSON(200, cachedResponse)
+	if fromCache {
+		response.Message = "data from cache"
+	}
+
+	ctx.JSON(200, response)
 }
 
 
-func UserProfile(ctx *gin.Context){
+func (s *Server) UserProfile(ctx *gin.Context){
 	userData,_ := ctx.Get("user")
 	user := userData.(lib.UserPayload)
 
-	profile, err := models.GetUserProfile(int64(user.Id))
+	profile, err := s.Users.Profile(ctx, int64(user.Id))
 	if err != nil{
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 	ctx.JSON(200, models.Response{
 		Success: true,
-		Message: "success get user profile",
+		Message: translate(ctx, "success get user profile"),
 		Data: profile,
 	})
 }
 
-func UpdateProfile(ctx *gin.Context) {
+func (s *Server) UpdateProfile(ctx *gin.Context) {
 	userID := ctx.MustGet("user_id").(int64)
 
 	var req models.UpdateUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		ctx.Error(apperr.Binding(err))
 		return
 	}
 
-	updated, err := models.UpdateUserByID(userID, req)
+	updated, err := s.Users.UpdateProfile(ctx, userID, req)
 	if err != nil {
-		ctx.JSON(500, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
+	cache.Invalidate(ctx.Request.Context(), cache.UsersTag)
+
 	ctx.JSON(200, models.Response{
 		Success: true,
-		Message: "profile updated successfully",
+		Message: translate(ctx, "profile updated successfully"),
 		Data:    updated,
 	})
 }
 
 
-func ForgotPassword(c *gin.Context) {
+func (s *Server) ForgotPassword(c *gin.Context) {
 	var body struct {
-		Email string `json:"email"`
+		Email string `json:"email" binding:"required,email"`
 	}
 
-	if err := c.BindJSON(&body); err != nil {
-		c.JSON(400, gin.H{"message": "invalid request"})
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(apperr.Binding(err))
 		return
 	}
 
-	user, _ := models.Forgot(body.Email)
-
 	otp := fmt.Sprintf("%06d", rand.Intn(999999))
 
-	config.Rdb.Set(context.Background(), "otp:"+user.Email, otp, 10*time.Minute)
+	// same response whether the email exists or not, the OTP of an unknown
+	// email is just never stored
+	user, err := s.Users.FindByEmail(c, body.Email)
+	switch {
+	case apperr.KindOf(err) == apperr.KindNotFound:
+	case err != nil:
+		c.Error(err)
+		return
+	default:
+		if err := s.Tokens.SaveOTP(context.Background(), user.Email, otp, 10*time.Minute); err != nil {
+			serviceUnavailable(c, "Redis")
+			return
+		}
+	}
 
-	c.JSON(200, gin.H{
-		"message": "OTP created (dev mode)",
-		"otp":     otp,
+	c.JSON(200, models.Response{
+		Success: true,
+		Message: translate(c, "OTP created (dev mode)"),
+		Data:    map[string]string{"otp": otp},
 	})
 }
 
-func ResetPassword(c *gin.Context) {
+func (s *Server) ResetPassword(c *gin.Context) {
 	var body struct {
-		OTP     string `json:"otp"`
-		NewPass string `json:"new_password"`
+		OTP     string `json:"otp" binding:"required"`
+		NewPass string `json:"new_password" binding:"required,password"`
 	}
 
-	if err := c.BindJSON(&body); err != nil {
-		c.JSON(400, gin.H{"message": "invalid request"})
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(apperr.Binding(err))
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
-	keys, _ := config.Rdb.Keys(ctx, "otp:*").Result()
-
-	var email string
-	for _, key := range keys {
-		val, _ := config.Rdb.Get(ctx, key).Result()
-		if val == body.OTP {
-			email = strings.TrimPrefix(key, "otp:")
-			break
-		}
+	email, found, err := s.Tokens.FindOTP(ctx, body.OTP)
+	if err != nil {
+		serviceUnavailable(c, "Redis")
+		return
 	}
-
-	if email == "" {
-		c.JSON(400, gin.H{"message": "invalid or expired OTP"})
+	if !found {
+		c.Error(apperr.Validation("invalid or expired OTP"))
 		return
 	}
 	hash := lib.HashPassword(body.NewPass)
-	config.Db.Exec(ctx, `UPDATE users SET password=$1 WHERE email=$2`, hash, email)
-	config.Rdb.Del(ctx, "otp:"+email)
+	if err := s.Users.SetPassword(ctx, email, string(hash)); err != nil {
+		c.Error(err)
+		return
+	}
+	s.Tokens.DeleteOTP(ctx, email)
 
-	c.JSON(200, gin.H{"message": "password updated"})
+	c.JSON(200, models.Response{
+		Success: true,
+		Message: translate(c, "password updated"),
+	})
 }
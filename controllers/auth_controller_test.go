@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"backend/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLoginUser(t *testing.T) {
+	t.Setenv("APP_SECRET", "test-secret")
+
+	s := newTestServer()
+	s.users.Register(t.Context(), models.RegisterRequest{Email: "dina@example.com", Password: "rahasia123", Username: "dina"})
+
+	r := newRouter()
+	r.POST("/auth/login", s.LoginUser)
+
+	assertStatus(t, serve(t, r, http.MethodPost, "/auth/login", `{"email": "dina@example.com", "password": "salah"}`), 401)
+	assertStatus(t, serve(t, r, http.MethodPost, "/auth/login", `{"email": "nobody@example.com", "password": "rahasia123"}`), 401)
+
+	w := serve(t, r, http.MethodPost, "/auth/login", `{"email": "dina@example.com", "password": "rahasia123"}`)
+	assertStatus(t, w, 200)
+	if _, data := decodeResponse[map[string]any](t, w.Body.Bytes()); data["token"] == "" || data["token"] == nil {
+		t.Fatalf("login data = %+v", data)
+	}
+}
+
+func TestUserProfile(t *testing.T) {
+	s := newTestServer()
+	user, _ := s.users.Register(t.Context(), models.RegisterRequest{Email: "dina@example.com", Password: "rahasia123", Username: "dina"})
+
+	r := newRouter()
+	profile := r.Group("/user", asUser(user.ID, "user"))
+	profile.GET("/profile", s.UserProfile)
+	profile.PUT("/profile/update", s.UpdateProfile)
+
+	assertStatus(t, serve(t, r, http.MethodPut, "/user/profile/update", `{"username": "d"}`), 400)
+	assertStatus(t, serve(t, r, http.MethodPut, "/user/profile/update", `{"username": "dina_k", "address": "Jl. Kopi 1"}`), 200)
+
+	w := serve(t, r, http.MethodGet, "/user/profile", "")
+	assertStatus(t, w, 200)
+	if _, p := decodeResponse[models.ListUserStruct](t, w.Body.Bytes()); p.Username != "dina_k" || p.Address != "Jl. Kopi 1" {
+		t.Fatalf("profile = %+v", p)
+	}
+}
+
+func TestRegisterDuplicateEmail(t *testing.T) {
+	s := newTestServer()
+	s.users.Register(t.Context(), models.RegisterRequest{Email: "dina@example.com", Password: "rahasia123", Username: "dina"})
+
+	r := newRouter()
+	r.POST("/auth/register", s.RegisterUser)
+
+	body := `{"email": "dina@example.com", "password": "rahasia123", "username": "dina2"}`
+	w := serve(t, r, http.MethodPost, "/auth/register", body)
+	assertStatus(t, w, 409)
+	if res, _ := decodeResponse[any](t, w.Body.Bytes()); res.Message != "email already exists" {
+		t.Fatalf("message = %q", res.Message)
+	}
+
+	w = serveWithHeader(t, r, http.MethodPost, "/auth/register", body, http.Header{"Accept": {"application/problem+json"}})
+	assertStatus(t, w, 409)
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/problem+json") {
+		t.Fatalf("content type = %q", ct)
+	}
+
+	var problem struct {
+		Status int    `json:"status"`
+		Title  string `json:"title"`
+		Errors []struct {
+			Field string `json:"field"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Status != 409 || problem.Title != "Conflict" || len(problem.Errors) != 1 || problem.Errors[0].Field != "email" {
+		t.Fatalf("problem = %s", w.Body)
+	}
+	if strings.Contains(w.Body.String(), "dina@example.com") {
+		t.Fatalf("problem leaks the value: %s", w.Body)
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	s := newTestServer()
+	s.users.Register(t.Context(), models.RegisterRequest{Email: "dina@example.com", Password: "rahasia123", Username: "dina"})
+
+	r := newRouter()
+	r.POST("/auth/forgot-password", s.ForgotPassword)
+	r.POST("/auth/reset-password", s.ResetPassword)
+
+	// an unknown email gets the same answer, nothing is stored
+	assertStatus(t, serve(t, r, http.MethodPost, "/auth/forgot-password", `{"email": "nobody@example.com"}`), 200)
+	if len(s.tokens.otps) != 0 {
+		t.Fatalf("otps = %v, want none", s.tokens.otps)
+	}
+
+	assertStatus(t, serve(t, r, http.MethodPost, "/auth/forgot-password", `{"email": "dina@example.com"}`), 200)
+	otp := s.tokens.otps["dina@example.com"]
+	if otp == "" {
+		t.Fatal("otp not stored")
+	}
+
+	assertStatus(t, serve(t, r, http.MethodPost, "/auth/reset-password", `{"otp": "x`+otp+`", "new_password": "Rahasia456"}`), 400)
+	assertStatus(t, serve(t, r, http.MethodPost, "/auth/reset-password", `{"otp": "`+otp+`", "new_password": "Rahasia456"}`), 200)
+	if _, ok := s.tokens.otps["dina@example.com"]; ok {
+		t.Fatal("otp kept after reset")
+	}
+
+	s.users.err = context.DeadlineExceeded
+	assertStatus(t, serve(t, r, http.MethodPost, "/auth/forgot-password", `{"email": "dina@example.com"}`), 504)
+}
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"backend/apperr"
+	"backend/cache"
+	"backend/config"
+	"backend/i18n"
+	"backend/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	rankingDefaultLimit = 10
+	rankingLease        = "ranking-refresh"
+)
+
+type rankingFunc func(ctx context.Context, days, categoryID, limit int) ([]models.Product, error)
+
+func (s *Server) rankings() map[string]rankingFunc {
+	return map[string]rankingFunc{
+		"best-sellers": s.Products.BestSellers,
+		"trending":     s.Products.Trending,
+	}
+}
+
+func rankingKey(kind, locale string, days, categoryID, limit int) string {
+	return fmt.Sprintf("products:%s:%s:days:%d:category:%d:limit:%d", kind, locale, days, categoryID, limit)
+}
+
+// refreshRanking computes a ranking in the locale of ctx and stores it, the
+// cache outlives one refresh interval so readers never wait on the job
+func (s *Server) refreshRanking(ctx context.Context, kind string, days, categoryID, limit int) ([]models.Product, error) {
+	products, err := s.rankings()[kind](ctx, days, categoryID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Set(ctx, rankingKey(kind, i18n.FromContext(ctx), days, categoryID, limit), products, 2*config.BestSellerRefresh(),
+		cache.ProductsTag, cache.CategoriesTag)
+
+	return products, nil
+}
+
+// RefreshRankings recomputes the default best seller and trending lists,
+// for all products and per category, in every locale
+func (s *Server) RefreshRankings(ctx context.Context) error {
+	categories, err := s.Categories.IDs(ctx)
+	if err != nil {
+		return err
+	}
+	categories = append([]int{0}, categories...)
+
+	windows := map[string]int{
+		"best-sellers": config.BestSellerDays(),
+		"trending":     config.TrendingDays(),
+	}
+	for _, lang := range i18n.Locales {
+		localeCtx := i18n.WithLocale(ctx, lang)
+		for kind, days := range windows {
+			for _, categoryID := range categories {
+				if _, err := s.refreshRanking(localeCtx, kind, days, categoryID, rankingDefaultLimit); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// acquireRankingLease lets one instance refresh the rankings per interval,
+// the others skip the tick. The lease ends shortly before the next tick so
+// the holder does not block itself. Without Redis the rankings cannot be
+// stored, nobody refreshes.
+func (s *Server) acquireRankingLease(ctx context.Context, interval time.Duration) bool {
+	if !config.RedisReady() {
+		return false
+	}
+
+	ok, err := s.Tokens.AcquireLease(ctx, rankingLease, interval*9/10)
+	if err != nil {
+		slog.Warn("ranking refresh lease", "error", err)
+		return false
+	}
+	return ok
+}
+
+// StartRankingRefresh runs RefreshRankings now and then every
+// BEST_SELLER_REFRESH until ctx is done, on one instance at a time
+func (s *Server) StartRankingRefresh(ctx context.Context) {
+	if config.Db == nil {
+		slog.Warn("best seller refresh disabled: no database connection")
+		return
+	}
+
+	go func() {
+		interval := config.BestSellerRefresh()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if config.DbReady() && s.acquireRankingLease(ctx, interval) {
+				if err := s.RefreshRankings(ctx); err != nil {
+					slog.Error("refresh best sellers", "error", err)
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *Server) rankingResponse(ctx *gin.Context, kind string, defaultDays, minDays int) {
+	days, err := strconv.Atoi(ctx.DefaultQuery("days", strconv.Itoa(defaultDays)))
+	if err != nil || days < minDays || days > 365 {
+		ctx.Error(apperr.Newf(apperr.KindValidation, "days must be between %d and 365", minDays))
+		return
+	}
+
+	categoryID, _ := strconv.Atoi(ctx.DefaultQuery("category", "0"))
+	if categoryID < 0 {
+		categoryID = 0
+	}
+
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(rankingDefaultLimit)))
+	if limit < 1 || limit > 50 {
+		limit = rankingDefaultLimit
+	}
+
+	if products, ok := cache.Get[[]models.Product](ctx.Request.Context(), rankingKey(kind, locale(ctx), days, categoryID, limit)); ok {
+		s.markFavorited(ctx, products)
+		ctx.JSON(200, models.Response{
+			Success: true,
+			Message: translate(ctx, kind+" ( from cache )"),
+			Data:    products,
+		})
+		return
+	}
+
+	products, err := s.refreshRanking(ctx.Request.Context(), kind, days, categoryID, limit)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	s.markFavorited(ctx, products)
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, kind),
+		Data:    products,
+	})
+}
+
+// BestSellers godoc
+// @Summary Best selling products
+// @Description Products ranked by quantity sold, sold is the quantity in the window
+// @Tags Products
+// @Produce json
+// @Param days query int false "Window in days, 0 for all time (default BEST_SELLER_DAYS)"
+// @Param category query int false "Category id"
+// @Param limit query int false "Max products (default 10, max 50)"
+// @Success 200 {object} models.Response
+// @Router /products/best-sellers [get]
+func (s *Server) BestSellers(ctx *gin.Context) {
+	s.rankingResponse(ctx, "best-sellers", config.BestSellerDays(), 0)
+}
+
+// TrendingProducts godoc
+// @Summary Trending products
+// @Description Products selling more in the last days than in the same period before
+// @Tags Products
+// @Produce json
+// @Param days query int false "Window in days (default TRENDING_DAYS)"
+// @Param category query int false "Category id"
+// @Param limit query int false "Max products (default 10, max 50)"
+// @Success 200 {object} models.Response
+// @Router /products/trending [get]
+func (s *Server) TrendingProducts(ctx *gin.Context) {
+	s.rankingResponse(ctx, "trending", config.TrendingDays(), 1)
+}
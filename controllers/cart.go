@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"backend/apperr"
 	"backend/lib"
 	"backend/models"
 	"strconv"
@@ -8,67 +9,50 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AddToCart(ctx *gin.Context) {
+func (s *Server) AddToCart(ctx *gin.Context) {
 	userData, _ := ctx.Get("user")
 	user := userData.(lib.UserPayload)
 	userID := int64(user.Id)
 
 	var req models.ReqCart
-	if err := ctx.BindJSON(&req); err != nil {
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
-		return
-	}
-
-	if req.Qty <= 0 {
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: "Quantity must be greater than 0",
-		})
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperr.Binding(err))
 		return
 	}
 
 	req.UserID = userID
 
-	cartID, err := models.AddToCart(req)
+	cartID, err := s.Carts.Add(ctx, req)
 	if err != nil {
-		ctx.JSON(500, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(200, models.Response{
 		Success: true,
-		Message: "Item added to cart",
+		Message: translate(ctx, "Item added to cart"),
 		Data:    cartID,
 	})
 }
 
-func GetCart(ctx *gin.Context) {
+func (s *Server) GetCart(ctx *gin.Context) {
 	userData, _ := ctx.Get("user")
 	user := userData.(lib.UserPayload)
 	userID := int64(user.Id)
 
-	carts, err := models.GetCart(userID)
+	carts, err := s.Carts.List(ctx, userID)
 	if err != nil {
-		ctx.JSON(500, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(200, models.Response{
 		Success: true,
-		Message: "Cart successfully",
+		Message: translate(ctx, "Cart successfully"),
 		Data:    carts,
 	})
 }
-func DeleteCart(ctx *gin.Context) {
+func (s *Server) DeleteCart(ctx *gin.Context) {
 	userData, _ := ctx.Get("user")
 	user := userData.(lib.UserPayload)
 	userID := int64(user.Id)
@@ -76,24 +60,18 @@ func DeleteCart(ctx *gin.Context) {
 	cartItemIDStr := ctx.Param("id")
 	cartItemID, err := strconv.ParseInt(cartItemIDStr, 10, 64)
 	if err != nil {
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: "Invalid cart item id",
-		})
+		ctx.Error(apperr.Validation("Invalid cart item id"))
 		return
 	}
 
-	err = models.DeleteCartItem(userID, cartItemID)
+	err = s.Carts.Delete(ctx, userID, cartItemID)
 	if err != nil {
-		ctx.JSON(500, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(200, models.Response{
 		Success: true,
-		Message: "Product removed from cart",
+		Message: translate(ctx, "Product removed from cart"),
 	})
 }
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"backend/models"
+	"net/http"
+	"testing"
+)
+
+func TestCart(t *testing.T) {
+	s := newTestServer()
+
+	r := newRouter()
+	cart := r.Group("/cart", asUser(3, "user"))
+	cart.POST("", s.AddToCart)
+	cart.GET("", s.GetCart)
+	cart.DELETE("/delete/:id", s.DeleteCart)
+
+	assertStatus(t, serve(t, r, http.MethodPost, "/cart", `{"product_id": 1, "quantity": 0}`), 400)
+	assertStatus(t, serve(t, r, http.MethodPost, "/cart", `{"product_id": 1, "quantity": 2}`), 200)
+	assertStatus(t, serve(t, r, http.MethodPost, "/cart", `{"product_id": 2, "quantity": 1}`), 200)
+
+	// another user's item stays out of the list
+	s.carts.Add(t.Context(), models.ReqCart{UserID: 4, ProductID: 1, Qty: 1})
+
+	w := serve(t, r, http.MethodGet, "/cart", "")
+	assertStatus(t, w, 200)
+	if _, items := decodeResponse[[]models.CartItemResponse](t, w.Body.Bytes()); len(items) != 2 || items[0].Qty != 2 {
+		t.Fatalf("cart = %+v", items)
+	}
+
+	assertStatus(t, serve(t, r, http.MethodDelete, "/cart/delete/x", ""), 400)
+	assertStatus(t, serve(t, r, http.MethodDelete, "/cart/delete/1", ""), 200)
+
+	w = serve(t, r, http.MethodGet, "/cart", "")
+	if _, items := decodeResponse[[]models.CartItemResponse](t, w.Body.Bytes()); len(items) != 1 || items[0].ProductID != 2 {
+		t.Fatalf("cart after delete = %+v", items)
+	}
+}
+
+func TestCartLocalized(t *testing.T) {
+	s := newTestServer()
+
+	r := newRouter()
+	r.POST("/cart", asUser(3, "user"), s.AddToCart)
+
+	indonesian := http.Header{"Accept-Language": {"id-ID,id;q=0.9"}}
+	w := serveWithHeader(t, r, http.MethodPost, "/cart", `{"product_id": 1, "quantity": 2}`, indonesian)
+	assertStatus(t, w, 200)
+	if res, _ := decodeResponse[any](t, w.Body.Bytes()); res.Message != "Produk ditambahkan ke keranjang" {
+		t.Fatalf("message = %q", res.Message)
+	}
+	if w.Header().Get("Content-Language") != "id" {
+		t.Fatalf("Content-Language = %q", w.Header().Get("Content-Language"))
+	}
+
+	w = serveWithHeader(t, r, http.MethodPost, "/cart", `{"product_id": 1, "quantity": 0}`, indonesian)
+	assertStatus(t, w, 400)
+	if res, _ := decodeResponse[any](t, w.Body.Bytes()); res.Message != "Permintaan tidak valid" || res.Errors[0].Message != "harus lebih dari 0" {
+		t.Fatalf("response = %+v", res)
+	}
+}
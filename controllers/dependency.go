@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"backend/apperr"
+	"backend/config"
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serviceUnavailable answers 503 for a request that needs redis (or another
+// dependency) while it is down
+func serviceUnavailable(ctx *gin.Context, dependency string) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(config.HealthCheckInterval().Seconds()))))
+	ctx.Error(apperr.Newf(apperr.KindUnavailable, "%s is unavailable, try again later", dependency))
+}
+
+// notFound replaces a missing row error with the handler's own error, other
+// errors (timeouts, ...) are kept
+func notFound(err error, missing *apperr.Error) error {
+	if apperr.KindOf(err) == apperr.KindNotFound {
+		return missing.Wrap(err)
+	}
+	return err
+}
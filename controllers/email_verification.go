@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"backend/apperr"
+	"backend/cache"
+	"backend/lib"
+	"backend/models"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const verificationTTL = 24 * time.Hour
+
+func (s *Server) sendVerificationEmail(user *models.User) error {
+	token := lib.RandomToken(32)
+
+	err := s.Tokens.SaveVerification(context.Background(), token, user.ID, verificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/auth/verify-email?token=%s", os.Getenv("APP_BASE_URL"), url.QueryEscape(token))
+	body := fmt.Sprintf("Hi,\n\nPlease verify your email by opening the link below:\n%s\n\nThe link expires in 24 hours.", link)
+
+	return lib.SendMail(user.Email, "Verify your email", body)
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Verify account email with the token sent after register
+// @Tags Auth
+// @Produce json
+// @Param token query string false "Verification token"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Router /auth/verify-email [get]
+func (s *Server) VerifyEmail(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if token == "" {
+		var body struct {
+			Token string `json:"token"`
+		}
+		_ = ctx.ShouldBindJSON(&body)
+		token = body.Token
+	}
+
+	if token == "" {
+		ctx.Error(apperr.Validation("token is required"))
+		return
+	}
+
+	redisCtx := context.Background()
+	userID, found, err := s.Tokens.VerificationUser(redisCtx, token)
+	if err != nil {
+		serviceUnavailable(ctx, "Redis")
+		return
+	}
+	if !found {
+		ctx.Error(apperr.Validation("invalid or expired token"))
+		return
+	}
+
+	if err := s.Users.MarkEmailVerified(ctx, userID); err != nil {
+		ctx.Error(err)
+		return
+	}
+	s.Tokens.DeleteVerification(redisCtx, token)
+	cache.Invalidate(redisCtx, cache.UsersTag)
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "Email verified"),
+	})
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Description Send a new verification link to an unverified account
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Router /auth/resend-verification [post]
+func (s *Server) ResendVerification(ctx *gin.Context) {
+	var body struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	// same response whether the email exists or not
+	user, err := s.Users.FindByEmail(ctx, body.Email)
+	if err == nil && !user.EmailVerified {
+		if err := s.sendVerificationEmail(user); err != nil {
+			ctx.Error(apperr.Internal("failed to send verification email").Wrap(err))
+			return
+		}
+	}
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "If the account exists and is not verified yet, a verification email has been sent"),
+	})
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"backend/lib"
+	"backend/middleware"
+	"backend/models"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEmailVerification(t *testing.T) {
+	s := newTestServer()
+
+	r := newRouter()
+	r.POST("/auth/register", s.RegisterUser)
+	r.GET("/auth/verify-email", s.VerifyEmail)
+	r.POST("/auth/resend-verification", s.ResendVerification)
+
+	assertStatus(t, serve(t, r, http.MethodPost, "/auth/register", `{"email": "dina@example.com", "password": "Rahasia123", "username": "dina"}`), 200)
+	tokens := slices.Collect(maps.Keys(s.tokens.verifications))
+	if len(tokens) != 1 {
+		t.Fatalf("verification tokens = %v, want one", s.tokens.verifications)
+	}
+	token := tokens[0]
+	userID := s.tokens.verifications[token]
+
+	assertStatus(t, serve(t, r, http.MethodGet, "/auth/verify-email", ""), 400)
+	assertStatus(t, serve(t, r, http.MethodGet, "/auth/verify-email?token=unknown", ""), 400)
+
+	assertStatus(t, serve(t, r, http.MethodGet, "/auth/verify-email?token="+token, ""), 200)
+	if !s.users.users[userID].EmailVerified {
+		t.Fatal("email not verified")
+	}
+	// the link works once
+	assertStatus(t, serve(t, r, http.MethodGet, "/auth/verify-email?token="+token, ""), 400)
+
+	// a verified account gets the same answer but no new link
+	assertStatus(t, serve(t, r, http.MethodPost, "/auth/resend-verification", `{"email": "dina@example.com"}`), 200)
+	if len(s.tokens.verifications) != 0 {
+		t.Fatalf("verification tokens = %v, want none", s.tokens.verifications)
+	}
+
+	s.users.users[userID].EmailVerified = false
+	assertStatus(t, serve(t, r, http.MethodPost, "/auth/resend-verification", `{"email": "dina@example.com"}`), 200)
+	if len(s.tokens.verifications) != 1 {
+		t.Fatalf("verification tokens = %v, want a new one", s.tokens.verifications)
+	}
+
+	s.tokens.err = errors.New("redis down")
+	assertStatus(t, serve(t, r, http.MethodGet, "/auth/verify-email?token="+token, ""), 503)
+}
+
+// countingUsers counts the account lookups of the middlewares
+type countingUsers struct {
+	models.UserRepository
+	gets int
+}
+
+func (c *countingUsers) Get(ctx context.Context, id int64) (*models.User, error) {
+	c.gets++
+	return c.UserRepository.Get(ctx, id)
+}
+
+func TestEmailVerificationPolicy(t *testing.T) {
+	t.Setenv("APP_SECRET", "test-secret")
+	t.Setenv("TWO_FACTOR_POLICY", "optional")
+
+	s := newTestServer()
+	user, _ := s.users.Register(t.Context(), models.RegisterRequest{Email: "dina@example.com", Password: "rahasia123", Username: "dina"})
+	users := &countingUsers{UserRepository: s.users}
+
+	r := newRouter()
+	group := r.Group("/user", middleware.Auth(users))
+	group.GET("/profile", s.UserProfile)
+	group.POST("/order", middleware.VerifiedEmail(users), func(ctx *gin.Context) { ctx.Status(http.StatusCreated) })
+
+	header := http.Header{"Authorization": {"Bearer " + lib.GeneratedTokens(int(user.ID), "user")}}
+
+	tests := []struct {
+		policy   string
+		verified bool
+		profile  int
+		order    int
+		// lookups of an order request, the revocation fallback (Redis is
+		// not there) and each verification check
+		orderGets int
+	}{
+		{"checkout", false, 200, 403, 2},
+		{"checkout", true, 200, 201, 2},
+		{"login", false, 403, 403, 2},
+		{"login", true, 200, 201, 2},
+		{"off", false, 200, 201, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s verified %v", tt.policy, tt.verified), func(t *testing.T) {
+			t.Setenv("EMAIL_VERIFICATION_POLICY", tt.policy)
+			s.users.users[user.ID].EmailVerified = tt.verified
+
+			assertStatus(t, serveWithHeader(t, r, http.MethodGet, "/user/profile", "", header), tt.profile)
+
+			users.gets = 0
+			assertStatus(t, serveWithHeader(t, r, http.MethodPost, "/user/order", "", header), tt.order)
+			if users.gets != tt.orderGets {
+				t.Errorf("order looked the user up %d times, want %d", users.gets, tt.orderGets)
+			}
+		})
+	}
+
+	// an account deleted after the token was issued
+	delete(s.users.users, user.ID)
+	assertStatus(t, serveWithHeader(t, r, http.MethodGet, "/user/profile", "", header), 401)
+}
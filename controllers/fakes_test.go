@@ -0,0 +1,1199 @@
+package controllers
+
+import (
+	"backend/lib"
+	"backend/middleware"
+	"backend/models"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// in-memory repositories, err makes every call fail
+
+type fakeProducts struct {
+	products map[int64]models.Product
+	nextID   int64
+	err      error
+}
+
+func newFakeProducts(products ...models.Product) *fakeProducts {
+	f := &fakeProducts{products: map[int64]models.Product{}}
+	for _, p := range products {
+		f.products[p.ID] = p
+		f.nextID = max(f.nextID, p.ID)
+	}
+	return f
+}
+
+func (f *fakeProducts) sorted() []models.Product {
+	list := make([]models.Product, 0, len(f.products))
+	for _, p := range f.products {
+		list = append(list, p)
+	}
+	slices.SortFunc(list, func(a, b models.Product) int { return int(a.ID - b.ID) })
+	return list
+}
+
+func (f *fakeProducts) List(_ context.Context, page, limit int, _ string, filter models.ProductFilter) ([]models.Product, int64, error) {
+	if f.err != nil {
+		return nil, 0, f.err
+	}
+
+	matched := []models.Product{}
+	for _, p := range f.sorted() {
+		if strings.Contains(strings.ToLower(p.Name), strings.ToLower(filter.Search)) {
+			matched = append(matched, p)
+		}
+	}
+
+	start := min((page-1)*limit, len(matched))
+	end := min(start+limit, len(matched))
+	return matched[start:end], int64(len(matched)), nil
+}
+
+func (f *fakeProducts) ListCursor(_ context.Context, _ *lib.Cursor, limit int, _ string, _ models.ProductFilter) ([]models.Product, string, string, error) {
+	if f.err != nil {
+		return nil, "", "", f.err
+	}
+	list := f.sorted()
+	return list[:min(limit, len(list))], "", "", nil
+}
+
+func (f *fakeProducts) Facets(context.Context, models.ProductFilter) (*models.ProductFacets, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &models.ProductFacets{}, nil
+}
+
+func (f *fakeProducts) Suggest(_ context.Context, search string, limit int) ([]models.ProductSuggestion, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	suggestions := []models.ProductSuggestion{}
+	for _, p := range f.sorted() {
+		if strings.HasPrefix(strings.ToLower(p.Name), strings.ToLower(search)) && len(suggestions) < limit {
+			suggestions = append(suggestions, models.ProductSuggestion{ID: p.ID, Name: p.Name, Category: p.Category})
+		}
+	}
+	return suggestions, nil
+}
+
+func (f *fakeProducts) Get(_ context.Context, id int64) (*models.Product, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	p, ok := f.products[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &p, nil
+}
+
+func (f *fakeProducts) Recommendations(context.Context, *models.Product) ([]models.Product, error) {
+	return []models.Product{}, f.err
+}
+
+func (f *fakeProducts) UserRecommendations(_ context.Context, _ int64, limit int) ([]models.Product, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	list := f.sorted()
+	return list[:min(limit, len(list))], nil
+}
+
+func (f *fakeProducts) BestSellers(_ context.Context, _, _, limit int) ([]models.Product, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	list := f.sorted()
+	return list[:min(limit, len(list))], nil
+}
+
+func (f *fakeProducts) Trending(ctx context.Context, days, categoryID, limit int) ([]models.Product, error) {
+	return f.BestSellers(ctx, days, categoryID, limit)
+}
+
+func (f *fakeProducts) ListAdmin(_ context.Context, page, limit int, search string) ([]models.ProductAdmin, int64, error) {
+	products, total, err := f.List(context.Background(), page, limit, "", models.ProductFilter{Search: search})
+	admin := make([]models.ProductAdmin, len(products))
+	for i, p := range products {
+		admin[i] = models.ProductAdmin{ID: p.ID, Name: p.Name, Description: p.Description, Stock: p.Stock}
+	}
+	return admin, total, err
+}
+
+func (f *fakeProducts) ListAdminCursor(_ context.Context, _ *lib.Cursor, limit int, search string) ([]models.ProductAdmin, string, string, error) {
+	admin, _, err := f.ListAdmin(context.Background(), 1, limit, search)
+	return admin, "", "", err
+}
+
+func (f *fakeProducts) Create(_ context.Context, req models.CreateProductRequest) (*models.Product, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.nextID++
+	p := models.Product{ID: f.nextID, Name: req.Name, Description: req.Description, Stock: int64(req.Stock), Images: req.Images}
+	f.products[p.ID] = p
+	return &p, nil
+}
+
+func (f *fakeProducts) Update(_ context.Context, id int64, req models.CreateProductRequest) (*models.Product, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if _, ok := f.products[id]; !ok {
+		return nil, pgx.ErrNoRows
+	}
+	p := models.Product{ID: id, Name: req.Name, Description: req.Description, Stock: int64(req.Stock), Images: req.Images}
+	f.products[id] = p
+	return &p, nil
+}
+
+func (f *fakeProducts) Delete(_ context.Context, id int64) error {
+	if f.err != nil {
+		return f.err
+	}
+	delete(f.products, id)
+	return nil
+}
+
+func (f *fakeProducts) SetImage(_ context.Context, id int64, url string) error {
+	if f.err != nil {
+		return f.err
+	}
+	p := f.products[id]
+	p.Images = append(p.Images, url)
+	f.products[id] = p
+	return nil
+}
+
+type fakeCarts struct {
+	items  map[int64]models.ReqCart
+	nextID int64
+	err    error
+}
+
+func newFakeCarts() *fakeCarts {
+	return &fakeCarts{items: map[int64]models.ReqCart{}}
+}
+
+func (f *fakeCarts) Add(_ context.Context, req models.ReqCart) (int64, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.nextID++
+	f.items[f.nextID] = req
+	return f.nextID, nil
+}
+
+func (f *fakeCarts) List(_ context.Context, userID int64) ([]models.CartItemResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	items := []models.CartItemResponse{}
+	for id := range f.nextID + 1 {
+		if item, ok := f.items[id]; ok && item.UserID == userID {
+			items = append(items, models.CartItemResponse{ID: id, ProductID: item.ProductID, Qty: item.Qty})
+		}
+	}
+	return items, nil
+}
+
+func (f *fakeCarts) Delete(_ context.Context, userID, cartItemID int64) error {
+	if f.err != nil {
+		return f.err
+	}
+	if item, ok := f.items[cartItemID]; ok && item.UserID == userID {
+		delete(f.items, cartItemID)
+	}
+	return nil
+}
+
+type fakeOrders struct {
+	carts    *fakeCarts
+	contacts map[int64]models.OrderContact
+	orders   map[int64]*models.OrderDetail
+	owners   map[int64]int64
+	nextID   int64
+	err      error
+}
+
+func newFakeOrders(carts *fakeCarts) *fakeOrders {
+	return &fakeOrders{
+		carts:    carts,
+		contacts: map[int64]models.OrderContact{},
+		orders:   map[int64]*models.OrderDetail{},
+		owners:   map[int64]int64{},
+	}
+}
+
+var orderStatuses = map[int]string{1: "On Progress", 2: "Sending Goods", 3: "Finish Order"}
+
+// Create moves the user's cart into an order like models.CreateOrder
+func (f *fakeOrders) Create(ctx context.Context, userID int64, req models.CreateOrderRequest) (models.OrderResponse, error) {
+	if f.err != nil {
+		return models.OrderResponse{}, f.err
+	}
+
+	items, _ := f.carts.List(ctx, userID)
+	if len(items) == 0 {
+		return models.OrderResponse{}, models.ErrCartEmpty
+	}
+	for _, item := range items {
+		f.carts.Delete(ctx, userID, item.ID)
+	}
+
+	f.nextID++
+	detail := &models.OrderDetail{
+		ID:              f.nextID,
+		Invoice:         fmt.Sprintf("INV-%d", f.nextID),
+		CustomerName:    req.CustomerName,
+		CustomerPhone:   req.CustomerPhone,
+		CustomerAddress: req.CustomerAddress,
+		Status:          orderStatuses[1],
+	}
+	f.orders[detail.ID] = detail
+	f.owners[detail.ID] = userID
+
+	return models.OrderResponse{
+		OrderID:         detail.ID,
+		Invoice:         detail.Invoice,
+		CustomerName:    detail.CustomerName,
+		CustomerPhone:   detail.CustomerPhone,
+		CustomerAddress: detail.CustomerAddress,
+		Status:          detail.Status,
+	}, nil
+}
+
+func (f *fakeOrders) Contact(_ context.Context, userID int64) (models.OrderContact, error) {
+	if f.err != nil {
+		return models.OrderContact{}, f.err
+	}
+	return f.contacts[userID], nil
+}
+
+func (f *fakeOrders) History(_ context.Context, userID int64, _, _, page, limit int) ([]map[string]interface{}, int, error) {
+	if f.err != nil {
+		return nil, 0, f.err
+	}
+
+	history := []map[string]interface{}{}
+	for id := range f.nextID + 1 {
+		if order, ok := f.orders[id]; ok && f.owners[id] == userID {
+			history = append(history, map[string]interface{}{"id": order.ID, "invoice": order.Invoice, "status": order.Status})
+		}
+	}
+
+	start := min((page-1)*limit, len(history))
+	end := min(start+limit, len(history))
+	return history[start:end], len(history), nil
+}
+
+func (f *fakeOrders) HistoryCursor(ctx context.Context, userID int64, month, shippingID int, _ *lib.Cursor, limit int) ([]map[string]interface{}, string, string, error) {
+	history, _, err := f.History(ctx, userID, month, shippingID, 1, limit)
+	return history, "", "", err
+}
+
+func (f *fakeOrders) Detail(_ context.Context, id int64) (*models.OrderDetail, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	order, ok := f.orders[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return order, nil
+}
+
+func (f *fakeOrders) List(_ context.Context, page, limit int) ([]models.OrderListItem, int64, error) {
+	if f.err != nil {
+		return nil, 0, f.err
+	}
+
+	list := []models.OrderListItem{}
+	for id := range f.nextID + 1 {
+		if order, ok := f.orders[id]; ok {
+			list = append(list, models.OrderListItem{ID: order.ID, Invoice: order.Invoice, Status: order.Status})
+		}
+	}
+
+	start := min((page-1)*limit, len(list))
+	end := min(start+limit, len(list))
+	return list[start:end], int64(len(list)), nil
+}
+
+func (f *fakeOrders) ListCursor(ctx context.Context, _ *lib.Cursor, limit int) ([]models.OrderListItem, string, string, error) {
+	list, _, err := f.List(ctx, 1, limit)
+	return list, "", "", err
+}
+
+func (f *fakeOrders) UpdateStatus(_ context.Context, id int64, status int) error {
+	if f.err != nil {
+		return f.err
+	}
+	order, ok := f.orders[id]
+	if !ok {
+		return pgx.ErrNoRows
+	}
+	name, ok := orderStatuses[status]
+	if !ok {
+		return fmt.Errorf("invalid status %d", status)
+	}
+	order.Status = name
+	return nil
+}
+
+type fakeUsers struct {
+	users    map[int64]*models.User
+	profiles map[int64]models.ListUserStruct
+	nextID   int64
+	err      error
+}
+
+func newFakeUsers() *fakeUsers {
+	return &fakeUsers{users: map[int64]*models.User{}, profiles: map[int64]models.ListUserStruct{}}
+}
+
+func (f *fakeUsers) Register(_ context.Context, req models.RegisterRequest) (*models.User, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, u := range f.users {
+		if u.Email == req.Email {
+			// what the users_email_key index answers
+			return nil, &pgconn.PgError{Code: "23505", Detail: fmt.Sprintf("Key (email)=(%s) already exists.", req.Email)}
+		}
+	}
+
+	f.nextID++
+	user := &models.User{ID: f.nextID, Email: req.Email, Password: string(lib.HashPassword(req.Password)), Role: "user"}
+	f.users[user.ID] = user
+	f.profiles[user.ID] = models.ListUserStruct{ID: user.ID, Email: req.Email, Role: "user", Username: req.Username, Phone: req.Phone, Address: req.Address}
+
+	registered := *user
+	registered.Password = ""
+	return &registered, nil
+}
+
+func (f *fakeUsers) FindByEmail(_ context.Context, email string) (*models.User, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, u := range f.users {
+		if u.Email == email {
+			found := *u
+			return &found, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (f *fakeUsers) Get(_ context.Context, id int64) (*models.User, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	user, ok := f.users[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	found := *user
+	found.Password = ""
+	return &found, nil
+}
+
+func (f *fakeUsers) Email(ctx context.Context, id int64) (string, error) {
+	user, err := f.Get(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return user.Email, nil
+}
+
+func (f *fakeUsers) PasswordHash(_ context.Context, id int64) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	user, ok := f.users[id]
+	if !ok {
+		return "", pgx.ErrNoRows
+	}
+	return user.Password, nil
+}
+
+func (f *fakeUsers) Profile(_ context.Context, id int64) (models.ListUserStruct, error) {
+	if f.err != nil {
+		return models.ListUserStruct{}, f.err
+	}
+	profile, ok := f.profiles[id]
+	if !ok {
+		return models.ListUserStruct{}, pgx.ErrNoRows
+	}
+	return profile, nil
+}
+
+func (f *fakeUsers) UpdateProfile(_ context.Context, id int64, req models.UpdateUserRequest) (*models.User, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	user, ok := f.users[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+
+	profile := f.profiles[id]
+	profile.Username, profile.Phone, profile.Address = req.Username, req.Phone, req.Address
+	f.profiles[id] = profile
+
+	return &models.User{ID: user.ID, Email: user.Email, Role: user.Role}, nil
+}
+
+func (f *fakeUsers) SetProfilePicture(_ context.Context, id int64, path string) error {
+	if f.err != nil {
+		return f.err
+	}
+	profile := f.profiles[id]
+	profile.ProfilePicture = path
+	f.profiles[id] = profile
+	return nil
+}
+
+func (f *fakeUsers) SetPassword(_ context.Context, email, hashedPassword string) error {
+	if f.err != nil {
+		return f.err
+	}
+	for _, u := range f.users {
+		if u.Email == email {
+			u.Password = hashedPassword
+		}
+	}
+	return nil
+}
+
+func (f *fakeUsers) MarkEmailVerified(_ context.Context, id int64) error {
+	if f.err != nil {
+		return f.err
+	}
+	if user, ok := f.users[id]; ok {
+		user.EmailVerified = true
+	}
+	return nil
+}
+
+// LoginWithIdentity links by email only, the linking rules are tested in models
+func (f *fakeUsers) LoginWithIdentity(ctx context.Context, identity lib.OIDCIdentity) (*models.User, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if user, err := f.FindByEmail(ctx, identity.Email); err == nil {
+		user.Password = ""
+		return user, nil
+	}
+
+	f.nextID++
+	user := &models.User{ID: f.nextID, Email: identity.Email, Role: "user", EmailVerified: identity.EmailVerified}
+	f.users[user.ID] = user
+	f.profiles[user.ID] = models.ListUserStruct{ID: user.ID, Email: user.Email, Role: "user", Username: identity.Name}
+
+	created := *user
+	return &created, nil
+}
+
+func (f *fakeUsers) List(_ context.Context, page, limit int) ([]models.ListUserStruct, int64, error) {
+	if f.err != nil {
+		return nil, 0, f.err
+	}
+
+	list := []models.ListUserStruct{}
+	for id := f.nextID; id > 0; id-- {
+		if profile, ok := f.profiles[id]; ok {
+			list = append(list, profile)
+		}
+	}
+
+	start := min((page-1)*limit, len(list))
+	end := min(start+limit, len(list))
+	return list[start:end], int64(len(list)), nil
+}
+
+func (f *fakeUsers) ListCursor(ctx context.Context, _ *lib.Cursor, limit int) ([]models.ListUserStruct, string, string, error) {
+	list, _, err := f.List(ctx, 1, limit)
+	return list, "", "", err
+}
+
+func (f *fakeUsers) AdminUpdate(ctx context.Context, id int64, req models.AdminUpdateUserRequest) (*models.User, error) {
+	user, err := f.UpdateProfile(ctx, id, models.UpdateUserRequest{Username: req.Username, Phone: req.Phone, Address: req.Address})
+	if err != nil {
+		return nil, err
+	}
+	if req.Role != "" {
+		f.users[id].Role = req.Role
+		user.Role = req.Role
+	}
+	return user, nil
+}
+
+func (f *fakeUsers) AdminSetProfilePicture(ctx context.Context, id int64, path string) error {
+	return f.SetProfilePicture(ctx, id, path)
+}
+
+type fakeCategories struct {
+	categories map[int]models.Categories
+	nextID     int
+	err        error
+}
+
+func newFakeCategories() *fakeCategories {
+	return &fakeCategories{categories: map[int]models.Categories{}}
+}
+
+func (f *fakeCategories) List(context.Context) ([]models.Categories, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	list := []models.Categories{}
+	for id := range f.nextID + 1 {
+		if c, ok := f.categories[id]; ok {
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeCategories) IDs(context.Context) ([]int, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	ids := []int{}
+	for id := range f.nextID + 1 {
+		if _, ok := f.categories[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (f *fakeCategories) Create(_ context.Context, req models.Categories) (models.Categories, error) {
+	if f.err != nil {
+		return models.Categories{}, f.err
+	}
+	for _, c := range f.categories {
+		if c.Name == req.Name {
+			// what the categories_name_key index answers
+			return models.Categories{}, &pgconn.PgError{Code: "23505", Detail: fmt.Sprintf("Key (name)=(%s) already exists.", req.Name)}
+		}
+	}
+
+	f.nextID++
+	req.Id = f.nextID
+	f.categories[req.Id] = req
+	return req, nil
+}
+
+func (f *fakeCategories) Update(_ context.Context, id int, req models.Categories) (models.Categories, error) {
+	if f.err != nil {
+		return models.Categories{}, f.err
+	}
+	if _, ok := f.categories[id]; !ok {
+		return models.Categories{}, pgx.ErrNoRows
+	}
+	req.Id = id
+	f.categories[id] = req
+	return req, nil
+}
+
+func (f *fakeCategories) Delete(_ context.Context, id int) error {
+	if f.err != nil {
+		return f.err
+	}
+	delete(f.categories, id)
+	return nil
+}
+
+type fakeFavorites struct {
+	products *fakeProducts
+	featured map[int64]models.FeatureProductRequest
+	saved    map[int64][]int64
+	err      error
+}
+
+func newFakeFavorites(products *fakeProducts) *fakeFavorites {
+	return &fakeFavorites{products: products, featured: map[int64]models.FeatureProductRequest{}, saved: map[int64][]int64{}}
+}
+
+func (f *fakeFavorites) page(ids []int64, page, limit int) ([]models.Product, int64, error) {
+	if f.err != nil {
+		return nil, 0, f.err
+	}
+
+	list := []models.Product{}
+	for _, id := range ids {
+		if p, ok := f.products.products[id]; ok {
+			list = append(list, p)
+		}
+	}
+
+	start := min((page-1)*limit, len(list))
+	end := min(start+limit, len(list))
+	return list[start:end], int64(len(list)), nil
+}
+
+func (f *fakeFavorites) Featured(_ context.Context, page, limit int) ([]models.Product, int64, error) {
+	ids := []int64{}
+	for _, p := range f.products.sorted() {
+		if _, ok := f.featured[p.ID]; ok {
+			ids = append(ids, p.ID)
+		}
+	}
+	return f.page(ids, page, limit)
+}
+
+func (f *fakeFavorites) NextFeaturedChange(context.Context) (time.Duration, bool, error) {
+	return 0, false, f.err
+}
+
+func (f *fakeFavorites) FeaturedAdmin(context.Context) ([]models.FeaturedProduct, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	list := []models.FeaturedProduct{}
+	for _, p := range f.products.sorted() {
+		if req, ok := f.featured[p.ID]; ok {
+			list = append(list, models.FeaturedProduct{ID: p.ID, Name: p.Name, DisplayOrder: &req.DisplayOrder, FeaturedFrom: req.FeaturedFrom, FeaturedUntil: req.FeaturedUntil, Active: true})
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeFavorites) Feature(_ context.Context, productID int64, req models.FeatureProductRequest) error {
+	if f.err != nil {
+		return f.err
+	}
+	if _, ok := f.products.products[productID]; !ok {
+		return models.ErrProductNotFound
+	}
+	f.featured[productID] = req
+	return nil
+}
+
+func (f *fakeFavorites) Unfeature(_ context.Context, productID int64) error {
+	if f.err != nil {
+		return f.err
+	}
+	if _, ok := f.products.products[productID]; !ok {
+		return models.ErrProductNotFound
+	}
+	delete(f.featured, productID)
+	return nil
+}
+
+func (f *fakeFavorites) List(_ context.Context, userID int64, page, limit int) ([]models.Product, int64, error) {
+	return f.page(f.saved[userID], page, limit)
+}
+
+func (f *fakeFavorites) Favorited(_ context.Context, userID int64, productIDs []int64) (map[int64]bool, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	favorited := map[int64]bool{}
+	for _, id := range productIDs {
+		if slices.Contains(f.saved[userID], id) {
+			favorited[id] = true
+		}
+	}
+	return favorited, nil
+}
+
+func (f *fakeFavorites) Add(_ context.Context, userID, productID int64) error {
+	if f.err != nil {
+		return f.err
+	}
+	if _, ok := f.products.products[productID]; !ok {
+		return models.ErrProductNotFound
+	}
+	if !slices.Contains(f.saved[userID], productID) {
+		f.saved[userID] = append(f.saved[userID], productID)
+	}
+	return nil
+}
+
+func (f *fakeFavorites) Remove(_ context.Context, userID, productID int64) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.saved[userID] = slices.DeleteFunc(f.saved[userID], func(id int64) bool { return id == productID })
+	return nil
+}
+
+type fakeReviews struct {
+	reviews map[int64]*models.Review
+	// buyers lists the products each user has a completed order of
+	buyers map[int64][]int64
+	nextID int64
+	err    error
+}
+
+func newFakeReviews() *fakeReviews {
+	return &fakeReviews{reviews: map[int64]*models.Review{}, buyers: map[int64][]int64{}}
+}
+
+func (f *fakeReviews) list(match func(r *models.Review) bool, page, limit int) ([]models.Review, int64, error) {
+	if f.err != nil {
+		return nil, 0, f.err
+	}
+
+	list := []models.Review{}
+	for id := f.nextID; id > 0; id-- {
+		if r, ok := f.reviews[id]; ok && match(r) {
+			list = append(list, *r)
+		}
+	}
+
+	start := min((page-1)*limit, len(list))
+	end := min(start+limit, len(list))
+	return list[start:end], int64(len(list)), nil
+}
+
+func (f *fakeReviews) ListByProduct(_ context.Context, productID int64, page, limit int) ([]models.Review, int64, error) {
+	return f.list(func(r *models.Review) bool { return r.ProductID == productID && r.Status == "approved" }, page, limit)
+}
+
+func (f *fakeReviews) Create(_ context.Context, userID, productID int64, req models.ReviewRequest, status string) (*models.Review, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if !slices.Contains(f.buyers[userID], productID) {
+		return nil, models.ErrReviewNotAllowed
+	}
+	for _, r := range f.reviews {
+		if r.UserID == userID && r.ProductID == productID {
+			return nil, models.ErrReviewExists
+		}
+	}
+
+	f.nextID++
+	review := &models.Review{ID: f.nextID, ProductID: productID, UserID: userID, Rating: req.Rating, Review: req.Review, Status: status}
+	f.reviews[review.ID] = review
+	created := *review
+	return &created, nil
+}
+
+func (f *fakeReviews) Update(_ context.Context, userID, reviewID int64, req models.ReviewRequest, status string) (*models.Review, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	review, ok := f.reviews[reviewID]
+	if !ok || review.UserID != userID {
+		return nil, models.ErrReviewNotFound
+	}
+
+	review.Rating, review.Review = req.Rating, req.Review
+	if review.Status != "hidden" {
+		review.Status = status
+	}
+	updated := *review
+	return &updated, nil
+}
+
+func (f *fakeReviews) Delete(_ context.Context, userID, reviewID int64) error {
+	if f.err != nil {
+		return f.err
+	}
+	review, ok := f.reviews[reviewID]
+	if !ok || review.UserID != userID {
+		return models.ErrReviewNotFound
+	}
+	delete(f.reviews, reviewID)
+	return nil
+}
+
+func (f *fakeReviews) ListAdmin(_ context.Context, status string, page, limit int) ([]models.Review, int64, error) {
+	return f.list(func(r *models.Review) bool { return status == "" || r.Status == status }, page, limit)
+}
+
+func (f *fakeReviews) SetStatus(_ context.Context, reviewID int64, status string) error {
+	if f.err != nil {
+		return f.err
+	}
+	review, ok := f.reviews[reviewID]
+	if !ok {
+		return models.ErrReviewNotFound
+	}
+	review.Status = status
+	return nil
+}
+
+type fakeTOTP struct {
+	secret      string
+	enabled     bool
+	backupCodes []string
+}
+
+type fakeTwoFactor struct {
+	users map[int64]*fakeTOTP
+	err   error
+}
+
+func newFakeTwoFactor() *fakeTwoFactor {
+	return &fakeTwoFactor{users: map[int64]*fakeTOTP{}}
+}
+
+func (f *fakeTwoFactor) user(userID int64) *fakeTOTP {
+	if f.users[userID] == nil {
+		f.users[userID] = &fakeTOTP{}
+	}
+	return f.users[userID]
+}
+
+func (f *fakeTwoFactor) TOTP(_ context.Context, userID int64) (string, bool, error) {
+	if f.err != nil {
+		return "", false, f.err
+	}
+	u := f.user(userID)
+	return u.secret, u.enabled, nil
+}
+
+func (f *fakeTwoFactor) SetPendingSecret(_ context.Context, userID int64, secret string) error {
+	if f.err != nil {
+		return f.err
+	}
+	u := f.user(userID)
+	if !u.enabled {
+		u.secret = secret
+	}
+	return nil
+}
+
+func (f *fakeTwoFactor) Enable(_ context.Context, userID int64, backupCodeHashes []string) error {
+	if f.err != nil {
+		return f.err
+	}
+	u := f.user(userID)
+	u.enabled, u.backupCodes = true, backupCodeHashes
+	return nil
+}
+
+func (f *fakeTwoFactor) Disable(_ context.Context, userID int64) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.users[userID] = &fakeTOTP{}
+	return nil
+}
+
+func (f *fakeTwoFactor) RegenerateBackupCodes(_ context.Context, userID int64, backupCodeHashes []string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.user(userID).backupCodes = backupCodeHashes
+	return nil
+}
+
+func (f *fakeTwoFactor) UseBackupCode(_ context.Context, userID int64, codeHash string) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	u := f.user(userID)
+	i := slices.Index(u.backupCodes, codeHash)
+	if i < 0 {
+		return false, nil
+	}
+	u.backupCodes = slices.Delete(u.backupCodes, i, i+1)
+	return true, nil
+}
+
+// fakeAccounts exports and deletes from the other fakes like
+// models.ExportUserData and models.DeleteUserAccount
+type fakeAccounts struct {
+	users     *fakeUsers
+	carts     *fakeCarts
+	favorites *fakeFavorites
+	err       error
+}
+
+func (f *fakeAccounts) Export(ctx context.Context, userID int64) (*models.UserExport, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	profile, err := f.users.Profile(ctx, userID)
+	if err != nil {
+		return nil, models.ErrUserNotFound
+	}
+	cart, _ := f.carts.List(ctx, userID)
+	favorites, _, _ := f.favorites.List(ctx, userID, 1, len(f.favorites.saved[userID])+1)
+
+	return &models.UserExport{ExportedAt: time.Now(), Profile: profile, Orders: []models.OrderDetail{}, Cart: cart, Favorites: favorites}, nil
+}
+
+func (f *fakeAccounts) Delete(ctx context.Context, userID int64) error {
+	if f.err != nil {
+		return f.err
+	}
+	if _, ok := f.users.users[userID]; !ok {
+		return models.ErrUserNotFound
+	}
+
+	delete(f.users.users, userID)
+	delete(f.users.profiles, userID)
+	delete(f.favorites.saved, userID)
+	items, _ := f.carts.List(ctx, userID)
+	for _, item := range items {
+		f.carts.Delete(ctx, userID, item.ID)
+	}
+	return nil
+}
+
+// fakeTokens ignores the ttls, nothing expires during a test
+type fakeTokens struct {
+	otps              map[string]string
+	verifications     map[string]int64
+	challenges        map[string]int64
+	challengeFailures map[string]int64
+	codeFailures      map[int64]int64
+	usedTOTP          map[string]bool
+	oidcStates        map[string][]byte
+	leases            map[string]bool
+	err               error
+}
+
+func newFakeTokens() *fakeTokens {
+	return &fakeTokens{
+		otps:              map[string]string{},
+		verifications:     map[string]int64{},
+		challenges:        map[string]int64{},
+		challengeFailures: map[string]int64{},
+		codeFailures:      map[int64]int64{},
+		usedTOTP:          map[string]bool{},
+		oidcStates:        map[string][]byte{},
+		leases:            map[string]bool{},
+	}
+}
+
+func (f *fakeTokens) SaveOTP(_ context.Context, email, otp string, _ time.Duration) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.otps[email] = otp
+	return nil
+}
+
+func (f *fakeTokens) FindOTP(_ context.Context, otp string) (string, bool, error) {
+	if f.err != nil {
+		return "", false, f.err
+	}
+	for email, saved := range f.otps {
+		if saved == otp {
+			return email, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func (f *fakeTokens) DeleteOTP(_ context.Context, email string) error {
+	delete(f.otps, email)
+	return f.err
+}
+
+func (f *fakeTokens) SaveVerification(_ context.Context, token string, userID int64, _ time.Duration) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.verifications[token] = userID
+	return nil
+}
+
+func (f *fakeTokens) VerificationUser(_ context.Context, token string) (int64, bool, error) {
+	userID, ok := f.verifications[token]
+	return userID, ok, f.err
+}
+
+func (f *fakeTokens) DeleteVerification(_ context.Context, token string) error {
+	delete(f.verifications, token)
+	return f.err
+}
+
+func (f *fakeTokens) SaveChallenge(_ context.Context, challenge string, userID int64, _ time.Duration) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.challenges[challenge] = userID
+	return nil
+}
+
+func (f *fakeTokens) ChallengeUser(_ context.Context, challenge string) (int64, bool, error) {
+	userID, ok := f.challenges[challenge]
+	return userID, ok, f.err
+}
+
+func (f *fakeTokens) FailChallenge(_ context.Context, challenge string, _ time.Duration) (int64, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.challengeFailures[challenge]++
+	return f.challengeFailures[challenge], nil
+}
+
+func (f *fakeTokens) DeleteChallenge(_ context.Context, challenge string) error {
+	delete(f.challenges, challenge)
+	delete(f.challengeFailures, challenge)
+	return f.err
+}
+
+func (f *fakeTokens) CodeFailures(_ context.Context, userID int64) (int64, error) {
+	return f.codeFailures[userID], f.err
+}
+
+func (f *fakeTokens) FailCode(_ context.Context, userID int64, _ time.Duration) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.codeFailures[userID]++
+	return nil
+}
+
+func (f *fakeTokens) ResetCodeFailures(_ context.Context, userID int64) error {
+	delete(f.codeFailures, userID)
+	return f.err
+}
+
+func (f *fakeTokens) UseTOTP(_ context.Context, userID int64, code string, _ time.Duration) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	key := fmt.Sprintf("%d:%s", userID, code)
+	if f.usedTOTP[key] {
+		return false, nil
+	}
+	f.usedTOTP[key] = true
+	return true, nil
+}
+
+func (f *fakeTokens) SaveOIDCState(_ context.Context, state string, data []byte, _ time.Duration) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.oidcStates[state] = data
+	return nil
+}
+
+func (f *fakeTokens) TakeOIDCState(_ context.Context, state string) ([]byte, bool, error) {
+	if f.err != nil {
+		return nil, false, f.err
+	}
+	data, ok := f.oidcStates[state]
+	delete(f.oidcStates, state)
+	return data, ok, nil
+}
+
+func (f *fakeTokens) AcquireLease(_ context.Context, name string, _ time.Duration) (bool, error) {
+	if f.err != nil || f.leases[name] {
+		return false, f.err
+	}
+	f.leases[name] = true
+	return true, nil
+}
+
+type testServer struct {
+	*Server
+	products   *fakeProducts
+	carts      *fakeCarts
+	orders     *fakeOrders
+	users      *fakeUsers
+	categories *fakeCategories
+	favorites  *fakeFavorites
+	reviews    *fakeReviews
+	twoFactor  *fakeTwoFactor
+	accounts   *fakeAccounts
+	tokens     *fakeTokens
+}
+
+func newTestServer() *testServer {
+	gin.SetMode(gin.TestMode)
+
+	products, carts, users := newFakeProducts(), newFakeCarts(), newFakeUsers()
+	favorites := newFakeFavorites(products)
+	s := &testServer{
+		products:   products,
+		carts:      carts,
+		orders:     newFakeOrders(carts),
+		users:      users,
+		categories: newFakeCategories(),
+		favorites:  favorites,
+		reviews:    newFakeReviews(),
+		twoFactor:  newFakeTwoFactor(),
+		accounts:   &fakeAccounts{users: users, carts: carts, favorites: favorites},
+		tokens:     newFakeTokens(),
+	}
+	s.Server = &Server{
+		Products:   s.products,
+		Orders:     s.orders,
+		Users:      s.users,
+		Carts:      s.carts,
+		Categories: s.categories,
+		Favorites:  s.favorites,
+		Reviews:    s.reviews,
+		TwoFactor:  s.twoFactor,
+		Accounts:   s.accounts,
+		Tokens:     s.tokens,
+	}
+	return s
+}
+
+// asUser stands in for middleware.Auth
+func asUser(id int64, role string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set("user", lib.UserPayload{Id: int(id), Role: role})
+		ctx.Set("user_id", id)
+		ctx.Set("role", role)
+	}
+}
+
+func newRouter() *gin.Engine {
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.Use(middleware.Locale(), middleware.Errors())
+	return r
+}
+
+func serve(t *testing.T, r *gin.Engine, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	return serveWithHeader(t, r, method, target, body, nil)
+}
+
+func serveWithHeader(t *testing.T, r *gin.Engine, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func assertStatus(t *testing.T, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if w.Code != want {
+		t.Fatalf("status = %d, want %d, body %s", w.Code, want, w.Body.String())
+	}
+}
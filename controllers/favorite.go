@@ -1,17 +1,25 @@
 package controllers
 
 import (
-	"backend/config"
+	"backend/apperr"
+	"backend/cache"
+	"backend/lib"
 	"backend/models"
 	"context"
-	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func FavoriteProduct(ctx *gin.Context) {
+// featuredTTL is how long the featured list is cached without a schedule
+// change coming up
+const featuredTTL = 15 * time.Minute
+
+func (s *Server) FavoriteProduct(ctx *gin.Context) {
 	page := ctx.DefaultQuery("page", "1")
 	limit := ctx.DefaultQuery("limit", "4")
 
@@ -24,59 +32,285 @@ func FavoriteProduct(ctx *gin.Context) {
 	if err != nil || limitInt < 1 {
 		limitInt = 4
 	}
-	key := ctx.Request.RequestURI
-	var cacheData struct {
+	type featuredCache struct {
 		Products   []models.Product `json:"products"`
 		Pagination map[string]any   `json:"pagination"`
 	}
 
-	cache, err := config.Rdb.Get(context.Background(), key).Result()
-	if err == nil && cache != "" {
-		_ = json.Unmarshal([]byte(cache), &cacheData)
-		ctx.JSON(200, models.Response{
-			Success: true,
-			Message: "list favorite products ( from cache )",
-			Data:    cacheData,
-		})
+	// /favorite-product and /featured-products share the entries
+	key := fmt.Sprintf("featured:%s:page:%d:limit:%d", locale(ctx), pageInt, limitInt)
+	tags := []string{cache.FeaturedTag, cache.ProductsTag, cache.CategoriesTag}
+
+	// the entry expires when the next scheduled product enters or leaves the list
+	cacheData, fromCache, err := cache.RememberTTL(ctx.Request.Context(), key, tags, func(loadCtx context.Context) (featuredCache, time.Duration, error) {
+		products, total, err := s.Favorites.Featured(loadCtx, pageInt, limitInt)
+		if err != nil {
+			return featuredCache{}, 0, err
+		}
+		totalPage := int((total + int64(limitInt) - 1) / int64(limitInt))
+
+		ttl := featuredTTL
+		next, scheduled, err := s.Favorites.NextFeaturedChange(loadCtx)
+		if err != nil {
+			return featuredCache{}, 0, err
+		}
+		if scheduled {
+			ttl = min(ttl, next)
+		}
+
+		return featuredCache{
+			Products: products,
+			Pagination: map[string]any{
+				"page":       strconv.Itoa(pageInt),
+				"limit":      strconv.Itoa(limitInt),
+				"total_data": total,
+				"total_page": totalPage,
+			},
+		}, ttl, nil
+	})
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	products, total, err := models.Favorite(pageInt, limitInt)
+	message := "list products favorite"
+	if fromCache {
+		message = "list favorite products ( from cache )"
+	}
+
+	s.markFavorited(ctx, cacheData.Products)
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, message),
+		Data:    cacheData,
+	})
+}
+
+// markFavorited sets is_favorited for the logged in user (OptionalAuth),
+// call it after caching so cached lists stay user independent
+func (s *Server) markFavorited(ctx *gin.Context, products []models.Product) {
+	userID, exists := ctx.Get("user_id")
+	if !exists || len(products) == 0 {
+		return
+	}
+
+	ids := make([]int64, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+
+	favorited, err := s.Favorites.Favorited(ctx, userID.(int64), ids)
 	if err != nil {
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		// the list is still served, unmarked, the request log shows why
+		ctx.Error(apperr.Internal("failed to mark favorited products").Wrap(err))
 		return
 	}
-	totalPage := int((total + int64(limitInt) - 1) / int64(limitInt))
 
-	cacheData = struct {
-		Products   []models.Product `json:"products"`
-		Pagination map[string]any   `json:"pagination"`
-	}{
-		Products: products,
-		Pagination: map[string]any{
-			"page":       page,
-			"limit":      limit,
-			"total_data": total,
-			"total_page": totalPage,
-		},
+	for i := range products {
+		products[i].IsFavorited = favorited[products[i].ID]
+	}
+}
+
+// UserFavorites godoc
+// @Summary List my favorite products
+// @Tags User - Favorites
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} models.Response
+// @Router /user/favorites [get]
+func (s *Server) UserFavorites(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
 	}
 
-	jsonData, err := json.Marshal(cacheData)
+	products, totalItems, err := s.Favorites.List(ctx, userID, page, limit)
 	if err != nil {
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: "failed marshal data",
-		})
+		ctx.Error(err)
+		return
+	}
+
+	totalPage := int((totalItems + int64(limit) - 1) / int64(limit))
+
+	rawQuery := ctx.Request.URL.Query()
+	extraQuery := url.Values{}
+	for k, v := range rawQuery {
+		extraQuery[k] = append([]string{}, v...)
+	}
+
+	baseURL := os.Getenv("APP_BASE_URL")
+	path := ctx.Request.URL.Path
+
+	links := lib.Hateoas(baseURL, path, page, limit, totalPage, extraQuery)
+	pagination := lib.Pagination(page, limit, totalPage, totalItems, links)
+
+	ctx.JSON(200, models.Response{
+		Success:    true,
+		Message:    translate(ctx, "list my favorite products"),
+		Pagination: pagination,
+		Data:       products,
+	})
+}
+
+// AddFavorite godoc
+// @Summary Save product to my favorites
+// @Tags User - Favorites
+// @Produce json
+// @Security BearerAuth
+// @Param productId path int true "Product ID"
+// @Success 200 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /user/favorites/{productId} [post]
+func (s *Server) AddFavorite(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+
+	productID, err := strconv.ParseInt(ctx.Param("productId"), 10, 64)
+	if err != nil || productID <= 0 {
+		ctx.Error(apperr.Validation("Invalid product id"))
+		return
+	}
+
+	err = s.Favorites.Add(ctx, userID, productID)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
-	config.Rdb.Set(context.Background(), key, jsonData, 15*time.Minute)
 
 	ctx.JSON(200, models.Response{
 		Success: true,
-		Message: "list products favorite",
-		Data:    cacheData,
+		Message: translate(ctx, "Product added to favorites"),
+	})
+}
+
+// RemoveFavorite godoc
+// @Summary Remove product from my favorites
+// @Tags User - Favorites
+// @Produce json
+// @Security BearerAuth
+// @Param productId path int true "Product ID"
+// @Success 200 {object} models.Response
+// @Router /user/favorites/{productId} [delete]
+func (s *Server) RemoveFavorite(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+
+	productID, err := strconv.ParseInt(ctx.Param("productId"), 10, 64)
+	if err != nil || productID <= 0 {
+		ctx.Error(apperr.Validation("Invalid product id"))
+		return
+	}
+
+	if err := s.Favorites.Remove(ctx, userID, productID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "Product removed from favorites"),
+	})
+}
+
+// AdminFeaturedList godoc
+// @Summary List featured products (Admin)
+// @Description All featured products with display order and schedule, active tells if it is shown now
+// @Tags Admin - Product
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.Response
+// @Router /admin/featured [get]
+func (s *Server) AdminFeaturedList(ctx *gin.Context) {
+	products, err := s.Favorites.FeaturedAdmin(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "list featured products"),
+		Data:    products,
+	})
+}
+
+// FeatureProduct godoc
+// @Summary Feature a product (Admin)
+// @Description Show product in /favorite-product with display order and optional schedule window
+// @Tags Admin - Product
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param request body models.FeatureProductRequest true "Display order and schedule"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /admin/product/{id}/featured [put]
+func (s *Server) FeatureProduct(ctx *gin.Context) {
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperr.Validation("Invalid product id"))
+		return
+	}
+
+	var req models.FeatureProductRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	if req.FeaturedFrom != nil && req.FeaturedUntil != nil && !req.FeaturedUntil.After(*req.FeaturedFrom) {
+		ctx.Error(apperr.Validation("featured_until must be after featured_from"))
+		return
+	}
+
+	err = s.Favorites.Feature(ctx, productID, req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	cache.Invalidate(ctx.Request.Context(), cache.FeaturedTag)
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "Product featured"),
+	})
+}
+
+// UnfeatureProduct godoc
+// @Summary Remove product from featured (Admin)
+// @Tags Admin - Product
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /admin/product/{id}/featured [delete]
+func (s *Server) UnfeatureProduct(ctx *gin.Context) {
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperr.Validation("Invalid product id"))
+		return
+	}
+
+	err = s.Favorites.Unfeature(ctx, productID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	cache.Invalidate(ctx.Request.Context(), cache.FeaturedTag)
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "Product removed from featured"),
 	})
 }
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"backend/models"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserFavorites(t *testing.T) {
+	s := newTestServer()
+	s.products.Create(t.Context(), models.CreateProductRequest{Name: "Americano"})
+	s.products.Create(t.Context(), models.CreateProductRequest{Name: "Caramel Latte"})
+
+	r := newRouter()
+	favorites := r.Group("/user/favorites", asUser(3, "user"))
+	favorites.GET("", s.UserFavorites)
+	favorites.POST("/:productId", s.AddFavorite)
+	favorites.DELETE("/:productId", s.RemoveFavorite)
+
+	assertStatus(t, serve(t, r, http.MethodPost, "/user/favorites/x", ""), 400)
+	assertStatus(t, serve(t, r, http.MethodPost, "/user/favorites/99", ""), 404)
+	assertStatus(t, serve(t, r, http.MethodPost, "/user/favorites/2", ""), 200)
+	assertStatus(t, serve(t, r, http.MethodPost, "/user/favorites/2", ""), 200)
+	assertStatus(t, serve(t, r, http.MethodPost, "/user/favorites/1", ""), 200)
+
+	// another user's favorite stays out of the list
+	s.favorites.Add(t.Context(), 4, 1)
+
+	w := serve(t, r, http.MethodGet, "/user/favorites?limit=1", "")
+	assertStatus(t, w, 200)
+	res, products := decodeResponse[[]models.Product](t, w.Body.Bytes())
+	if len(products) != 1 || products[0].Name != "Caramel Latte" {
+		t.Fatalf("favorites = %+v", products)
+	}
+	if res.Pagination == nil {
+		t.Fatal("pagination is missing")
+	}
+
+	assertStatus(t, serve(t, r, http.MethodDelete, "/user/favorites/2", ""), 200)
+	w = serve(t, r, http.MethodGet, "/user/favorites", "")
+	if _, products := decodeResponse[[]models.Product](t, w.Body.Bytes()); len(products) != 1 || products[0].ID != 1 {
+		t.Fatalf("favorites after delete = %+v", products)
+	}
+}
+
+func TestFeaturedProducts(t *testing.T) {
+	s := newTestServer()
+	s.products.Create(t.Context(), models.CreateProductRequest{Name: "Americano"})
+	s.products.Create(t.Context(), models.CreateProductRequest{Name: "Caramel Latte"})
+	s.favorites.Add(t.Context(), 3, 2)
+
+	r := newRouter()
+	r.GET("/featured-products", s.FavoriteProduct)
+	r.GET("/user/featured-products", asUser(3, "user"), s.FavoriteProduct)
+	admin := r.Group("/admin", asUser(1, "admin"))
+	admin.GET("/featured", s.AdminFeaturedList)
+	admin.PUT("/product/:id/featured", s.FeatureProduct)
+	admin.DELETE("/product/:id/featured", s.UnfeatureProduct)
+
+	assertStatus(t, serve(t, r, http.MethodPut, "/admin/product/99/featured", `{"display_order": 1}`), 404)
+	body := `{"featured_from": "2026-02-01T00:00:00Z", "featured_until": "2026-01-01T00:00:00Z"}`
+	assertStatus(t, serve(t, r, http.MethodPut, "/admin/product/2/featured", body), 400)
+	assertStatus(t, serve(t, r, http.MethodPut, "/admin/product/2/featured", `{"display_order": 1}`), 200)
+
+	w := serve(t, r, http.MethodGet, "/featured-products", "")
+	assertStatus(t, w, 200)
+	type featured struct {
+		Products []models.Product `json:"products"`
+	}
+	if _, data := decodeResponse[featured](t, w.Body.Bytes()); len(data.Products) != 1 || data.Products[0].ID != 2 || data.Products[0].IsFavorited {
+		t.Fatalf("featured = %+v", data.Products)
+	}
+
+	// is_favorited is set per user
+	w = serve(t, r, http.MethodGet, "/user/featured-products", "")
+	if _, data := decodeResponse[featured](t, w.Body.Bytes()); len(data.Products) != 1 || !data.Products[0].IsFavorited {
+		t.Fatalf("featured for user 3 = %+v", data.Products)
+	}
+
+	w = serve(t, r, http.MethodGet, "/admin/featured", "")
+	if _, list := decodeResponse[[]models.FeaturedProduct](t, w.Body.Bytes()); len(list) != 1 || *list[0].DisplayOrder != 1 {
+		t.Fatalf("admin featured = %+v", list)
+	}
+
+	assertStatus(t, serve(t, r, http.MethodDelete, "/admin/product/2/featured", ""), 200)
+	w = serve(t, r, http.MethodGet, "/featured-products", "")
+	if _, data := decodeResponse[featured](t, w.Body.Bytes()); len(data.Products) != 0 {
+		t.Fatalf("featured after unfeature = %+v", data.Products)
+	}
+}
+
+// a failing favorites lookup leaves the marks out but not the list, the
+// error goes to the request log
+func TestMarkFavoritedError(t *testing.T) {
+	s := newTestServer()
+	s.products.Create(t.Context(), models.CreateProductRequest{Name: "Americano"})
+	s.favorites.Add(t.Context(), 3, 1)
+	s.favorites.err = errors.New("connection reset")
+
+	var logged []error
+	r := newRouter()
+	r.GET("/products", func(ctx *gin.Context) {
+		ctx.Next()
+		for _, err := range ctx.Errors {
+			logged = append(logged, err.Err)
+		}
+	}, asUser(3, "user"), s.Product)
+
+	w := serve(t, r, http.MethodGet, "/products", "")
+	assertStatus(t, w, 200)
+	if _, products := decodeResponse[[]models.Product](t, w.Body.Bytes()); len(products) != 1 || products[0].IsFavorited {
+		t.Fatalf("products = %+v", products)
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0].Error(), "connection reset") {
+		t.Fatalf("request errors = %v", logged)
+	}
+}
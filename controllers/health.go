@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"backend/cache"
+	"backend/config"
+	"backend/lib"
+	"backend/models"
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const readinessTimeout = 2 * time.Second
+
+var startedAt = time.Now()
+
+type dependencyCheck struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// checkDependency pings one dependency, the error is only logged since
+// /readyz is public and driver errors name hosts and users
+func checkDependency(ctx *gin.Context, name string, ping func(ctx context.Context) error) dependencyCheck {
+	pingCtx, cancel := context.WithTimeout(context.Background(), readinessTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ping(pingCtx)
+	check := dependencyCheck{Status: "up", LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		check.Status = "down"
+		config.Logger(ctx).Warn("readiness check failed", "dependency", name, "error", err)
+	}
+
+	return check
+}
+
+// Healthz godoc
+// @Summary Liveness probe
+// @Description Always 200 while the process is running
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+func Healthz(ctx *gin.Context) {
+	ctx.JSON(200, gin.H{"status": "ok"})
+}
+
+// Readyz godoc
+// @Summary Readiness probe
+// @Description Pings Postgres and Redis and reports the migration version. 503 when the database is down, a down Redis only degrades.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]any
+// @Failure 503 {object} map[string]any
+// @Router /readyz [get]
+func Readyz(ctx *gin.Context) {
+	database := checkDependency(ctx, "database", config.PingDb)
+	redis := checkDependency(ctx, "redis", config.PingRedis)
+
+	checks := gin.H{
+		"database": database,
+		"redis":    redis,
+	}
+
+	ready := database.Status == "up"
+	if ready {
+		migrationCtx, cancel := context.WithTimeout(context.Background(), readinessTimeout)
+		migration, err := models.GetMigrationStatus(migrationCtx, config.Db)
+		cancel()
+
+		switch {
+		case err != nil:
+			config.Logger(ctx).Warn("readiness check failed", "dependency", "migration", "error", err)
+			checks["migration"] = gin.H{"status": "unknown"}
+		case migration == nil:
+			checks["migration"] = gin.H{"status": "untracked"}
+		default:
+			checks["migration"] = gin.H{"status": "up", "version": migration.Version}
+		}
+	}
+
+	status, code := "ready", 200
+	switch {
+	case !ready:
+		status, code = "not ready", 503
+	case redis.Status != "up":
+		status = "degraded"
+	}
+
+	ctx.JSON(code, gin.H{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// Status godoc
+// @Summary Service status
+// @Description Build info, uptime, dependency state, connection pool and cache statistics. Needs "Authorization: Bearer <METRICS_TOKEN>", 404 when METRICS_TOKEN is not set.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]any
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /status [get]
+func Status(ctx *gin.Context) {
+	uptime := time.Since(startedAt)
+
+	status := gin.H{
+		"build":          lib.GetBuildInfo(),
+		"started_at":     startedAt,
+		"uptime":         uptime.Round(time.Second).String(),
+		"uptime_seconds": int64(uptime.Seconds()),
+		"dependencies": gin.H{
+			"database": config.DbReady(),
+			"redis":    config.RedisReady(),
+		},
+		"cache": cache.Stats(),
+	}
+
+	if config.Db != nil {
+		stat := config.Db.Stat()
+		status["database_pool"] = gin.H{
+			"max_conns":              stat.MaxConns(),
+			"total_conns":            stat.TotalConns(),
+			"idle_conns":             stat.IdleConns(),
+			"acquired_conns":         stat.AcquiredConns(),
+			"constructing_conns":     stat.ConstructingConns(),
+			"acquire_count":          stat.AcquireCount(),
+			"empty_acquire_count":    stat.EmptyAcquireCount(),
+			"canceled_acquire_count": stat.CanceledAcquireCount(),
+			"acquire_duration_ms":    stat.AcquireDuration().Milliseconds(),
+		}
+	}
+
+	if config.Rdb != nil {
+		stat := config.Rdb.PoolStats()
+		status["redis_pool"] = gin.H{
+			"total_conns": stat.TotalConns,
+			"idle_conns":  stat.IdleConns,
+			"stale_conns": stat.StaleConns,
+			"hits":        stat.Hits,
+			"misses":      stat.Misses,
+			"timeouts":    stat.Timeouts,
+		}
+	}
+
+	ctx.JSON(200, status)
+}
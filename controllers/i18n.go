@@ -0,0 +1,17 @@
+package controllers
+
+import (
+	"backend/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// locale is the language of the request, set by middleware.Locale
+func locale(ctx *gin.Context) string {
+	return i18n.FromContext(ctx.Request.Context())
+}
+
+// translate words a response message in the language of the request
+func translate(ctx *gin.Context, message string, args ...any) string {
+	return i18n.T(locale(ctx), message, args...)
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"backend/apperr"
+	"backend/lib"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+type oidcState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+}
+
+// OIDCLogin godoc
+// @Summary Start social login
+// @Description Redirect to the provider login page (authorization code + PKCE)
+// @Tags Auth
+// @Param provider path string true "Provider name, ex: google"
+// @Success 302
+// @Failure 404 {object} models.Response
+// @Router /auth/oidc/{provider}/login [get]
+func (s *Server) OIDCLogin(ctx *gin.Context) {
+	provider, err := lib.GetOIDCProvider(ctx.Request.Context(), ctx.Param("provider"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	state := lib.RandomToken(16)
+	data := oidcState{
+		Provider:     provider.Name(),
+		CodeVerifier: oauth2.GenerateVerifier(),
+		Nonce:        lib.RandomToken(16),
+	}
+
+	jsonData, _ := json.Marshal(data)
+	if err := s.Tokens.SaveOIDCState(context.Background(), state, jsonData, 10*time.Minute); err != nil {
+		serviceUnavailable(ctx, "Redis")
+		return
+	}
+
+	ctx.Redirect(302, provider.AuthURL(state, data.Nonce, data.CodeVerifier))
+}
+
+// OIDCCallback godoc
+// @Summary Social login callback
+// @Description Exchange the authorization code and return a JWT token like /auth/login
+// @Tags Auth
+// @Produce json
+// @Param provider path string true "Provider name, ex: google"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Router /auth/oidc/{provider}/callback [get]
+func (s *Server) OIDCCallback(ctx *gin.Context) {
+	if errMsg := ctx.Query("error"); errMsg != "" {
+		ctx.Error(apperr.Newf(apperr.KindValidation, "login cancelled: %s", errMsg))
+		return
+	}
+
+	state := ctx.Query("state")
+	code := ctx.Query("code")
+	if state == "" || code == "" {
+		ctx.Error(apperr.Validation("code and state are required"))
+		return
+	}
+
+	redisCtx := context.Background()
+	raw, found, err := s.Tokens.TakeOIDCState(redisCtx, state)
+	if err != nil {
+		serviceUnavailable(ctx, "Redis")
+		return
+	}
+	if !found {
+		ctx.Error(apperr.Validation("invalid or expired state"))
+		return
+	}
+
+	var data oidcState
+	if err := json.Unmarshal(raw, &data); err != nil || data.Provider != ctx.Param("provider") {
+		ctx.Error(apperr.Validation("invalid or expired state"))
+		return
+	}
+
+	provider, err := lib.GetOIDCProvider(ctx.Request.Context(), data.Provider)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	identity, err := provider.Exchange(ctx.Request.Context(), code, data.CodeVerifier, data.Nonce)
+	if err != nil {
+		ctx.Error(apperr.Validation("login failed").Wrap(err))
+		return
+	}
+
+	user, err := s.Users.LoginWithIdentity(ctx, *identity)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	s.loginResponse(ctx, user)
+}
@@ -1,11 +1,11 @@
 package controllers
 
 import (
-	"backend/config"
+	"backend/apperr"
+	"backend/cache"
 	"backend/lib"
+	"backend/metrics"
 	"backend/models"
-	"context"
-	"database/sql"
 	"net/http"
 	"net/url"
 	"os"
@@ -24,82 +24,52 @@ import (
 // @Success 200 {object} models.Response
 // @Failure 400 {object} models.Response
 // @Router /orders [post]
-func CreateOrder(ctx *gin.Context) {
+func (s *Server) CreateOrder(ctx *gin.Context) {
 	userID := ctx.MustGet("user_id").(int64)
 
 	var req models.CreateOrderRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: "Invalid request body",
-			Data:    err.Error(),
-		})
-		return
-	}
-
-	//  wajib diisi
-	if req.PaymentID == 0 || req.MethodID == 0 {
-		ctx.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: "payment_id, shipping_id, and method_id are required",
-		})
+		ctx.Error(apperr.Binding(err))
 		return
 	}
 
 	if req.CustomerName == "" || req.CustomerPhone == "" || req.CustomerAddress == "" {
-		var userData struct {
-			Name    sql.NullString
-			Phone   sql.NullString
-			Address sql.NullString
-		}
-
-		err := config.Db.QueryRow(context.Background(), `
-	SELECT username, phone, address 
-	FROM profile 
-	WHERE id = $1
-`, userID).Scan(&userData.Name, &userData.Phone, &userData.Address)
+		contact, err := s.Orders.Contact(ctx, userID)
 		if err != nil {
-			ctx.JSON(http.StatusBadRequest, models.Response{
-				Success: false,
-				Message: "Failed to fetch user info",
-				Data:    err.Error(),
-			})
+			ctx.Error(err)
 			return
 		}
 
-		if !userData.Address.Valid || userData.Address.String == "" {
-			ctx.JSON(http.StatusBadRequest, models.Response{
-				Success: false,
-				Message: "complete the data first",
-			})
+		if contact.Address == "" {
+			ctx.Error(apperr.Validation("complete the data first"))
 			return
 		}
 
-		if req.CustomerName == "" && userData.Name.Valid {
-			req.CustomerName = userData.Name.String
+		if req.CustomerName == "" {
+			req.CustomerName = contact.Name
 		}
-		if req.CustomerPhone == "" && userData.Phone.Valid {
-			req.CustomerPhone = userData.Phone.String
+		if req.CustomerPhone == "" {
+			req.CustomerPhone = contact.Phone
 		}
-		if req.CustomerAddress == "" && userData.Address.Valid {
-			req.CustomerAddress = userData.Address.String
+		if req.CustomerAddress == "" {
+			req.CustomerAddress = contact.Address
 		}
 
 	}
 
-	order, err := models.CreateOrder(userID, req)
+	order, err := s.Orders.Create(ctx, userID, req)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: "Failed to create order",
-			Data:    err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
+	// recommendations are based on the order history
+	cache.Invalidate(ctx.Request.Context(), cache.UserTag(userID))
+	metrics.OrderCreated(order.Total)
+
 	ctx.JSON(http.StatusOK, models.Response{
 		Success: true,
-		Message: "Order created successfully",
+		Message: translate(ctx, "Order created successfully"),
 		Data:    order,
 	})
 }
@@ -110,10 +80,11 @@ func CreateOrder(ctx *gin.Context) {
 // @Tags User - Orders
 // @Produce json
 // @Security BearerAuth
+// @Param cursor query string false "Cursor pagination, empty for the first page then next_cursor / prev_cursor"
 // @Success 200 {object} models.Response
 // @Failure 500 {object} models.Response
 // @Router /user/history [get]
-func OrderHistory(ctx *gin.Context) {
+func (s *Server) OrderHistory(ctx *gin.Context) {
 	userData, _ := ctx.Get("user")
 	user := userData.(lib.UserPayload)
 
@@ -126,15 +97,32 @@ func OrderHistory(ctx *gin.Context) {
 		page = 1
 	}
 
-	history, totalItems, err := models.GetOrderHistoryByUserID(int64(user.Id), month, shippingID, page, limit)
-	if err != nil {
-		ctx.JSON(500, models.Response{
-			Success: false,
-			Message: err.Error(),
+	cursor, cursorMode, ok := cursorParam(ctx)
+	if !ok {
+		return
+	}
+	if cursorMode {
+		history, next, prev, err := s.Orders.HistoryCursor(ctx, int64(user.Id), month, shippingID, cursor, limit)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(200, models.Response{
+			Success:    true,
+			Message:    translate(ctx, "success from db"),
+			Pagination: cursorPagination(ctx, limit, next, prev),
+			Data:       history,
 		})
 		return
 	}
 
+	history, totalItems, err := s.Orders.History(ctx, int64(user.Id), month, shippingID, page, limit)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
 	totalPage := int((int64(totalItems) + int64(limit) - 1) / int64(limit))
 
 	rawQuery := ctx.Request.URL.Query()
@@ -151,7 +139,7 @@ func OrderHistory(ctx *gin.Context) {
 
 	ctx.JSON(200, models.Response{
 		Success:    true,
-		Message:    "success from db",
+		Message:    translate(ctx, "success from db"),
 		Pagination: pagination,
 		Data:       history,
 	})
@@ -168,21 +156,17 @@ func OrderHistory(ctx *gin.Context) {
 // @Failure 400 {object} models.Response
 // @Failure 500 {object} models.Response
 // @Router /user/order/{id} [get]
-func OrderDetail(ctx *gin.Context) {
+func (s *Server) OrderDetail(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	orderID, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: "Invalid order ID"})
+		ctx.Error(apperr.Validation("Invalid order ID"))
 		return
 	}
 
-	result, err := models.GetOrderDetail(int64(orderID))
+	result, err := s.Orders.Detail(ctx, int64(orderID))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.Response{
-			Success: false,
-			Message: err.Error()})
+		ctx.Error(err)
 		return
 	}
 
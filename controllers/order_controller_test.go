@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"backend/models"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestCreateOrder(t *testing.T) {
+	s := newTestServer()
+
+	r := newRouter()
+	user := r.Group("/user", asUser(5, "user"))
+	user.POST("/order", s.CreateOrder)
+	user.GET("/history", s.OrderHistory)
+	user.GET("/order/:id", s.OrderDetail)
+
+	assertStatus(t, serve(t, r, http.MethodPost, "/user/order", `{"payment_id": 1}`), 400)
+
+	// the profile has no address yet
+	assertStatus(t, serve(t, r, http.MethodPost, "/user/order", `{"payment_id": 1, "method_id": 1}`), 400)
+
+	s.orders.contacts[5] = models.OrderContact{Name: "Dina", Phone: "081234567890", Address: "Jl. Kopi 1"}
+
+	// empty cart
+	assertStatus(t, serve(t, r, http.MethodPost, "/user/order", `{"payment_id": 1, "method_id": 1}`), 400)
+
+	s.carts.Add(t.Context(), models.ReqCart{UserID: 5, ProductID: 1, Qty: 2})
+
+	w := serve(t, r, http.MethodPost, "/user/order", `{"payment_id": 1, "method_id": 1, "customer_name": "Dina Office"}`)
+	assertStatus(t, w, 200)
+
+	_, order := decodeResponse[models.OrderResponse](t, w.Body.Bytes())
+	if order.CustomerName != "Dina Office" || order.CustomerAddress != "Jl. Kopi 1" {
+		t.Fatalf("order = %+v", order)
+	}
+	if items, _ := s.carts.List(t.Context(), 5); len(items) != 0 {
+		t.Fatalf("cart not emptied: %+v", items)
+	}
+
+	w = serve(t, r, http.MethodGet, "/user/history", "")
+	assertStatus(t, w, 200)
+	if _, history := decodeResponse[[]map[string]any](t, w.Body.Bytes()); len(history) != 1 {
+		t.Fatalf("history = %+v", history)
+	}
+
+	assertStatus(t, serve(t, r, http.MethodGet, "/user/order/1", ""), 200)
+}
+
+func TestCreateOrderValidation(t *testing.T) {
+	s := newTestServer()
+
+	r := newRouter()
+	r.POST("/user/order", asUser(5, "user"), s.CreateOrder)
+
+	body := `{"payment_id": -1, "method_id": 1, "customer_phone": "12345"}`
+	w := serveWithHeader(t, r, http.MethodPost, "/user/order", body, http.Header{"Accept-Language": {"id-ID,id;q=0.9,en;q=0.8"}})
+	assertStatus(t, w, 400)
+
+	res, _ := decodeResponse[any](t, w.Body.Bytes())
+	if res.Message != "Permintaan tidak valid" || len(res.Errors) != 2 {
+		t.Fatalf("response = %+v", res)
+	}
+	if res.Errors[0].Field != "payment_id" || res.Errors[0].Message != "harus berupa id yang valid" {
+		t.Fatalf("payment_id error = %+v", res.Errors[0])
+	}
+	if res.Errors[1].Field != "customer_phone" {
+		t.Fatalf("customer_phone error = %+v", res.Errors[1])
+	}
+
+	w = serve(t, r, http.MethodPost, "/user/order", `{"payment_id": "satu", "method_id": 1}`)
+	assertStatus(t, w, 400)
+	if res, _ := decodeResponse[any](t, w.Body.Bytes()); len(res.Errors) != 1 || res.Errors[0].Message != "must be a number" {
+		t.Fatalf("type error = %+v", res)
+	}
+}
+
+func TestUpdateOrderStatus(t *testing.T) {
+	s := newTestServer()
+	s.carts.Add(t.Context(), models.ReqCart{UserID: 5, ProductID: 1, Qty: 1})
+	s.orders.Create(t.Context(), 5, models.CreateOrderRequest{CustomerAddress: "Jl. Kopi 1"})
+
+	r := newRouter()
+	admin := r.Group("/admin", asUser(1, "admin"))
+	admin.PUT("/orders/:id/status", s.UpdateOrderStatus)
+	admin.GET("/orders", s.AdminOrderList)
+
+	assertStatus(t, serve(t, r, http.MethodPut, "/admin/orders/x/status", `{"status": 2}`), 400)
+	assertStatus(t, serve(t, r, http.MethodPut, "/admin/orders/1/status", `{"status": 2}`), 200)
+	if status := s.orders.orders[1].Status; status != "Sending Goods" {
+		t.Fatalf("status = %q", status)
+	}
+
+	w := serve(t, r, http.MethodGet, "/admin/orders", "")
+	assertStatus(t, w, 200)
+	if _, orders := decodeResponse[[]models.OrderListItem](t, w.Body.Bytes()); len(orders) != 1 {
+		t.Fatalf("orders = %+v", orders)
+	}
+
+	s.orders.err = errors.New("connection reset")
+	assertStatus(t, serve(t, r, http.MethodGet, "/admin/orders", ""), 500)
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"backend/lib"
+	"net/url"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cursorParam reports whether the request asks for cursor pagination, an
+// empty ?cursor= is the first page. It answers 400 itself on a bad cursor.
+func cursorParam(ctx *gin.Context) (*lib.Cursor, bool, bool) {
+	raw, cursorMode := ctx.GetQuery("cursor")
+	if !cursorMode {
+		return nil, false, true
+	}
+
+	cursor, err := lib.DecodeCursor(raw)
+	if err != nil {
+		invalidCursor(ctx)
+		return nil, true, false
+	}
+
+	return cursor, true, true
+}
+
+func invalidCursor(ctx *gin.Context) {
+	ctx.Error(lib.ErrInvalidCursor)
+}
+
+func cursorPagination(ctx *gin.Context, limit int, nextCursor, prevCursor string) *lib.CursorPaginationData {
+	rawQuery := ctx.Request.URL.Query()
+	extraQuery := url.Values{}
+	for k, v := range rawQuery {
+		extraQuery[k] = append([]string{}, v...)
+	}
+
+	baseURL := os.Getenv("APP_BASE_URL")
+	path := ctx.Request.URL.Path
+
+	links := lib.HateoasCursor(baseURL, path, limit, nextCursor, prevCursor, extraQuery)
+	return lib.CursorPagination(limit, nextCursor, prevCursor, links)
+}
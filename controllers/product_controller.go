@@ -1,11 +1,12 @@
 package controllers
 
 import (
+	"backend/apperr"
+	"backend/cache"
 	"backend/config"
 	"backend/lib"
 	"backend/models"
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
@@ -18,14 +19,34 @@ import (
 )
 
 // admin
-func AdminProductList(ctx *gin.Context) {
+func (s *Server) AdminProductList(ctx *gin.Context) {
 	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "5"))
 	search := ctx.DefaultQuery("search", "")
 
-	products, totalItems, err := models.GetProductsAdmin(page, limit, search)
+	cursor, cursorMode, ok := cursorParam(ctx)
+	if !ok {
+		return
+	}
+	if cursorMode {
+		products, next, prev, err := s.Products.ListAdminCursor(ctx, cursor, limit, search)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(200, models.Response{
+			Success:    true,
+			Message:    translate(ctx, "admin product list"),
+			Pagination: cursorPagination(ctx, limit, next, prev),
+			Data:       products,
+		})
+		return
+	}
+
+	products, totalItems, err := s.Products.ListAdmin(ctx, page, limit, search)
 	if err != nil {
-		ctx.JSON(400, models.Response{Success: false, Message: err.Error()})
+		ctx.Error(err)
 		return
 	}
 	totalPage := int((totalItems + int64(limit) - 1) / int64(limit))
@@ -42,7 +63,7 @@ func AdminProductList(ctx *gin.Context) {
 
 	ctx.JSON(200, models.Response{
 		Success:    true,
-		Message:    "admin product list",
+		Message:    translate(ctx, "admin product list"),
 		Pagination: pagination,
 		Data:       products,
 	})
@@ -53,31 +74,60 @@ func AdminProductList(ctx *gin.Context) {
 // @Description Public product list
 // @Tags Products
 // @Produce json
-// @Param search query string false "Search keyword"
+// @Param q query string false "Search keyword (name, description, category, typo tolerant)"
 // @Param page query int false "Page number"
 // @Param limit query int false "Items per page"
+// @Param cursor query string false "Cursor pagination, empty for the first page then next_cursor / prev_cursor"
+// @Param category[] query []int false "Category ids"
+// @Param variant[] query []int false "Variant ids (ex: Hot, Ice)"
+// @Param size[] query []int false "Size ids"
+// @Param method[] query []int false "Delivery method ids"
+// @Param in_stock query bool false "Only products in stock"
+// @Param on_sale query bool false "Only products with an active discount"
+// @Param sort query string false "newest (default), oldest, price_low, price_high, name_asc, name_desc, rating_high, best_selling, relevance (default when searching)"
 // @Success 200 {object} models.Response
 // @Router /products [get]
 
 type ProductCache struct {
-	Products   []models.Product    `json:"products"`
-	Pagination *lib.PaginationData `json:"pagination"`
+	Products   []models.Product      `json:"products"`
+	Pagination *lib.PaginationData   `json:"pagination"`
+	Facets     *models.ProductFacets `json:"facets"`
+}
+
+type ProductDetailCache struct {
+	Product         models.Product   `json:"product"`
+	Recommendations []models.Product `json:"recommendations"`
+}
+
+// queryIDs reads an id list like ?size[]=1&size[]=2
+func queryIDs(ctx *gin.Context, key string) []int {
+	var ids []int
+	for _, c := range ctx.QueryArray(key) {
+		if id, err := strconv.Atoi(c); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
 
-func Product(ctx *gin.Context) {
+func (s *Server) Product(ctx *gin.Context) {
 	pageStr := ctx.DefaultQuery("page", "1")
 	limitStr := ctx.DefaultQuery("limit", "10")
 	search := ctx.DefaultQuery("q", "")
 	sort := ctx.DefaultQuery("sort", "")
 	minPrice := ctx.DefaultQuery("min_price", "")
 	maxPrice := ctx.DefaultQuery("max_price", "")
-	categoryStr := ctx.QueryArray("category[]")
 
-	var categoryIDs []int
-	for _, c := range categoryStr {
-		if id, err := strconv.Atoi(c); err == nil {
-			categoryIDs = append(categoryIDs, id)
-		}
+	filter := models.ProductFilter{
+		Search:      search,
+		MinPrice:    parseInt(minPrice),
+		MaxPrice:    parseInt(maxPrice),
+		CategoryIDs: queryIDs(ctx, "category[]"),
+		VariantIDs:  queryIDs(ctx, "variant[]"),
+		SizeIDs:     queryIDs(ctx, "size[]"),
+		MethodIDs:   queryIDs(ctx, "method[]"),
+		InStock:     ctx.Query("in_stock") == "true",
+		OnSale:      ctx.Query("on_sale") == "true",
 	}
 
 	page, _ := strconv.Atoi(pageStr)
@@ -90,72 +140,143 @@ func Product(ctx *gin.Context) {
 		limit = 10
 	}
 
-	isCachable := (search == "" && sort == "" && minPrice == "" && maxPrice == "" && len(categoryIDs) == 0)
-	var cacheKey string
-
-	if isCachable {
-		cacheKey = fmt.Sprintf("products:page:%d:limit:%d", page, limit)
-
-		var cacheData ProductCache
-		cacheString, err := config.Rdb.Get(context.Background(), cacheKey).Result()
-		if err == nil && cacheString != "" {
-			_ = json.Unmarshal([]byte(cacheString), &cacheData)
-
-			ctx.JSON(200, models.Response{
-				Success:    true,
-				Message:    "success from cache",
-				Pagination: cacheData.Pagination,
-				Data:       cacheData.Products,
-			})
+	cursor, cursorMode, ok := cursorParam(ctx)
+	if !ok {
+		return
+	}
+	if cursorMode {
+		products, next, prev, err := s.Products.ListCursor(ctx, cursor, limit, sort, filter)
+		if err != nil {
+			ctx.Error(err)
 			return
 		}
-	}
 
-	products, total, err := models.GetProducts(page, limit, search, sort, parseInt(minPrice), parseInt(maxPrice), categoryIDs)
-	if err != nil {
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		response := models.Response{
+			Success:    true,
+			Message:    translate(ctx, "success from db"),
+			Pagination: cursorPagination(ctx, limit, next, prev),
+			Data:       products,
+		}
+
+		// facets don't change between pages, only the first page has them
+		if cursor == nil {
+			facets, err := s.Products.Facets(ctx, filter)
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+			response.Facets = facets
+		}
+
+		s.markFavorited(ctx, products)
+		ctx.JSON(200, response)
 		return
 	}
 
-	totalPage := int((total + int64(limit) - 1) / int64(limit))
+	isCachable := (search == "" && sort == "" && minPrice == "" && maxPrice == "" &&
+		len(filter.CategoryIDs) == 0 && len(filter.VariantIDs) == 0 && len(filter.SizeIDs) == 0 &&
+		len(filter.MethodIDs) == 0 && !filter.InStock && !filter.OnSale)
 
-	rawQuery := ctx.Request.URL.Query()
-	extraQuery := url.Values{}
-	for k, v := range rawQuery {
-		extraQuery[k] = append([]string{}, v...)
-	}
+	load := func(loadCtx context.Context) (ProductCache, error) {
+		products, total, err := s.Products.List(loadCtx, page, limit, sort, filter)
+		if err != nil {
+			return ProductCache{}, err
+		}
 
-	baseURL := os.Getenv("APP_BASE_URL")
-	path := ctx.Request.URL.Path
+		facets, err := s.Products.Facets(loadCtx, filter)
+		if err != nil {
+			return ProductCache{}, err
+		}
 
-	hateoasURL := lib.Hateoas(baseURL, path, page, limit, totalPage, extraQuery)
+		totalPage := int((total + int64(limit) - 1) / int64(limit))
 
-	pagination := lib.Pagination(page, limit, totalPage, total, hateoasURL)
+		rawQuery := ctx.Request.URL.Query()
+		extraQuery := url.Values{}
+		for k, v := range rawQuery {
+			extraQuery[k] = append([]string{}, v...)
+		}
 
-	if isCachable {
-		cacheValue := ProductCache{
+		baseURL := os.Getenv("APP_BASE_URL")
+		path := ctx.Request.URL.Path
+
+		hateoasURL := lib.Hateoas(baseURL, path, page, limit, totalPage, extraQuery)
+
+		return ProductCache{
 			Products:   products,
-			Pagination: pagination,
-		}
+			Pagination: lib.Pagination(page, limit, totalPage, total, hateoasURL),
+			Facets:     facets,
+		}, nil
+	}
 
-		jsonData, err := json.Marshal(cacheValue)
-		if err == nil {
-			config.Rdb.Set(context.Background(), cacheKey, jsonData, 15*time.Minute)
-		}
+	var data ProductCache
+	var fromCache bool
+	var err error
+	if isCachable {
+		cacheKey := fmt.Sprintf("products:%s:page:%d:limit:%d", locale(ctx), page, limit)
+		data, fromCache, err = cache.Remember(ctx.Request.Context(), cacheKey, 15*time.Minute,
+			[]string{cache.ProductsTag, cache.CategoriesTag}, load)
+	} else {
+		data, err = load(ctx)
+	}
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	message := "success from db"
+	if fromCache {
+		message = "success from cache"
 	}
 
+	s.markFavorited(ctx, data.Products)
 	ctx.JSON(200, models.Response{
 		Success:    true,
-		Message:    "success from db",
-		Pagination: pagination,
-		Data:       products,
+		Message:    translate(ctx, message),
+		Pagination: data.Pagination,
+		Data:       data.Products,
+		Facets:     data.Facets,
 	})
 }
 
 
+// ProductSuggest godoc
+// @Summary Product search autocomplete
+// @Description Product names in the request language matching the typed text, prefix matches first
+// @Tags Products
+// @Produce json
+// @Param q query string true "Typed text"
+// @Param limit query int false "Max suggestions (default 5, max 20)"
+// @Success 200 {object} models.Response
+// @Router /products/suggest [get]
+func (s *Server) ProductSuggest(ctx *gin.Context) {
+	q := strings.TrimSpace(ctx.Query("q"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "5"))
+	if limit < 1 || limit > 20 {
+		limit = 5
+	}
+
+	cacheKey := fmt.Sprintf("products:suggest:%s:%s:limit:%d", locale(ctx), strings.ToLower(q), limit)
+	suggestions, fromCache, err := cache.Remember(ctx.Request.Context(), cacheKey, 5*time.Minute,
+		[]string{cache.ProductsTag, cache.CategoriesTag}, func(loadCtx context.Context) ([]models.ProductSuggestion, error) {
+			return s.Products.Suggest(loadCtx, q, limit)
+		})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	message := "suggestions"
+	if fromCache {
+		message = "suggestions from cache"
+	}
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, message),
+		Data:    suggestions,
+	})
+}
+
 func parseInt(s string) int {
     v, _ := strconv.Atoi(s)
     return v
@@ -170,34 +291,47 @@ func parseInt(s string) int {
 // @Param id path int true "Product ID"
 // @Success 200 {object} models.Response
 // @Router /products/{id} [get]
-func ProductDetail(c *gin.Context) {
+func (s *Server) ProductDetail(c *gin.Context) {
 	idParam := c.Param("id")
 	productID, err := strconv.ParseInt(idParam, 10, 64)
 	if err != nil {
-		c.JSON(400, models.Response{
-			Success: false,
-			Message: "Invalid product id",
-		})
+		c.Error(apperr.Validation("Invalid product id"))
 		return
 	}
 
-	product, err := models.GetProductByID(productID)
-	if err != nil {
-		c.JSON(400, models.Response{
-			Success: false,
-			Message: "product not found",
+	detail, fromCache, err := cache.Remember(c.Request.Context(), fmt.Sprintf("product:%d:%s", productID, locale(c)), 15*time.Minute,
+		[]string{cache.ProductTag(productID), cache.ProductsTag, cache.CategoriesTag}, func(loadCtx context.Context) (ProductDetailCache, error) {
+			product, err := s.Products.Get(loadCtx, productID)
+			if err != nil {
+				return ProductDetailCache{}, err
+			}
+
+			recommendations, err := s.Products.Recommendations(loadCtx, product)
+			if err != nil {
+				config.Logger(c).Warn("product recommendations", "product_id", productID, "error", err)
+			}
+			return ProductDetailCache{Product: *product, Recommendations: recommendations}, nil
 		})
+	if err != nil {
+		c.Error(notFound(err, models.ErrProductNotFound))
 		return
 	}
 
-	recommendations, _ := models.GetRecommendationsByCategory(product.Category, product.ID)
+	product := []models.Product{detail.Product}
+	s.markFavorited(c, product)
+	s.markFavorited(c, detail.Recommendations)
+
+	message := "success"
+	if fromCache {
+		message = "success from cache"
+	}
 
 	c.JSON(200, models.Response{
 		Success: true,
-		Message: "success",
+		Message: translate(c, message),
 		Data: gin.H{
-			"product":         product,
-			"recommendations": recommendations,
+			"product":         product[0],
+			"recommendations": detail.Recommendations,
 		},
 	})
 }
@@ -213,35 +347,25 @@ func ProductDetail(c *gin.Context) {
 // @Success 200 {object} models.Response
 // @Failure 400 {object} models.Response
 // @Router /admin/product [post]
-func CreateProduct(ctx *gin.Context) {
+func (s *Server) CreateProduct(ctx *gin.Context) {
 	var req models.CreateProductRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		ctx.Error(apperr.Binding(err))
 		return
 	}
 
-	product, err := models.CreateProduct(req)
+	product, err := s.Products.Create(ctx, req)
 	if err != nil {
-		ctx.JSON(500, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
-	redisCtx := context.Background()
-	iter := config.Rdb.Scan(redisCtx, 0, "products*", 0).Iterator()
-	for iter.Next(redisCtx) {
-		config.Rdb.Del(redisCtx, iter.Val())
-	}
+	cache.Invalidate(ctx.Request.Context(), cache.ProductsTag)
 
 	ctx.JSON(201, models.Response{
 		Success: true,
-		Message: "Product created",
+		Message: translate(ctx, "Product created"),
 		Data:    product,
 	})
 }
@@ -258,30 +382,26 @@ func CreateProduct(ctx *gin.Context) {
 // @Success 200 {object} models.Response
 // @Failure 400 {object} models.Response
 // @Router /admin/product/{id} [put]
-func UpdateProduct(ctx *gin.Context) {
+func (s *Server) UpdateProduct(ctx *gin.Context) {
 	id, _ := strconv.Atoi(ctx.Param("id"))
 
 	var req models.CreateProductRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(400, models.Response{Success: false, Message: err.Error()})
+		ctx.Error(apperr.Binding(err))
 		return
 	}
 
-	product, err := models.UpdateProduct(int64(id), req)
+	product, err := s.Products.Update(ctx, int64(id), req)
 	if err != nil {
-		ctx.JSON(500, models.Response{Success: false, Message: err.Error()})
+		ctx.Error(err)
 		return
 	}
 
-	redisCtx := context.Background()
-	iter := config.Rdb.Scan(redisCtx, 0, "products*", 0).Iterator()
-	for iter.Next(redisCtx) {
-		config.Rdb.Del(redisCtx, iter.Val())
-	}
+	cache.Invalidate(ctx.Request.Context(), cache.ProductTag(int64(id)), cache.ProductsTag)
 
 	ctx.JSON(200, models.Response{
 		Success: true,
-		Message: "Product updated",
+		Message: translate(ctx, "Product updated"),
 		Data:    product,
 	})
 }
@@ -295,33 +415,23 @@ func UpdateProduct(ctx *gin.Context) {
 // @Failure 400 {object} models.Response
 // @Failure 500 {object} models.Response
 // @Router /admin/products/{id} [delete]
-func DeleteProduct(ctx *gin.Context) {
+func (s *Server) DeleteProduct(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(400, models.Response{
-			Success: false,
-			Message: "Invalid product id",
-		})
+		ctx.Error(apperr.Validation("Invalid product id"))
 		return
 	}
 
-	err = models.DeleteProduct(int64(id))
+	err = s.Products.Delete(ctx, int64(id))
 	if err != nil {
-		ctx.JSON(500, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
-	redisCtx := context.Background()
-	iter := config.Rdb.Scan(redisCtx, 0, "products*", 0).Iterator()
-	for iter.Next(redisCtx) {
-		config.Rdb.Del(redisCtx, iter.Val())
-	}
+	cache.Invalidate(ctx.Request.Context(), cache.ProductTag(int64(id)), cache.ProductsTag)
 	ctx.JSON(200, models.Response{
 		Success: true,
-		Message: "Product deleted",
+		Message: translate(ctx, "Product deleted"),
 	})
 }
 
@@ -338,17 +448,17 @@ func DeleteProduct(ctx *gin.Context) {
 // @Failure 403 {object} models.Response
 // @Failure 500 {object} models.Response
 // @Router /admin/products/{id}/image [post]
-func UploadProductImages(ctx *gin.Context) {
+func (s *Server) UploadProductImages(ctx *gin.Context) {
 	productIDParam := ctx.Param("id")
 	productID, err := strconv.Atoi(productIDParam)
 	if err != nil {
-		ctx.JSON(400, models.Response{Success: false, Message: "invalid product id"})
+		ctx.Error(apperr.Validation("invalid product id"))
 		return
 	}
 
 	file, err := ctx.FormFile("image")
 	if err != nil {
-		ctx.JSON(400, models.Response{Success: false, Message: "file not provided: " + err.Error()})
+		ctx.Error(apperr.Validation("file not provided").Wrap(err))
 		return
 	}
 
@@ -363,13 +473,13 @@ func UploadProductImages(ctx *gin.Context) {
 		}
 	}
 	if !valid {
-		ctx.JSON(400, models.Response{Success: false, Message: "invalid file extension. Only .jpg, .jpeg, .png allowed"})
+		ctx.Error(apperr.Validation("invalid file extension. Only .jpg, .jpeg, .png allowed"))
 		return
 	}
 
 	src, err := file.Open()
 	if err != nil {
-		ctx.JSON(500, models.Response{Success: false, Message: "cannot open file: " + err.Error()})
+		ctx.Error(apperr.Internal("cannot open file").Wrap(err))
 		return
 	}
 	defer src.Close()
@@ -377,18 +487,20 @@ func UploadProductImages(ctx *gin.Context) {
 	// cloudinary
 	uploadedURL, err := lib.UploadImage(src)
 	if err != nil {
-		ctx.JSON(500, models.Response{Success: false, Message: "failed upload to cloudinary: " + err.Error()})
+		ctx.Error(apperr.Internal("failed upload to cloudinary").Wrap(err))
 		return
 	}
 	
-	if err := models.UploadImgProduct(int64(productID), uploadedURL); err != nil {
-		ctx.JSON(500, models.Response{Success: false, Message: err.Error()})
+	if err := s.Products.SetImage(ctx, int64(productID), uploadedURL); err != nil {
+		ctx.Error(err)
 		return
 	}
 
+	cache.Invalidate(ctx.Request.Context(), cache.ProductTag(int64(productID)), cache.ProductsTag)
+
 	ctx.JSON(200, models.Response{
 		Success: true,
-		Message: "upload success",
+		Message: translate(ctx, "upload success"),
 		Data:    gin.H{"image_url": uploadedURL},
 	})
 }
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"backend/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func decodeResponse[T any](t *testing.T, body []byte) (models.Response, T) {
+	t.Helper()
+
+	var raw struct {
+		models.Response
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+
+	var data T
+	if len(raw.Data) > 0 {
+		if err := json.Unmarshal(raw.Data, &data); err != nil {
+			t.Fatalf("decode data %s: %v", raw.Data, err)
+		}
+	}
+	return raw.Response, data
+}
+
+func TestProductList(t *testing.T) {
+	s := newTestServer()
+	s.products = newFakeProducts(
+		models.Product{ID: 1, Name: "Americano"},
+		models.Product{ID: 2, Name: "Caramel Latte"},
+		models.Product{ID: 3, Name: "Vanilla Latte"},
+	)
+	s.Products = s.products
+
+	r := newRouter()
+	r.GET("/products", s.Product)
+
+	w := serve(t, r, http.MethodGet, "/products?page=1&limit=2", "")
+	assertStatus(t, w, 200)
+
+	res, products := decodeResponse[[]models.Product](t, w.Body.Bytes())
+	if len(products) != 2 || products[0].Name != "Americano" {
+		t.Fatalf("products = %+v", products)
+	}
+	if res.Pagination == nil {
+		t.Fatal("pagination missing")
+	}
+
+	w = serve(t, r, http.MethodGet, "/products?q=latte", "")
+	assertStatus(t, w, 200)
+	if _, products := decodeResponse[[]models.Product](t, w.Body.Bytes()); len(products) != 2 {
+		t.Fatalf("search latte = %+v", products)
+	}
+}
+
+func TestProductListTimeout(t *testing.T) {
+	s := newTestServer()
+	s.products.err = context.DeadlineExceeded
+
+	r := newRouter()
+	r.GET("/products", s.Product)
+
+	assertStatus(t, serve(t, r, http.MethodGet, "/products?q=slow", ""), 504)
+}
+
+func TestProductDetail(t *testing.T) {
+	s := newTestServer()
+	s.products = newFakeProducts(models.Product{ID: 7, Name: "Mocha"})
+	s.Products = s.products
+
+	r := newRouter()
+	r.GET("/products/:id", s.ProductDetail)
+
+	w := serve(t, r, http.MethodGet, "/products/7", "")
+	assertStatus(t, w, 200)
+
+	_, data := decodeResponse[struct {
+		Product models.Product `json:"product"`
+	}](t, w.Body.Bytes())
+	if data.Product.Name != "Mocha" {
+		t.Fatalf("product = %+v", data.Product)
+	}
+
+	assertStatus(t, serve(t, r, http.MethodGet, "/products/99", ""), 404)
+	assertStatus(t, serve(t, r, http.MethodGet, "/products/abc", ""), 400)
+}
+
+func TestAdminProductCrud(t *testing.T) {
+	s := newTestServer()
+
+	r := newRouter()
+	r.POST("/admin/product-create", s.CreateProduct)
+	r.PUT("/admin/product/:id", s.UpdateProduct)
+	r.DELETE("/admin/product/:id", s.DeleteProduct)
+
+	assertStatus(t, serve(t, r, http.MethodPost, "/admin/product-create", `{"stock": 1}`), 400)
+
+	w := serve(t, r, http.MethodPost, "/admin/product-create", `{"name": "Flat White", "stock": 5, "base_price": 30000}`)
+	assertStatus(t, w, 201)
+	_, created := decodeResponse[models.Product](t, w.Body.Bytes())
+
+	w = serve(t, r, http.MethodPut, "/admin/product/1", `{"name": "Flat White Oat", "stock": 4}`)
+	assertStatus(t, w, 200)
+	if s.products.products[created.ID].Name != "Flat White Oat" {
+		t.Fatalf("product not updated: %+v", s.products.products[created.ID])
+	}
+
+	assertStatus(t, serve(t, r, http.MethodDelete, "/admin/product/1", ""), 200)
+	if len(s.products.products) != 0 {
+		t.Fatalf("product not deleted: %+v", s.products.products)
+	}
+}
+
+// offset mode keeps every key even for an empty listing, cursor mode has no
+// totals
+func TestPaginationKeys(t *testing.T) {
+	s := newTestServer()
+
+	r := newRouter()
+	r.GET("/admin/product", s.AdminProductList)
+
+	pagination := func(target string) map[string]any {
+		t.Helper()
+		w := serve(t, r, http.MethodGet, target, "")
+		assertStatus(t, w, 200)
+
+		var res struct {
+			Pagination map[string]any `json:"pagination"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		return res.Pagination
+	}
+
+	offset := pagination("/admin/product?page=1&limit=5")
+	for _, key := range []string{"page", "limit", "total_page", "total_items", "links"} {
+		if _, ok := offset[key]; !ok {
+			t.Errorf("offset pagination %v has no %s", offset, key)
+		}
+	}
+
+	cursor := pagination("/admin/product?cursor=&limit=5")
+	for _, key := range []string{"page", "total_page", "total_items"} {
+		if _, ok := cursor[key]; ok {
+			t.Errorf("cursor pagination %v has %s", cursor, key)
+		}
+	}
+	if cursor["limit"] != float64(5) {
+		t.Errorf("cursor pagination %v, want limit 5", cursor)
+	}
+}
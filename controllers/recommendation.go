@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"backend/cache"
+	"backend/models"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserRecommendations godoc
+// @Summary Recommended products for me
+// @Description Based on my order history (customers also bought, popular in the same categories), best sellers for new customers
+// @Tags User
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Max products (default 10, max 20)"
+// @Success 200 {object} models.Response
+// @Router /user/recommendations [get]
+func (s *Server) UserRecommendations(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 20 {
+		limit = 10
+	}
+
+	key := fmt.Sprintf("recommendations:user:%d:%s:limit:%d", userID, locale(ctx), limit)
+	tags := []string{cache.UserTag(userID), cache.ProductsTag, cache.CategoriesTag}
+
+	products, fromCache, err := cache.Remember(ctx.Request.Context(), key, 10*time.Minute, tags, func(loadCtx context.Context) ([]models.Product, error) {
+		return s.Products.UserRecommendations(loadCtx, userID, limit)
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	message := "recommendations"
+	if fromCache {
+		message = "recommendations ( from cache )"
+	}
+
+	s.markFavorited(ctx, products)
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, message),
+		Data:    products,
+	})
+}
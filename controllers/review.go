@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"backend/apperr"
+	"backend/cache"
+	"backend/config"
+	"backend/lib"
+	"backend/models"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clearRatingCache drops cached products, average_rating and review_count
+// are part of them
+func clearRatingCache(ctx *gin.Context) {
+	cache.Invalidate(ctx.Request.Context(), cache.ProductsTag)
+}
+
+func reviewListResponse(ctx *gin.Context, message string, list func(page, limit int) ([]models.Review, int64, error)) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	reviews, totalItems, err := list(page, limit)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	totalPage := int((totalItems + int64(limit) - 1) / int64(limit))
+
+	rawQuery := ctx.Request.URL.Query()
+	extraQuery := url.Values{}
+	for k, v := range rawQuery {
+		extraQuery[k] = append([]string{}, v...)
+	}
+
+	baseURL := os.Getenv("APP_BASE_URL")
+	path := ctx.Request.URL.Path
+
+	links := lib.Hateoas(baseURL, path, page, limit, totalPage, extraQuery)
+	pagination := lib.Pagination(page, limit, totalPage, totalItems, links)
+
+	ctx.JSON(200, models.Response{
+		Success:    true,
+		Message:    translate(ctx, message),
+		Pagination: pagination,
+		Data:       reviews,
+	})
+}
+
+// ProductReviews godoc
+// @Summary List product reviews
+// @Description Approved reviews of a product, newest first
+// @Tags Products - Reviews
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} models.Response
+// @Router /products/{id}/reviews [get]
+func (s *Server) ProductReviews(ctx *gin.Context) {
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || productID <= 0 {
+		ctx.Error(apperr.Validation("Invalid product id"))
+		return
+	}
+
+	reviewListResponse(ctx, "list product reviews", func(page, limit int) ([]models.Review, int64, error) {
+		return s.Reviews.ListByProduct(ctx, productID, page, limit)
+	})
+}
+
+// CreateReview godoc
+// @Summary Review a product
+// @Description Post a 1-5 star rating and review, only for products in a completed order
+// @Tags Products - Reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param request body models.ReviewRequest true "Rating and review"
+// @Success 201 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Router /products/{id}/reviews [post]
+func (s *Server) CreateReview(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || productID <= 0 {
+		ctx.Error(apperr.Validation("Invalid product id"))
+		return
+	}
+
+	var req models.ReviewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	review, err := s.Reviews.Create(ctx, userID, productID, req, config.NewReviewStatus())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	clearRatingCache(ctx)
+
+	message := "review posted"
+	if review.Status == "pending" {
+		message = "review posted, waiting for approval"
+	}
+	ctx.JSON(201, models.Response{
+		Success: true,
+		Message: translate(ctx, message),
+		Data:    review,
+	})
+}
+
+// UpdateReview godoc
+// @Summary Edit my review
+// @Tags User - Reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Param request body models.ReviewRequest true "Rating and review"
+// @Success 200 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /user/reviews/{id} [put]
+func (s *Server) UpdateReview(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+
+	reviewID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || reviewID <= 0 {
+		ctx.Error(apperr.Validation("Invalid review id"))
+		return
+	}
+
+	var req models.ReviewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	review, err := s.Reviews.Update(ctx, userID, reviewID, req, config.NewReviewStatus())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	clearRatingCache(ctx)
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "review updated"),
+		Data:    review,
+	})
+}
+
+// DeleteReview godoc
+// @Summary Delete my review
+// @Tags User - Reviews
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Success 200 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /user/reviews/{id} [delete]
+func (s *Server) DeleteReview(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+
+	reviewID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || reviewID <= 0 {
+		ctx.Error(apperr.Validation("Invalid review id"))
+		return
+	}
+
+	if err := s.Reviews.Delete(ctx, userID, reviewID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	clearRatingCache(ctx)
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "review deleted"),
+	})
+}
+
+// AdminReviewList godoc
+// @Summary List reviews (Admin)
+// @Tags Admin - Reviews
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, approved or hidden"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} models.Response
+// @Router /admin/reviews [get]
+func (s *Server) AdminReviewList(ctx *gin.Context) {
+	status := ctx.Query("status")
+	switch status {
+	case "", "pending", "approved", "hidden":
+	default:
+		ctx.Error(apperr.Validation("status must be pending, approved or hidden"))
+		return
+	}
+
+	reviewListResponse(ctx, "list reviews", func(page, limit int) ([]models.Review, int64, error) {
+		return s.Reviews.ListAdmin(ctx, status, page, limit)
+	})
+}
+
+// SetReviewStatus godoc
+// @Summary Approve or hide a review (Admin)
+// @Tags Admin - Reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Param request body models.ReviewStatusRequest true "approved or hidden"
+// @Success 200 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /admin/reviews/{id}/status [put]
+func (s *Server) SetReviewStatus(ctx *gin.Context) {
+	reviewID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || reviewID <= 0 {
+		ctx.Error(apperr.Validation("Invalid review id"))
+		return
+	}
+
+	var req models.ReviewStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	if err := s.Reviews.SetStatus(ctx, reviewID, req.Status); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	clearRatingCache(ctx)
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "review "+req.Status),
+	})
+}
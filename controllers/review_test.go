@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"backend/models"
+	"net/http"
+	"testing"
+)
+
+func TestReviews(t *testing.T) {
+	s := newTestServer()
+	s.reviews.buyers[3] = []int64{1}
+
+	r := newRouter()
+	r.GET("/products/:id/reviews", s.ProductReviews)
+	r.POST("/products/:id/reviews", asUser(3, "user"), s.CreateReview)
+	r.POST("/other/products/:id/reviews", asUser(4, "user"), s.CreateReview)
+	user := r.Group("/user/reviews", asUser(3, "user"))
+	user.PUT("/:id", s.UpdateReview)
+	user.DELETE("/:id", s.DeleteReview)
+
+	assertStatus(t, serve(t, r, http.MethodPost, "/products/1/reviews", `{"rating": 6}`), 400)
+	// only buyers review, once
+	assertStatus(t, serve(t, r, http.MethodPost, "/other/products/1/reviews", `{"rating": 5}`), 403)
+	assertStatus(t, serve(t, r, http.MethodPost, "/products/2/reviews", `{"rating": 5}`), 403)
+	w := serve(t, r, http.MethodPost, "/products/1/reviews", `{"rating": 4, "review": "enak"}`)
+	assertStatus(t, w, 201)
+	if _, review := decodeResponse[models.Review](t, w.Body.Bytes()); review.Status != "approved" {
+		t.Fatalf("review = %+v", review)
+	}
+	assertStatus(t, serve(t, r, http.MethodPost, "/products/1/reviews", `{"rating": 5}`), 409)
+
+	assertStatus(t, serve(t, r, http.MethodPut, "/user/reviews/99", `{"rating": 5}`), 404)
+	assertStatus(t, serve(t, r, http.MethodPut, "/user/reviews/1", `{"rating": 5, "review": "enak sekali"}`), 200)
+
+	w = serve(t, r, http.MethodGet, "/products/1/reviews", "")
+	assertStatus(t, w, 200)
+	if _, reviews := decodeResponse[[]models.Review](t, w.Body.Bytes()); len(reviews) != 1 || reviews[0].Rating != 5 {
+		t.Fatalf("reviews = %+v", reviews)
+	}
+
+	assertStatus(t, serve(t, r, http.MethodDelete, "/user/reviews/1", ""), 200)
+	assertStatus(t, serve(t, r, http.MethodDelete, "/user/reviews/1", ""), 404)
+}
+
+func TestReviewModeration(t *testing.T) {
+	t.Setenv("REVIEW_REQUIRE_APPROVAL", "true")
+
+	s := newTestServer()
+	s.reviews.buyers[3] = []int64{1}
+
+	r := newRouter()
+	r.GET("/products/:id/reviews", s.ProductReviews)
+	r.POST("/products/:id/reviews", asUser(3, "user"), s.CreateReview)
+	r.PUT("/user/reviews/:id", asUser(3, "user"), s.UpdateReview)
+	admin := r.Group("/admin/reviews", asUser(1, "admin"))
+	admin.GET("", s.AdminReviewList)
+	admin.PUT("/:id/status", s.SetReviewStatus)
+
+	w := serve(t, r, http.MethodPost, "/products/1/reviews", `{"rating": 4}`)
+	assertStatus(t, w, 201)
+	if res, _ := decodeResponse[models.Review](t, w.Body.Bytes()); res.Message != "review posted, waiting for approval" {
+		t.Fatalf("message = %q", res.Message)
+	}
+
+	reviewCount := func(target string) int {
+		t.Helper()
+		w := serve(t, r, http.MethodGet, target, "")
+		assertStatus(t, w, 200)
+		_, reviews := decodeResponse[[]models.Review](t, w.Body.Bytes())
+		return len(reviews)
+	}
+	if n := reviewCount("/products/1/reviews"); n != 0 {
+		t.Fatalf("%d pending reviews listed", n)
+	}
+	if n := reviewCount("/admin/reviews?status=pending"); n != 1 {
+		t.Fatalf("%d pending reviews for admin, want 1", n)
+	}
+	assertStatus(t, serve(t, r, http.MethodGet, "/admin/reviews?status=spam", ""), 400)
+
+	assertStatus(t, serve(t, r, http.MethodPut, "/admin/reviews/1/status", `{"status": "approved"}`), 200)
+	if n := reviewCount("/products/1/reviews"); n != 1 {
+		t.Fatalf("%d approved reviews listed, want 1", n)
+	}
+
+	// a hidden review stays hidden when its author edits it
+	assertStatus(t, serve(t, r, http.MethodPut, "/admin/reviews/1/status", `{"status": "hidden"}`), 200)
+	assertStatus(t, serve(t, r, http.MethodPut, "/user/reviews/1", `{"rating": 5}`), 200)
+	if n := reviewCount("/admin/reviews?status=hidden"); n != 1 {
+		t.Fatalf("%d hidden reviews, want 1", n)
+	}
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"backend/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Server holds the repositories behind the handlers, tests swap them for
+// in-memory fakes
+type Server struct {
+	Products   models.ProductRepository
+	Orders     models.OrderRepository
+	Users      models.UserRepository
+	Carts      models.CartRepository
+	Categories models.CategoryRepository
+	Favorites  models.FavoriteRepository
+	Reviews    models.ReviewRepository
+	TwoFactor  models.TwoFactorRepository
+	Accounts   models.AccountRepository
+	Tokens     models.TokenStore
+}
+
+// NewServer wires the pgx repositories on db and the token store on rdb
+func NewServer(db models.DB, rdb *redis.Client) *Server {
+	return &Server{
+		Products:   models.NewPgProductRepository(db),
+		Orders:     models.NewPgOrderRepository(db),
+		Users:      models.NewPgUserRepository(db),
+		Carts:      models.NewPgCartRepository(db),
+		Categories: models.NewPgCategoryRepository(db),
+		Favorites:  models.NewPgFavoriteRepository(db),
+		Reviews:    models.NewPgReviewRepository(db),
+		TwoFactor:  models.NewPgTwoFactorRepository(db),
+		Accounts:   models.NewPgAccountRepository(db),
+		Tokens:     models.NewRedisTokenStore(rdb),
+	}
+}
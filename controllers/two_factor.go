@@ -0,0 +1,399 @@
+package controllers
+
+import (
+	"backend/apperr"
+	"backend/config"
+	"backend/lib"
+	"backend/models"
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	twoFactorChallengeTTL = 5 * time.Minute
+	twoFactorMaxAttempts  = 5
+	backupCodeCount       = 10
+)
+
+// loginResponse returns the JWT token, or a challenge token when the account
+// has two factor enabled. The challenge is exchanged at /auth/login/2fa.
+func (s *Server) loginResponse(ctx *gin.Context, user *models.User) {
+	if user.TwoFactorEnabled {
+		challenge := lib.RandomToken(32)
+		err := s.Tokens.SaveChallenge(context.Background(), challenge, user.ID, twoFactorChallengeTTL)
+		if err != nil {
+			serviceUnavailable(ctx, "Redis")
+			return
+		}
+
+		ctx.JSON(200, models.Response{
+			Success: true,
+			Message: translate(ctx, "Two factor authentication required"),
+			Data: map[string]any{
+				"two_factor_required": true,
+				"challenge_token":     challenge,
+			},
+		})
+		return
+	}
+
+	token := lib.GeneratedTokens(int(user.ID), user.Role)
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "Login success"),
+		Data: map[string]any{
+			"user":  user,
+			"token": token,
+		},
+	})
+}
+
+var errTooManyCodes = apperr.TooManyRequests("too many invalid codes, try again later")
+
+// verifySecondFactor accepts a TOTP code (once per period) or an unused
+// backup code. Wrong codes are counted per user, after twoFactorMaxAttempts
+// of them every code is refused with errTooManyCodes for
+// twoFactorChallengeTTL, whichever route they were sent to.
+func (s *Server) verifySecondFactor(ctx context.Context, userID int64, secret, code string) (bool, error) {
+	attempts, err := s.Tokens.CodeFailures(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if attempts >= twoFactorMaxAttempts {
+		return false, errTooManyCodes
+	}
+
+	ok, err := s.checkSecondFactor(ctx, userID, secret, code)
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		s.Tokens.ResetCodeFailures(ctx, userID)
+		return true, nil
+	}
+
+	return false, s.Tokens.FailCode(ctx, userID, twoFactorChallengeTTL)
+}
+
+func (s *Server) checkSecondFactor(ctx context.Context, userID int64, secret, code string) (bool, error) {
+	if lib.ValidateTOTP(secret, code) {
+		return s.Tokens.UseTOTP(ctx, userID, code, 90*time.Second)
+	}
+
+	return s.TwoFactor.UseBackupCode(ctx, userID, lib.HashBackupCode(code))
+}
+
+// LoginTwoFactor godoc
+// @Summary Second login step
+// @Description Exchange the challenge token from /auth/login and a TOTP or backup code for a JWT token
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorLoginRequest true "Challenge and code"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 429 {object} models.Response
+// @Router /auth/login/2fa [post]
+func (s *Server) LoginTwoFactor(ctx *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	redisCtx := context.Background()
+
+	userID, found, err := s.Tokens.ChallengeUser(redisCtx, req.ChallengeToken)
+	if err != nil {
+		serviceUnavailable(ctx, "Redis")
+		return
+	}
+	if !found {
+		ctx.Error(apperr.Validation("invalid or expired challenge"))
+		return
+	}
+
+	secret, enabled, err := s.TwoFactor.TOTP(ctx, userID)
+	if err != nil || !enabled {
+		ctx.Error(apperr.Validation("invalid or expired challenge"))
+		return
+	}
+
+	ok, err := s.verifySecondFactor(ctx, userID, secret, req.Code)
+	if errors.Is(err, errTooManyCodes) {
+		s.Tokens.DeleteChallenge(redisCtx, req.ChallengeToken)
+	}
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if !ok {
+		attempts, _ := s.Tokens.FailChallenge(redisCtx, req.ChallengeToken, twoFactorChallengeTTL)
+		if attempts >= twoFactorMaxAttempts {
+			s.Tokens.DeleteChallenge(redisCtx, req.ChallengeToken)
+		}
+
+		ctx.Error(apperr.Validation("invalid code"))
+		return
+	}
+	s.Tokens.DeleteChallenge(redisCtx, req.ChallengeToken)
+
+	user, err := s.Users.Get(ctx, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	token := lib.GeneratedTokens(int(user.ID), user.Role)
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "Login success"),
+		Data: map[string]any{
+			"user":  user,
+			"token": token,
+		},
+	})
+}
+
+// EnrollTwoFactor godoc
+// @Summary Start two factor enrollment
+// @Description Generate a TOTP secret and otpauth uri to be shown as QR code
+// @Tags User - Two Factor
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Router /user/2fa/enroll [post]
+func (s *Server) EnrollTwoFactor(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+
+	_, enabled, err := s.TwoFactor.TOTP(ctx, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if enabled {
+		ctx.Error(apperr.Conflict("two factor already enabled"))
+		return
+	}
+
+	email, err := s.Users.Email(ctx, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	secret := lib.GenerateTOTPSecret()
+	if err := s.TwoFactor.SetPendingSecret(ctx, userID, secret); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	issuer := os.Getenv("APP_NAME")
+	if issuer == "" {
+		issuer = "CoffeeShop"
+	}
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "scan the otpauth uri with an authenticator app, then verify a code"),
+		Data: gin.H{
+			"secret":      secret,
+			"otpauth_uri": lib.TOTPURI(issuer, email, secret),
+		},
+	})
+}
+
+// VerifyTwoFactor godoc
+// @Summary Confirm two factor enrollment
+// @Description Verify the first TOTP code, enable two factor and return backup codes
+// @Tags User - Two Factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 429 {object} models.Response
+// @Router /user/2fa/verify [post]
+func (s *Server) VerifyTwoFactor(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+
+	var req models.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	secret, enabled, err := s.TwoFactor.TOTP(ctx, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if enabled {
+		ctx.Error(apperr.Conflict("two factor already enabled"))
+		return
+	}
+	if secret == "" {
+		ctx.Error(apperr.Validation("enroll two factor first"))
+		return
+	}
+
+	ok, err := s.verifySecondFactor(ctx, userID, secret, req.Code)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if !ok {
+		ctx.Error(apperr.Validation("invalid code"))
+		return
+	}
+
+	codes := lib.GenerateBackupCodes(backupCodeCount)
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = lib.HashBackupCode(code)
+	}
+
+	if err := s.TwoFactor.Enable(ctx, userID, hashes); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "two factor enabled, store the backup codes somewhere safe"),
+		Data:    gin.H{"backup_codes": codes},
+	})
+}
+
+// RegenerateBackupCodes godoc
+// @Summary Regenerate backup codes
+// @Description Replace all backup codes, requires a valid TOTP code
+// @Tags User - Two Factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 429 {object} models.Response
+// @Router /user/2fa/backup-codes [post]
+func (s *Server) RegenerateBackupCodes(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+
+	var req models.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	secret, enabled, err := s.TwoFactor.TOTP(ctx, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if !enabled {
+		ctx.Error(apperr.Validation("two factor is not enabled"))
+		return
+	}
+
+	ok, err := s.verifySecondFactor(ctx, userID, secret, req.Code)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if !ok {
+		ctx.Error(apperr.Validation("invalid code"))
+		return
+	}
+
+	codes := lib.GenerateBackupCodes(backupCodeCount)
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = lib.HashBackupCode(code)
+	}
+
+	if err := s.TwoFactor.RegenerateBackupCodes(ctx, userID, hashes); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "backup codes regenerated"),
+		Data:    gin.H{"backup_codes": codes},
+	})
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two factor
+// @Description Disable two factor with password and a TOTP or backup code, not allowed when the policy requires it for the role
+// @Tags User - Two Factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.DisableTwoFactorRequest true "Password and code"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 429 {object} models.Response
+// @Router /user/2fa/disable [post]
+func (s *Server) DisableTwoFactor(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+	role := ctx.MustGet("role").(string)
+
+	if config.TwoFactorRequired(role) {
+		ctx.Error(apperr.Forbidden("two factor is required for your account"))
+		return
+	}
+
+	var req models.DisableTwoFactorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	hashed, err := s.Users.PasswordHash(ctx, userID)
+	if err != nil || hashed == "" || !lib.VerifyPassword(req.Password, hashed) {
+		ctx.Error(apperr.Validation("wrong password"))
+		return
+	}
+
+	secret, enabled, err := s.TwoFactor.TOTP(ctx, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if !enabled {
+		ctx.Error(apperr.Validation("two factor is not enabled"))
+		return
+	}
+
+	ok, err := s.verifySecondFactor(ctx, userID, secret, req.Code)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if !ok {
+		ctx.Error(apperr.Validation("invalid code"))
+		return
+	}
+
+	if err := s.TwoFactor.Disable(ctx, userID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, models.Response{
+		Success: true,
+		Message: translate(ctx, "two factor disabled"),
+	})
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"backend/lib"
+	"backend/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEnrollTwoFactor(t *testing.T) {
+	s := newTestServer()
+	user, _ := s.users.Register(t.Context(), models.RegisterRequest{Email: "dina@example.com", Password: "rahasia123", Username: "dina"})
+
+	r := newRouter()
+	r.POST("/user/2fa/enroll", asUser(user.ID, "user"), s.EnrollTwoFactor)
+	r.POST("/user/2fa/verify", asUser(user.ID, "user"), s.VerifyTwoFactor)
+
+	assertStatus(t, serve(t, r, http.MethodPost, "/user/2fa/verify", `{"code": "123456"}`), 400)
+
+	w := serve(t, r, http.MethodPost, "/user/2fa/enroll", "")
+	assertStatus(t, w, 200)
+	_, data := decodeResponse[map[string]string](t, w.Body.Bytes())
+	if data["secret"] == "" || !strings.Contains(data["otpauth_uri"], "dina@example.com") {
+		t.Fatalf("enroll = %+v", data)
+	}
+	if secret, enabled, _ := s.twoFactor.TOTP(t.Context(), user.ID); secret != data["secret"] || enabled {
+		t.Fatalf("stored secret %q, enabled %v", secret, enabled)
+	}
+
+	s.twoFactor.Enable(t.Context(), user.ID, nil)
+	assertStatus(t, serve(t, r, http.MethodPost, "/user/2fa/enroll", ""), 409)
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	s := newTestServer()
+	user, _ := s.users.Register(t.Context(), models.RegisterRequest{Email: "dina@example.com", Password: "rahasia123", Username: "dina"})
+
+	r := newRouter()
+	r.POST("/user/2fa/disable", asUser(user.ID, "user"), s.DisableTwoFactor)
+	r.POST("/admin/2fa/disable", asUser(user.ID, "admin"), s.DisableTwoFactor)
+
+	// the default policy requires two factor for admins
+	assertStatus(t, serve(t, r, http.MethodPost, "/admin/2fa/disable", `{"password": "rahasia123", "code": "123456"}`), 403)
+
+	assertStatus(t, serve(t, r, http.MethodPost, "/user/2fa/disable", `{"password": "salah", "code": "123456"}`), 400)
+	w := serve(t, r, http.MethodPost, "/user/2fa/disable", `{"password": "rahasia123", "code": "123456"}`)
+	assertStatus(t, w, 400)
+	if res, _ := decodeResponse[any](t, w.Body.Bytes()); res.Message != "two factor is not enabled" {
+		t.Fatalf("message = %q", res.Message)
+	}
+}
+
+func TestLoginTwoFactor(t *testing.T) {
+	t.Setenv("APP_SECRET", "test-secret")
+
+	s := newTestServer()
+	user, _ := s.users.Register(t.Context(), models.RegisterRequest{Email: "dina@example.com", Password: "rahasia123", Username: "dina"})
+	secret := lib.GenerateTOTPSecret()
+	s.twoFactor.SetPendingSecret(t.Context(), user.ID, secret)
+	s.twoFactor.Enable(t.Context(), user.ID, []string{lib.HashBackupCode("backup-1")})
+	s.users.users[user.ID].TwoFactorEnabled = true
+
+	r := newRouter()
+	r.POST("/auth/login", s.LoginUser)
+	r.POST("/auth/login/2fa", s.LoginTwoFactor)
+
+	w := serve(t, r, http.MethodPost, "/auth/login", `{"email": "dina@example.com", "password": "rahasia123"}`)
+	assertStatus(t, w, 200)
+	_, data := decodeResponse[map[string]any](t, w.Body.Bytes())
+	challenge, _ := data["challenge_token"].(string)
+	if challenge == "" || data["token"] != nil {
+		t.Fatalf("login data = %+v", data)
+	}
+
+	login := func(challenge, code string) *httptest.ResponseRecorder {
+		return serve(t, r, http.MethodPost, "/auth/login/2fa", fmt.Sprintf(`{"challenge_token": %q, "code": %q}`, challenge, code))
+	}
+
+	assertStatus(t, login("unknown", "backup-1"), 400)
+	assertStatus(t, login(challenge, "000000"), 400)
+	if s.tokens.challengeFailures[challenge] != 1 || s.tokens.codeFailures[user.ID] != 1 {
+		t.Fatalf("failures = %d per challenge, %d per user", s.tokens.challengeFailures[challenge], s.tokens.codeFailures[user.ID])
+	}
+
+	w = login(challenge, "backup-1")
+	assertStatus(t, w, 200)
+	if _, data := decodeResponse[map[string]any](t, w.Body.Bytes()); data["token"] == nil {
+		t.Fatalf("2fa login data = %+v", data)
+	}
+	if _, ok := s.tokens.challenges[challenge]; ok || s.tokens.codeFailures[user.ID] != 0 {
+		t.Fatal("challenge and failures are kept after login")
+	}
+
+	// the challenge is used once
+	assertStatus(t, login(challenge, "backup-1"), 400)
+
+	s.tokens.err = errors.New("redis down")
+	assertStatus(t, serve(t, r, http.MethodPost, "/auth/login", `{"email": "dina@example.com", "password": "rahasia123"}`), 503)
+}
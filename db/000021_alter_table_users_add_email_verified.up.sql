@@ -0,0 +1,6 @@
+ALTER TABLE users
+ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT false,
+ADD COLUMN email_verified_at TIMESTAMP;
+
+-- akun yang sudah ada dianggap terverifikasi
+UPDATE users SET email_verified = TRUE, email_verified_at = now();
@@ -0,0 +1,11 @@
+CREATE TABLE user_identities (
+    id SERIAL PRIMARY KEY,
+    users_id BIGINT NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(100),
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now(),
+    FOREIGN KEY (users_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (provider, subject)
+);
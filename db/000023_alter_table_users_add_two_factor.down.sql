@@ -0,0 +1,5 @@
+DROP TABLE user_backup_codes;
+
+ALTER TABLE users DROP COLUMN totp_enabled;
+
+ALTER TABLE users DROP COLUMN totp_secret;
@@ -0,0 +1,12 @@
+ALTER TABLE users
+ADD COLUMN totp_secret TEXT,
+ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE user_backup_codes (
+    id SERIAL PRIMARY KEY,
+    users_id BIGINT NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT now(),
+    FOREIGN KEY (users_id) REFERENCES users(id) ON DELETE CASCADE
+);
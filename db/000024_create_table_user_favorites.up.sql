@@ -0,0 +1,9 @@
+CREATE TABLE user_favorites (
+    id SERIAL PRIMARY KEY,
+    users_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT now(),
+    FOREIGN KEY (users_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+    UNIQUE (users_id, product_id)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE products DROP COLUMN featured_until;
+
+ALTER TABLE products DROP COLUMN featured_from;
+
+ALTER TABLE products DROP COLUMN featured_order;
@@ -0,0 +1,4 @@
+ALTER TABLE products
+ADD COLUMN featured_order INT,
+ADD COLUMN featured_from TIMESTAMP,
+ADD COLUMN featured_until TIMESTAMP;
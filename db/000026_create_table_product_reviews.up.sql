@@ -0,0 +1,15 @@
+CREATE TABLE product_reviews (
+    id SERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL,
+    users_id BIGINT NOT NULL,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    review TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'approved',
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now(),
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+    FOREIGN KEY (users_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (product_id, users_id)
+);
+
+CREATE INDEX idx_product_reviews_product_status ON product_reviews (product_id, status);
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_products_name_trgm;
+
+DROP INDEX IF EXISTS idx_products_search_vector;
+
+DROP TRIGGER IF EXISTS categories_search_vector_update ON categories;
+
+DROP FUNCTION IF EXISTS categories_search_vector_trigger();
+
+DROP TRIGGER IF EXISTS products_search_vector_update ON products;
+
+DROP FUNCTION IF EXISTS products_search_vector_trigger();
+
+DROP FUNCTION IF EXISTS products_search_vector(products);
+
+ALTER TABLE products DROP COLUMN search_vector;
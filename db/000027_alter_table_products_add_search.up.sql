@@ -0,0 +1,40 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE products
+ADD COLUMN search_vector tsvector;
+
+-- nama produk paling berbobot, lalu kategori, lalu deskripsi
+CREATE OR REPLACE FUNCTION products_search_vector(product products) RETURNS tsvector AS $$
+    SELECT
+        setweight(to_tsvector('simple', COALESCE(product.name, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE((SELECT name FROM categories WHERE id = product.category_id), '')), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(product.description, '')), 'C')
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION products_search_vector_trigger() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector := products_search_vector(NEW);
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER products_search_vector_update
+BEFORE INSERT OR UPDATE OF name, description, category_id ON products
+FOR EACH ROW EXECUTE FUNCTION products_search_vector_trigger();
+
+-- rename kategori ikut memperbarui produk di dalamnya
+CREATE OR REPLACE FUNCTION categories_search_vector_trigger() RETURNS trigger AS $$
+BEGIN
+    UPDATE products SET search_vector = products_search_vector(products) WHERE category_id = NEW.id;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER categories_search_vector_update
+AFTER UPDATE OF name ON categories
+FOR EACH ROW EXECUTE FUNCTION categories_search_vector_trigger();
+
+UPDATE products SET search_vector = products_search_vector(products);
+
+CREATE INDEX idx_products_search_vector ON products USING GIN (search_vector);
+CREATE INDEX idx_products_name_trgm ON products USING GIN (name gin_trgm_ops);
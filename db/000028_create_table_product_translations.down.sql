@@ -0,0 +1,20 @@
+DROP TRIGGER IF EXISTS category_translations_search_vector_update ON category_translations;
+
+DROP FUNCTION IF EXISTS category_translations_search_vector_trigger();
+
+DROP TRIGGER IF EXISTS product_translations_search_vector_update ON product_translations;
+
+DROP FUNCTION IF EXISTS product_translations_search_vector_trigger();
+
+DROP TABLE IF EXISTS category_translations;
+
+DROP TABLE IF EXISTS product_translations;
+
+CREATE OR REPLACE FUNCTION products_search_vector(product products) RETURNS tsvector AS $$
+    SELECT
+        setweight(to_tsvector('simple', COALESCE(product.name, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE((SELECT name FROM categories WHERE id = product.category_id), '')), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(product.description, '')), 'C')
+$$ LANGUAGE sql STABLE;
+
+UPDATE products SET search_vector = products_search_vector(products);
@@ -0,0 +1,56 @@
+-- nama dan deskripsi per bahasa, kolom di products / categories jadi fallback
+CREATE TABLE product_translations (
+    product_id BIGINT NOT NULL,
+    locale VARCHAR(10) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (product_id, locale),
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE TABLE category_translations (
+    category_id BIGINT NOT NULL,
+    locale VARCHAR(10) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (category_id, locale),
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+-- pencarian juga menemukan nama dan deskripsi terjemahan
+CREATE OR REPLACE FUNCTION products_search_vector(product products) RETURNS tsvector AS $$
+    SELECT
+        setweight(to_tsvector('simple', COALESCE(product.name, '') || ' ' ||
+            COALESCE((SELECT string_agg(name, ' ') FROM product_translations WHERE product_id = product.id), '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE((SELECT name FROM categories WHERE id = product.category_id), '') || ' ' ||
+            COALESCE((SELECT string_agg(name, ' ') FROM category_translations WHERE category_id = product.category_id), '')), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(product.description, '') || ' ' ||
+            COALESCE((SELECT string_agg(description, ' ') FROM product_translations WHERE product_id = product.id), '')), 'C')
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION product_translations_search_vector_trigger() RETURNS trigger AS $$
+BEGIN
+    UPDATE products SET search_vector = products_search_vector(products)
+    WHERE id = COALESCE(NEW.product_id, OLD.product_id);
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER product_translations_search_vector_update
+AFTER INSERT OR UPDATE OR DELETE ON product_translations
+FOR EACH ROW EXECUTE FUNCTION product_translations_search_vector_trigger();
+
+CREATE OR REPLACE FUNCTION category_translations_search_vector_trigger() RETURNS trigger AS $$
+BEGIN
+    UPDATE products SET search_vector = products_search_vector(products)
+    WHERE category_id = COALESCE(NEW.category_id, OLD.category_id);
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER category_translations_search_vector_update
+AFTER INSERT OR UPDATE OR DELETE ON category_translations
+FOR EACH ROW EXECUTE FUNCTION category_translations_search_vector_trigger();
@@ -0,0 +1,3 @@
+ALTER TABLE products
+ALTER COLUMN featured_from TYPE TIMESTAMP USING featured_from AT TIME ZONE 'UTC',
+ALTER COLUMN featured_until TYPE TIMESTAMP USING featured_until AT TIME ZONE 'UTC';
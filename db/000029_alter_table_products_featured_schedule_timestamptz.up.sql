@@ -0,0 +1,5 @@
+-- jadwal featured disimpan dengan zona waktu, offset dari request tidak hilang
+-- (nilai lama dianggap UTC)
+ALTER TABLE products
+ALTER COLUMN featured_from TYPE TIMESTAMPTZ USING featured_from AT TIME ZONE 'UTC',
+ALTER COLUMN featured_until TYPE TIMESTAMPTZ USING featured_until AT TIME ZONE 'UTC';
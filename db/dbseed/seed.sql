@@ -2,10 +2,10 @@
 
 
 INSERT INTO users (email, password, role) VALUES 
-('admin123@gmail.com', '$argon2id$v=19$m=65536,t=3,p=4$E2+eLrHdKlxJ5+BXfhJvZQ$Gw3hB3Ftw0o8K7vYyff92T7ySQjZi+TtqLLRhtD4L5M', 'admin')
+('admin123@gmail.com', '$argon2id$v=19$m=65536,t=3,p=4$E2+eLrHdKlxJ5+BXfhJvZQ$Gw3hB3Ftw0o8K7vYyff92T7ySQjZi+TtqLLRhtD4L5M', 'admin');
 
 INSERT INTO profile (users_id, username, phone, address) VALUES
-('1', 'admin', '081234567', 'depok')
+('1', 'admin', '081234567', 'depok');
 
 INSERT INTO categories (name) VALUES
 ('Tea'),
@@ -268,7 +268,7 @@ UPDATE products
 SET is_favorite = TRUE
 WHERE id IN (50, 10, 14, 24);
 
-UPDATE products id SET is_favorite = TRUE
+UPDATE products SET is_favorite = TRUE
 WHERE id In (10, 11, 20, 9);
 
 
@@ -277,8 +277,6 @@ INSERT INTO method (name) VALUES
 ('Delivery'),
 ('Pick Up');
 
-ALTER TABLE products
-SET COLUMN method_id  CHECK < 4
 
 
 INSERT INTO product_method (product_id, method_id) VALUES
@@ -0,0 +1,78 @@
+// Package i18n picks the language of a request from Accept-Language and
+// translates the messages of the API. Messages are written in English in
+// the code and double as the catalog keys, a message without a
+// translation is sent in English.
+package i18n
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	English    = "en"
+	Indonesian = "id"
+)
+
+// Locales are the supported locales, English first as the default
+var Locales = []string{English, Indonesian}
+
+// matcher lists the tags in the order of Locales
+var matcher = language.NewMatcher([]language.Tag{language.English, language.Indonesian})
+
+// catalogs translate the English messages, per locale
+var catalogs = map[string]map[string]string{
+	Indonesian: indonesian,
+}
+
+// Locale picks a supported locale from an Accept-Language header, English
+// when none of them is asked for
+func Locale(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return English
+	}
+
+	_, index := language.MatchStrings(matcher, acceptLanguage)
+	return Locales[index]
+}
+
+// Supported reports whether locale is one of Locales
+func Supported(locale string) bool {
+	for _, l := range Locales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+type localeKey struct{}
+
+// WithLocale stores the request locale in ctx
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// FromContext returns the locale stored in ctx, English outside of a request
+func FromContext(ctx context.Context) string {
+	if ctx != nil {
+		if locale, ok := ctx.Value(localeKey{}).(string); ok && Supported(locale) {
+			return locale
+		}
+	}
+	return English
+}
+
+// T translates message to locale. With args the message is a fmt format,
+// the args fill the translated one.
+func T(locale, message string, args ...any) string {
+	if translated, ok := catalogs[locale][message]; ok {
+		message = translated
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
@@ -0,0 +1,65 @@
+package i18n
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestLocale(t *testing.T) {
+	tests := map[string]string{
+		"":                        English,
+		"id":                      Indonesian,
+		"id-ID,id;q=0.9,en;q=0.8": Indonesian,
+		"en-US,en;q=0.9,id;q=0.8": English,
+		"fr-FR,id;q=0.5":          Indonesian,
+		"de":                      English,
+	}
+	for header, want := range tests {
+		if got := Locale(header); got != want {
+			t.Errorf("Locale(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	tests := []struct {
+		locale, message string
+		args            []any
+		want            string
+	}{
+		{English, "cart is empty", nil, "cart is empty"},
+		{Indonesian, "cart is empty", nil, "Keranjang kosong"},
+		{Indonesian, "days must be between %d and 365", []any{7}, "days harus antara 7 dan 365"},
+		{English, "days must be between %d and 365", []any{7}, "days must be between 7 and 365"},
+		{Indonesian, "no translation yet", nil, "no translation yet"},
+		{"fr", "cart is empty", nil, "cart is empty"},
+	}
+	for _, tt := range tests {
+		if got := T(tt.locale, tt.message, tt.args...); got != tt.want {
+			t.Errorf("T(%s, %q) = %q, want %q", tt.locale, tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != English {
+		t.Fatalf("FromContext without locale = %s", got)
+	}
+	if got := FromContext(WithLocale(context.Background(), Indonesian)); got != Indonesian {
+		t.Fatalf("FromContext = %s", got)
+	}
+}
+
+// a translation must take the same args as its English message
+func TestCatalogVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[a-z]`)
+	for locale, catalog := range catalogs {
+		for message, translated := range catalog {
+			if !slices.Equal(verbs.FindAllString(message, -1), verbs.FindAllString(translated, -1)) {
+				t.Errorf("%s: %q -> %q", locale, message, translated)
+			}
+		}
+	}
+}
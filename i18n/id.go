@@ -0,0 +1,181 @@
+package i18n
+
+// indonesian is keyed by the English message, %s / %d stay in the same order
+var indonesian = map[string]string{
+	// apperr, database and request body errors
+	"Internal server error":                            "Terjadi kesalahan pada server",
+	"Database did not answer in time, try again later": "Database tidak merespons tepat waktu, coba lagi nanti",
+	"Data not found":                                   "Data tidak ditemukan",
+	"Data already exists":                              "Data sudah ada",
+	"Data is still in use":                             "Data masih digunakan",
+	"Value is not valid":                               "Nilai tidak valid",
+	"%s already exists":                                "%s sudah ada",
+	"%s does not exist":                                "%s tidak ada",
+	"%s is required":                                   "%s wajib diisi",
+	"already exists":                                   "sudah ada",
+	"does not exist":                                   "tidak ada",
+	"Request body is required":                         "Body request wajib diisi",
+	"Request body is not valid JSON":                   "Body request bukan JSON yang valid",
+
+	// validation
+	"Invalid request":       "Permintaan tidak valid",
+	"must be a number":      "harus berupa angka",
+	"must be text":          "harus berupa teks",
+	"must be true or false": "harus true atau false",
+	"must be a list":        "harus berupa daftar",
+	"must be an object":     "harus berupa objek",
+	"is required":           "wajib diisi",
+	"is not valid":          "tidak valid",
+	"must be a valid email": "harus berupa email yang valid",
+	"must be a valid phone number, e.g. 081234567890":     "harus berupa nomor HP yang valid, contoh 081234567890",
+	"must be 8 to 72 characters with letters and numbers": "harus 8 sampai 72 karakter dan berisi huruf serta angka",
+	"must be a supported language (en, id)":               "harus bahasa yang didukung (en, id)",
+	"must be a valid id":                                  "harus berupa id yang valid",
+	"must be one of %s":                                   "harus salah satu dari %s",
+	"must be at least %s":                                 "minimal %s",
+	"must be at least %s characters":                      "minimal %s karakter",
+	"must have at least %s items":                         "minimal %s item",
+	"must be at most %s":                                  "maksimal %s",
+	"must be at most %s characters":                       "maksimal %s karakter",
+	"must have at most %s items":                          "maksimal %s item",
+	"must be %s":                                          "harus %s",
+	"must be %s characters":                               "harus %s karakter",
+	"must have %s items":                                  "harus %s item",
+	"must be greater than %s":                             "harus lebih dari %s",
+	"must be %s or more":                                  "minimal %s",
+	"must be less than %s":                                "harus kurang dari %s",
+	"must be %s or less":                                  "maksimal %s",
+
+	// middleware
+	"Unauthorized":                                       "Tidak terautentikasi",
+	"Token invalid":                                      "Token tidak valid",
+	"Token revoked":                                      "Token sudah dicabut",
+	"Forbidden: Admin only":                              "Akses ditolak: khusus admin",
+	"Too many requests, try again later":                 "Terlalu banyak request, coba lagi nanti",
+	"Database is unavailable, try again later":           "Database sedang tidak tersedia, coba lagi nanti",
+	"%s is unavailable, try again later":                 "%s sedang tidak tersedia, coba lagi nanti",
+	"Email not verified, please verify your email first": "Email belum diverifikasi, verifikasi email terlebih dahulu",
+	"Two factor authentication is required, enroll at /user/2fa/enroll": "Autentikasi dua faktor wajib, daftarkan di /user/2fa/enroll",
+
+	// auth, profile and account
+	"Register success, please check your email to verify your account": "Registrasi berhasil, cek email untuk memverifikasi akun",
+	"Register success, but failed to send verification email":          "Registrasi berhasil, tetapi email verifikasi gagal dikirim",
+	"wrong email or password":                                          "Email atau password salah",
+	"Login success":                                                    "Login berhasil",
+	"Email verified":                                                   "Email berhasil diverifikasi",
+	"If the account exists and is not verified yet, a verification email has been sent": "Jika akun ada dan belum diverifikasi, email verifikasi sudah dikirim",
+	"failed to send verification email":                                                 "Email verifikasi gagal dikirim",
+	"token is required":                                                                 "Token wajib diisi",
+	"invalid or expired token":                                                          "Token tidak valid atau sudah kedaluwarsa",
+	"OTP created (dev mode)":                                                            "OTP dibuat (mode dev)",
+	"invalid or expired OTP":                                                            "OTP tidak valid atau sudah kedaluwarsa",
+	"password updated":                                                                  "Password berhasil diperbarui",
+	"wrong password":                                                                    "Password salah",
+	"success get user profile":                                                          "Berhasil mengambil profil",
+	"profile updated successfully":                                                      "Profil berhasil diperbarui",
+	"success data from db":                                                              "Berhasil mengambil data dari db",
+	"upload success":                                                                    "Upload berhasil",
+	"file not provided":                                                                 "File tidak dikirim",
+	"invalid file format":                                                               "Format file tidak valid",
+	"invalid file extension. Only .jpg, .jpeg, .png allowed":                            "Ekstensi file tidak valid. Hanya .jpg, .jpeg, .png",
+	"cannot open file":                                                                  "File tidak bisa dibuka",
+	"failed upload to cloudinary":                                                       "Upload ke cloudinary gagal",
+	"failed to save file":                                                               "File gagal disimpan",
+	"forbidden":                                                                         "Akses ditolak",
+	"invalid user id":                                                                   "Id user tidak valid",
+	"invalid target user id":                                                            "Id user tujuan tidak valid",
+	"user not found":                                                                    "User tidak ditemukan",
+	"only admin can update user":                                                        "Hanya admin yang bisa mengubah user",
+	"admin update user successfully":                                                    "User berhasil diperbarui oleh admin",
+	"admin updated user picture successfully":                                           "Foto user berhasil diperbarui oleh admin",
+	"user data export":                                                                  "Ekspor data user",
+	"account deleted":                                                                   "Akun berhasil dihapus",
+	"admin cannot delete their own account here":                                        "Admin tidak bisa menghapus akunnya sendiri di sini",
+
+	// social login
+	"login provider not found":         "Provider login tidak ditemukan",
+	"login cancelled: %s":              "Login dibatalkan: %s",
+	"code and state are required":      "code dan state wajib diisi",
+	"invalid or expired state":         "State tidak valid atau sudah kedaluwarsa",
+	"login failed":                     "Login gagal",
+	"provider did not return an email": "Provider tidak mengirimkan email",
+	"email from provider is not verified, login with password first to link the account":    "Email dari provider belum diverifikasi, login dengan password terlebih dahulu untuk menautkan akun",
+	"an account with this email is not verified yet, verify it or reset its password first": "Akun dengan email ini belum diverifikasi, verifikasi atau reset password-nya terlebih dahulu",
+
+	// two factor
+	"Two factor authentication required":                                 "Autentikasi dua faktor diperlukan",
+	"scan the otpauth uri with an authenticator app, then verify a code": "Scan uri otpauth dengan aplikasi authenticator, lalu verifikasi kodenya",
+	"two factor enabled, store the backup codes somewhere safe":          "Autentikasi dua faktor aktif, simpan backup code di tempat yang aman",
+	"backup codes regenerated":                                           "Backup code berhasil dibuat ulang",
+	"two factor disabled":                                                "Autentikasi dua faktor dinonaktifkan",
+	"invalid or expired challenge":                                       "Challenge tidak valid atau sudah kedaluwarsa",
+	"invalid code":                                                       "Kode tidak valid",
+	"too many invalid codes, try again later":                            "Terlalu banyak kode yang salah, coba lagi nanti",
+	"two factor already enabled":                                         "Autentikasi dua faktor sudah aktif",
+	"enroll two factor first":                                            "Daftarkan autentikasi dua faktor terlebih dahulu",
+	"two factor is required for your account":                            "Autentikasi dua faktor wajib untuk akun Anda",
+	"two factor is not enabled":                                          "Autentikasi dua faktor belum aktif",
+
+	// products and categories
+	"success":                         "Berhasil",
+	"success from db":                 "Berhasil mengambil data dari db",
+	"success from cache":              "Berhasil mengambil data dari cache",
+	"admin product list":              "Daftar produk admin",
+	"Product created":                 "Produk berhasil dibuat",
+	"Product updated":                 "Produk berhasil diperbarui",
+	"Product deleted":                 "Produk berhasil dihapus",
+	"product not found":               "Produk tidak ditemukan",
+	"Invalid product id":              "Id produk tidak valid",
+	"invalid product id":              "Id produk tidak valid",
+	"invalid cursor":                  "Cursor tidak valid",
+	"suggestions":                     "Saran pencarian",
+	"suggestions from cache":          "Saran pencarian dari cache",
+	"recommendations":                 "Rekomendasi",
+	"recommendations ( from cache )":  "Rekomendasi ( dari cache )",
+	"best-sellers":                    "Produk terlaris",
+	"best-sellers ( from cache )":     "Produk terlaris ( dari cache )",
+	"trending":                        "Produk trending",
+	"trending ( from cache )":         "Produk trending ( dari cache )",
+	"days must be between %d and 365": "days harus antara %d dan 365",
+	"Category Created":                "Kategori berhasil dibuat",
+	"list all categories":             "Daftar semua kategori",
+	"Category updated":                "Kategori berhasil diperbarui",
+	"Category deleted":                "Kategori berhasil dihapus",
+
+	// favorites and featured
+	"list my favorite products":                  "Daftar produk favorit saya",
+	"list products favorite":                     "Daftar produk favorit",
+	"list favorite products ( from cache )":      "Daftar produk favorit ( dari cache )",
+	"Product added to favorites":                 "Produk ditambahkan ke favorit",
+	"Product removed from favorites":             "Produk dihapus dari favorit",
+	"list featured products":                     "Daftar produk unggulan",
+	"Product featured":                           "Produk dijadikan unggulan",
+	"Product removed from featured":              "Produk dihapus dari unggulan",
+	"featured_until must be after featured_from": "featured_until harus setelah featured_from",
+
+	// reviews
+	"review posted":                              "Ulasan berhasil dikirim",
+	"review posted, waiting for approval":        "Ulasan berhasil dikirim, menunggu persetujuan",
+	"review updated":                             "Ulasan berhasil diperbarui",
+	"review deleted":                             "Ulasan berhasil dihapus",
+	"review pending":                             "Ulasan menunggu persetujuan",
+	"review approved":                            "Ulasan disetujui",
+	"review hidden":                              "Ulasan disembunyikan",
+	"review not found":                           "Ulasan tidak ditemukan",
+	"Invalid review id":                          "Id ulasan tidak valid",
+	"status must be pending, approved or hidden": "status harus pending, approved atau hidden",
+	"you already reviewed this product":          "Anda sudah mengulas produk ini",
+	"only customers with a completed order of this product can review it": "Hanya pelanggan dengan pesanan selesai untuk produk ini yang bisa mengulasnya",
+
+	// cart and orders
+	"Item added to cart":         "Produk ditambahkan ke keranjang",
+	"Cart successfully":          "Berhasil mengambil keranjang",
+	"Product removed from cart":  "Produk dihapus dari keranjang",
+	"Invalid cart item id":       "Id item keranjang tidak valid",
+	"cart is empty":              "Keranjang kosong",
+	"complete the data first":    "Lengkapi data terlebih dahulu",
+	"Order created successfully": "Pesanan berhasil dibuat",
+	"Invalid order ID":           "Id pesanan tidak valid",
+	"list all order":             "Daftar semua pesanan",
+	"Order status updated":       "Status pesanan berhasil diperbarui",
+}
@@ -0,0 +1,32 @@
+package integration
+
+import (
+	"backend/config"
+	"net/http"
+	"testing"
+)
+
+// TestDeleteAccountRevokesTokens deletes an account and checks its token is
+// refused afterwards, also while Redis (the revocation list) is down
+func TestDeleteAccountRevokesTokens(t *testing.T) {
+	r := newApp(t)
+
+	userID := register(t, r, "hapus@example.com")
+	verifyEmail(t, r, userID)
+	token := login(t, r, "hapus@example.com")
+
+	// nothing is deleted while the tokens cannot be revoked
+	restart := stopRedis(t)
+	assertStatus(t, call(t, r, http.MethodGet, "/user/profile", token, ""), 200)
+	assertStatus(t, call(t, r, http.MethodDelete, "/user/account", token, `{"password": "rahasia123"}`), 503)
+	restart()
+	config.CheckDependencies(t.Context())
+	assertStatus(t, call(t, r, http.MethodGet, "/user/profile", token, ""), 200)
+
+	assertStatus(t, call(t, r, http.MethodDelete, "/user/account", token, `{"password": "rahasia123"}`), 200)
+	assertStatus(t, call(t, r, http.MethodGet, "/user/profile", token, ""), 401)
+
+	// without the revocation list the deleted user is looked up instead
+	stopRedis(t)
+	assertStatus(t, call(t, r, http.MethodGet, "/user/profile", token, ""), 401)
+}
@@ -0,0 +1,218 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// errUnavailable means neither the env var, a local binary nor docker can
+// provide the dependency, the suite is skipped instead of failing.
+var errUnavailable = errors.New("not available")
+
+// dependency is a running Postgres or Redis, stop removes whatever was
+// started for the run.
+type dependency struct {
+	addr string
+	stop func()
+}
+
+// startPostgres returns a DSN to an empty database. TEST_DATABASE_URL is used
+// first (a fresh database is created on that server and dropped afterwards),
+// then initdb/pg_ctl from PATH, then the postgres:16-alpine image.
+func startPostgres(ctx context.Context, dir string) (dependency, error) {
+	if dsn := os.Getenv("TEST_DATABASE_URL"); dsn != "" {
+		return freshDatabase(ctx, dsn)
+	}
+
+	if _, err := exec.LookPath("pg_ctl"); err == nil {
+		return localPostgres(ctx, dir)
+	}
+
+	if _, err := exec.LookPath("docker"); err == nil {
+		dep, err := dockerRun(ctx, "postgres:16-alpine", "5432", "-e", "POSTGRES_PASSWORD=postgres")
+		if err != nil {
+			return dependency{}, err
+		}
+		dep.addr = fmt.Sprintf("postgres://postgres:postgres@%s/postgres?sslmode=disable", dep.addr)
+		if err := waitFor(ctx, func(ctx context.Context) error { return pingPostgres(ctx, dep.addr) }); err != nil {
+			dep.stop()
+			return dependency{}, err
+		}
+		return dep, nil
+	}
+
+	return dependency{}, fmt.Errorf("postgres %w: set TEST_DATABASE_URL, install postgres or docker", errUnavailable)
+}
+
+func freshDatabase(ctx context.Context, dsn string) (dependency, error) {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return dependency{}, fmt.Errorf("connect TEST_DATABASE_URL: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	name := fmt.Sprintf("coffeeshop_it_%d", time.Now().UnixNano())
+	if _, err := conn.Exec(ctx, "CREATE DATABASE "+name); err != nil {
+		return dependency{}, fmt.Errorf("create database %s: %w", name, err)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return dependency{}, err
+	}
+	u.Path = "/" + name
+
+	return dependency{
+		addr: u.String(),
+		stop: func() {
+			conn, err := pgx.Connect(context.Background(), dsn)
+			if err != nil {
+				return
+			}
+			defer conn.Close(context.Background())
+			conn.Exec(context.Background(), "DROP DATABASE IF EXISTS "+name+" WITH (FORCE)")
+		},
+	}, nil
+}
+
+func localPostgres(ctx context.Context, dir string) (dependency, error) {
+	data := filepath.Join(dir, "pgdata")
+	if out, err := exec.CommandContext(ctx, "initdb", "-D", data, "-U", "postgres", "-A", "trust").CombinedOutput(); err != nil {
+		return dependency{}, fmt.Errorf("initdb: %w: %s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return dependency{}, err
+	}
+
+	// the socket goes to the temp dir, /var/run/postgresql is often not writable
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -c fsync=off", port, dir)
+	start := exec.CommandContext(ctx, "pg_ctl", "-D", data, "-o", opts, "-l", filepath.Join(dir, "postgres.log"), "-w", "start")
+	if out, err := start.CombinedOutput(); err != nil {
+		return dependency{}, fmt.Errorf("pg_ctl start: %w: %s", err, out)
+	}
+
+	return dependency{
+		addr: fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port),
+		stop: func() {
+			exec.Command("pg_ctl", "-D", data, "-m", "immediate", "stop").Run()
+		},
+	}, nil
+}
+
+// startRedis returns the address of a Redis to use with DB 0 flushed.
+// TEST_REDIS_ADDR must point to a throwaway instance, then redis-server from
+// PATH is tried, then the redis:7-alpine image.
+func startRedis(ctx context.Context) (dependency, error) {
+	if addr := os.Getenv("TEST_REDIS_ADDR"); addr != "" {
+		return dependency{addr: addr, stop: func() {}}, nil
+	}
+
+	var dep dependency
+	if _, err := exec.LookPath("redis-server"); err == nil {
+		port, err := freePort()
+		if err != nil {
+			return dependency{}, err
+		}
+
+		cmd := exec.Command("redis-server", "--port", strconv.Itoa(port), "--bind", "127.0.0.1", "--save", "", "--appendonly", "no")
+		if err := cmd.Start(); err != nil {
+			return dependency{}, fmt.Errorf("redis-server: %w", err)
+		}
+		dep = dependency{
+			addr: fmt.Sprintf("127.0.0.1:%d", port),
+			stop: func() {
+				cmd.Process.Kill()
+				cmd.Wait()
+			},
+		}
+	} else if _, err := exec.LookPath("docker"); err == nil {
+		dep, err = dockerRun(ctx, "redis:7-alpine", "6379")
+		if err != nil {
+			return dependency{}, err
+		}
+	} else {
+		return dependency{}, fmt.Errorf("redis %w: set TEST_REDIS_ADDR, install redis or docker", errUnavailable)
+	}
+
+	err := waitFor(ctx, func(ctx context.Context) error {
+		rdb := redis.NewClient(&redis.Options{Addr: dep.addr})
+		defer rdb.Close()
+		return rdb.Ping(ctx).Err()
+	})
+	if err != nil {
+		dep.stop()
+		return dependency{}, err
+	}
+	return dep, nil
+}
+
+// dockerRun starts image with port published on a random local port and
+// returns that host:port, the container is removed on stop.
+func dockerRun(ctx context.Context, image, port string, args ...string) (dependency, error) {
+	run := append([]string{"run", "-d", "--rm", "-p", "127.0.0.1::" + port}, args...)
+	out, err := exec.CommandContext(ctx, "docker", append(run, image)...).Output()
+	if err != nil {
+		return dependency{}, fmt.Errorf("docker run %s: %w", image, err)
+	}
+	id := strings.TrimSpace(string(out))
+	stop := func() { exec.Command("docker", "rm", "-f", id).Run() }
+
+	out, err = exec.CommandContext(ctx, "docker", "port", id, port+"/tcp").Output()
+	if err != nil {
+		stop()
+		return dependency{}, fmt.Errorf("docker port %s: %w", image, err)
+	}
+
+	// "127.0.0.1:49153", one line per published address
+	addr := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	return dependency{addr: addr, stop: stop}, nil
+}
+
+func pingPostgres(ctx context.Context, dsn string) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+	return conn.Ping(ctx)
+}
+
+// waitFor retries ping until it succeeds or ctx is done, containers take a
+// few seconds before they accept connections.
+func waitFor(ctx context.Context, ping func(context.Context) error) error {
+	for {
+		err := ping(ctx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for dependency: %w", err)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
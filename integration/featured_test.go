@@ -0,0 +1,70 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestFeaturedSchedule checks a cached featured list does not outlive the
+// featured_until of a product in it
+func TestFeaturedSchedule(t *testing.T) {
+	r := newApp(t)
+
+	execSQL(t, `UPDATE products SET is_favorite = TRUE, featured_from = NULL, featured_until = NOW() + INTERVAL '2 seconds' WHERE id = 2`)
+
+	featuredIDs := func() []int64 {
+		var products []struct {
+			ID int64 `json:"id"`
+		}
+		w := callLocale(t, r, "/featured-products?limit=50", "en")
+		assertStatus(t, w, 200)
+		decodeData(t, w, &products)
+
+		ids := make([]int64, len(products))
+		for i, p := range products {
+			ids[i] = p.ID
+		}
+		return ids
+	}
+
+	if ids := featuredIDs(); !slices.Contains(ids, 2) {
+		t.Fatalf("featured %v, want product 2", ids)
+	}
+
+	time.Sleep(2500 * time.Millisecond)
+	if ids := featuredIDs(); slices.Contains(ids, 2) {
+		t.Fatalf("featured %v after featured_until, product 2 is still listed", ids)
+	}
+}
+
+// TestFeaturedScheduleOffset schedules with a +07:00 offset, the window has
+// to start at that instant and not at the same wall clock in UTC
+func TestFeaturedScheduleOffset(t *testing.T) {
+	r := newApp(t)
+
+	admin := register(t, r, "jadwal@example.com")
+	execSQL(t, `UPDATE users SET role = 'admin', email_verified = true WHERE id = $1`, admin)
+	token := login(t, r, "jadwal@example.com")
+
+	wib := time.FixedZone("WIB", 7*60*60)
+	from := time.Now().Add(-30 * time.Minute).In(wib).Format(time.RFC3339)
+	until := time.Now().Add(time.Hour).In(wib).Format(time.RFC3339)
+	body := fmt.Sprintf(`{"display_order": 1, "featured_from": %q, "featured_until": %q}`, from, until)
+	assertStatus(t, call(t, r, http.MethodPut, "/admin/product/3/featured", token, body), 200)
+
+	type scheduled struct {
+		ID     int64 `json:"id"`
+		Active bool  `json:"active"`
+	}
+	var featured []scheduled
+	w := call(t, r, http.MethodGet, "/admin/featured", token, "")
+	assertStatus(t, w, 200)
+	decodeData(t, w, &featured)
+
+	if !slices.Contains(featured, scheduled{ID: 3, Active: true}) {
+		t.Fatalf("featured %+v, want product 3 active from %s", featured, from)
+	}
+}
@@ -0,0 +1,63 @@
+package integration
+
+import (
+	"backend/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestDependencyOutage stops the database, then Redis, and checks requests
+// get 503 with Retry-After until the health monitor sees them back
+func TestDependencyOutage(t *testing.T) {
+	r := newApp(t)
+	t.Setenv("HEALTH_CHECK_INTERVAL", "100ms")
+
+	tests := []struct {
+		name     string
+		stop     func(t *testing.T) func()
+		target   string
+		recovers int
+	}{
+		{"database", stopDatabase, "/products", 200},
+		// an unknown token is a 400 once Redis can be asked
+		{"redis", stopRedis, "/auth/verify-email?token=unknown", 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restart := tt.stop(t)
+
+			w := call(t, r, http.MethodGet, tt.target, "", "")
+			assertStatus(t, w, 503)
+			if got := w.Header().Get("Retry-After"); got != "1" {
+				t.Fatalf("Retry-After = %q, want 1", got)
+			}
+			assertStatus(t, call(t, r, http.MethodGet, "/healthz", "", ""), 200)
+			assertStatus(t, call(t, r, http.MethodGet, "/readyz", "", ""), map[string]int{"database": 503, "redis": 200}[tt.name])
+
+			restart()
+			config.StartHealthMonitor(t.Context())
+			waitStatus(t, r, tt.target, tt.recovers)
+		})
+	}
+}
+
+// waitStatus polls target until it answers want
+func waitStatus(t *testing.T, r *gin.Engine, target string, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	var w *httptest.ResponseRecorder
+	for time.Now().Before(deadline) {
+		w = call(t, r, http.MethodGet, target, "", "")
+		if w.Code == want {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("%s = %d after recovery, want %d: %s", target, w.Code, want, w.Body)
+}
@@ -0,0 +1,51 @@
+package integration
+
+import (
+	"backend/config"
+	"backend/lib"
+	"backend/models"
+	"errors"
+	"testing"
+)
+
+// TestIdentityPreHijack registers an account for an email before its owner
+// signs in with a provider, the login must not take over that account
+func TestIdentityPreHijack(t *testing.T) {
+	r := newApp(t)
+
+	userID := register(t, r, "pemilik@example.com")
+	identity := lib.OIDCIdentity{
+		Provider:      "google",
+		Subject:       "pemilik-sub",
+		Email:         "pemilik@example.com",
+		EmailVerified: true,
+	}
+
+	_, err := models.LoginWithIdentity(t.Context(), config.Db, identity)
+	if !errors.Is(err, models.ErrIdentityAccountNotVerified) {
+		t.Fatalf("login unverified account = %v, want %v", err, models.ErrIdentityAccountNotVerified)
+	}
+
+	var verified bool
+	var links int
+	err = config.Db.QueryRow(t.Context(),
+		`SELECT u.email_verified, (SELECT COUNT(*) FROM user_identities WHERE users_id = u.id)
+		 FROM users u WHERE u.id = $1`, userID,
+	).Scan(&verified, &links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified || links != 0 {
+		t.Fatalf("email_verified %v, identities %d after a refused link", verified, links)
+	}
+
+	// once the owner verified the email the provider account is linked
+	verifyEmail(t, r, userID)
+	user, err := models.LoginWithIdentity(t.Context(), config.Db, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != userID {
+		t.Fatalf("linked user %d, want %d", user.ID, userID)
+	}
+}
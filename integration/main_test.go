@@ -0,0 +1,164 @@
+// Package integration runs the API end to end against a real Postgres and
+// Redis. The dependencies are started for the run, see startPostgres and
+// startRedis, and the tests skip when none can be provided.
+package integration
+
+import (
+	"backend/config"
+	"backend/controllers"
+	"backend/routes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// skipReason is set when the dependencies could not be started
+var skipReason string
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		skipReason = "integration tests are skipped with -short"
+		os.Exit(m.Run())
+	}
+
+	stop, err := setup()
+	if errors.Is(err, errUnavailable) {
+		skipReason = err.Error()
+		os.Exit(m.Run())
+	}
+	if err != nil {
+		stop()
+		log.Fatalf("integration setup: %v", err)
+	}
+
+	code := m.Run()
+	stop()
+	os.Exit(code)
+}
+
+// setup starts Postgres and Redis, applies the migrations and the seed and
+// connects the config globals the way main does.
+func setup() (func(), error) {
+	var stops []func()
+	stop := func() {
+		if config.Db != nil {
+			config.Db.Close()
+		}
+		if config.Rdb != nil {
+			config.Rdb.Close()
+		}
+		for i := len(stops) - 1; i >= 0; i-- {
+			stops[i]()
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "coffeeshop-it-")
+	if err != nil {
+		return stop, err
+	}
+	stops = append(stops, func() { os.RemoveAll(dir) })
+
+	pg, err := startPostgres(ctx, dir)
+	if err != nil {
+		return stop, err
+	}
+	stops = append(stops, pg.stop)
+
+	rdb, err := startRedis(ctx)
+	if err != nil {
+		return stop, err
+	}
+	stops = append(stops, rdb.stop)
+
+	if err := migrate(ctx, pg.addr); err != nil {
+		return stop, err
+	}
+
+	os.Setenv("DATABASE_URL", pg.addr)
+	os.Setenv("REDIS_URL", rdb.addr)
+	os.Setenv("PASSWORD_REDIS", "")
+	os.Setenv("DB", "0")
+	os.Setenv("APP_SECRET", "integration-secret")
+	os.Setenv("TWO_FACTOR_POLICY", "optional")
+	os.Setenv("DEPENDENCY_CONNECT_RETRIES", "1")
+	// every request comes from httptest's 192.0.2.1, the tests would share
+	// one rate limit bucket
+	os.Setenv("RATE_LIMIT_ENABLED", "false")
+	os.Unsetenv("SMTP_HOST")
+
+	config.ConnectDb()
+	if !config.DbReady() {
+		return stop, errors.New("database did not connect")
+	}
+	config.Redis()
+	if !config.RedisReady() {
+		return stop, errors.New("redis did not connect")
+	}
+	if err := config.Rdb.FlushDB(ctx).Err(); err != nil {
+		return stop, fmt.Errorf("flush redis: %w", err)
+	}
+
+	return stop, nil
+}
+
+// migrate applies db/*.up.sql in version order and then the seed, the same
+// files cmd/migrations runs.
+func migrate(ctx context.Context, dsn string) error {
+	files, err := filepath.Glob(filepath.Join("..", "db", "*.up.sql"))
+	if err != nil {
+		return err
+	}
+	files = append(files, filepath.Join("..", "db", "dbseed", "seed.sql"))
+
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	for _, f := range files {
+		sql, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		if _, err := conn.Exec(ctx, string(sql)); err != nil {
+			return fmt.Errorf("apply %s: %w", filepath.Base(f), err)
+		}
+	}
+	return nil
+}
+
+// newApp builds the router the same way main does
+func newApp(t *testing.T) *gin.Engine {
+	t.Helper()
+	if skipReason != "" {
+		t.Skip(skipReason)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	routes.Routes(r, controllers.NewServer(config.Db, config.Rdb))
+	return r
+}
+
+// execSQL runs a statement directly, for setup the API does not expose
+func execSQL(t *testing.T, sql string, args ...any) {
+	t.Helper()
+	if _, err := config.Db.Exec(t.Context(), sql, args...); err != nil {
+		t.Fatalf("%s: %v", strings.TrimSpace(sql), err)
+	}
+}
@@ -0,0 +1,157 @@
+package integration
+
+import (
+	"backend/config"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestOrderFlow walks a customer from register to a finished order: register,
+// verify email, login, add to cart, checkout and the admin closing the order.
+func TestOrderFlow(t *testing.T) {
+	r := newApp(t)
+
+	user := register(t, r, "dina@example.com")
+	verifyEmail(t, r, user)
+	token := login(t, r, "dina@example.com")
+
+	w := call(t, r, http.MethodPost, "/cart", token, `{"product_id": 1, "size_id": 1, "variant_id": 1, "quantity": 2}`)
+	assertStatus(t, w, 200)
+
+	var cart []struct {
+		ProductID int64 `json:"product_id"`
+		Qty       int   `json:"quantity"`
+	}
+	decodeData(t, call(t, r, http.MethodGet, "/cart", token, ""), &cart)
+	if len(cart) != 1 || cart[0].ProductID != 1 || cart[0].Qty != 2 {
+		t.Fatalf("cart = %+v", cart)
+	}
+
+	var order struct {
+		OrderID         int64  `json:"order_id"`
+		Invoice         string `json:"invoice"`
+		CustomerAddress string `json:"customer_address"`
+	}
+	w = call(t, r, http.MethodPost, "/user/order", token, `{"payment_id": 1, "method_id": 1}`)
+	assertStatus(t, w, 200)
+	decodeData(t, w, &order)
+	if order.OrderID == 0 || order.Invoice == "" || order.CustomerAddress != "Jl. Kopi 1" {
+		t.Fatalf("order = %+v", order)
+	}
+
+	decodeData(t, call(t, r, http.MethodGet, "/cart", token, ""), &cart)
+	if len(cart) != 0 {
+		t.Fatalf("cart not emptied after checkout: %+v", cart)
+	}
+
+	// admins are not created through the API, promote a verified account
+	admin := register(t, r, "barista@example.com")
+	execSQL(t, `UPDATE users SET role = 'admin', email_verified = true WHERE id = $1`, admin)
+	adminToken := login(t, r, "barista@example.com")
+
+	id := strconv.FormatInt(order.OrderID, 10)
+	assertStatus(t, call(t, r, http.MethodPut, "/admin/orders/"+id+"/status", token, `{"status": 1}`), 403)
+	assertStatus(t, call(t, r, http.MethodPut, "/admin/orders/"+id+"/status", adminToken, `{"status": 1}`), 200)
+
+	var detail struct {
+		ID     int64  `json:"order_id"`
+		Status string `json:"status"`
+	}
+	decodeData(t, call(t, r, http.MethodGet, "/user/order/"+id, token, ""), &detail)
+	if detail.ID != order.OrderID || detail.Status != "Done" {
+		t.Fatalf("order detail = %+v", detail)
+	}
+}
+
+// register creates an account through the API and returns its id
+func register(t *testing.T, r *gin.Engine, email string) int64 {
+	t.Helper()
+
+	body := fmt.Sprintf(`{"email": %q, "password": "rahasia123", "username": "dina", "phone": "081234567890", "address": "Jl. Kopi 1"}`, email)
+	w := call(t, r, http.MethodPost, "/auth/register", "", body)
+	assertStatus(t, w, 200)
+
+	var user struct {
+		ID int64 `json:"id"`
+	}
+	decodeData(t, w, &user)
+	return user.ID
+}
+
+// verifyEmail opens the link that was mailed on register, the token is only
+// known to Redis since the log mailer is used
+func verifyEmail(t *testing.T, r *gin.Engine, userID int64) {
+	t.Helper()
+
+	keys, err := config.Rdb.Keys(t.Context(), "verify:*").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		if id, _ := config.Rdb.Get(t.Context(), key).Int64(); id == userID {
+			assertStatus(t, call(t, r, http.MethodGet, "/auth/verify-email?token="+strings.TrimPrefix(key, "verify:"), "", ""), 200)
+			return
+		}
+	}
+	t.Fatalf("no verification token for user %d", userID)
+}
+
+func login(t *testing.T, r *gin.Engine, email string) string {
+	t.Helper()
+
+	w := call(t, r, http.MethodPost, "/auth/login", "", fmt.Sprintf(`{"email": %q, "password": "rahasia123"}`, email))
+	assertStatus(t, w, 200)
+
+	var data struct {
+		Token string `json:"token"`
+	}
+	decodeData(t, w, &data)
+	if data.Token == "" {
+		t.Fatalf("login %s: no token in %s", email, w.Body)
+	}
+	return data.Token
+}
+
+func call(t *testing.T, r *gin.Engine, method, target, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequestWithContext(t.Context(), method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func assertStatus(t *testing.T, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if w.Code != want {
+		t.Fatalf("status = %d, want %d: %s", w.Code, want, w.Body)
+	}
+}
+
+func decodeData(t *testing.T, w *httptest.ResponseRecorder, data any) {
+	t.Helper()
+
+	var res struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	if err := json.Unmarshal(res.Data, data); err != nil {
+		t.Fatalf("decode data %s: %v", res.Data, err)
+	}
+}
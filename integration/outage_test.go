@@ -0,0 +1,81 @@
+package integration
+
+import (
+	"backend/config"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// closedAddr is a local address nothing listens on
+func closedAddr(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+// stopRedis points config.Rdb at a closed port and lets the health check
+// notice. restart brings the real client back but leaves noticing it to the
+// caller (CheckDependencies or the health monitor).
+func stopRedis(t *testing.T) (restart func()) {
+	t.Helper()
+
+	rdb := config.Rdb
+	config.Rdb = redis.NewClient(&redis.Options{Addr: closedAddr(t), MaxRetries: -1})
+	config.CheckDependencies(t.Context())
+	if config.RedisReady() {
+		t.Fatal("redis still ready after stopping it")
+	}
+
+	return restartOnce(t, func() {
+		config.Rdb.Close()
+		config.Rdb = rdb
+	})
+}
+
+// stopDatabase is stopRedis for config.Db
+func stopDatabase(t *testing.T) (restart func()) {
+	t.Helper()
+
+	db := config.Db
+	pool, err := pgxpool.New(t.Context(), fmt.Sprintf("postgres://postgres@%s/postgres?connect_timeout=1", closedAddr(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Db = pool
+	config.CheckDependencies(t.Context())
+	if config.DbReady() {
+		t.Fatal("database still ready after stopping it")
+	}
+
+	return restartOnce(t, func() {
+		config.Db.Close()
+		config.Db = db
+	})
+}
+
+// restartOnce runs restart at most once, at the latest when the test ends
+func restartOnce(t *testing.T, restart func()) func() {
+	restarted := false
+	once := func() {
+		if !restarted {
+			restarted = true
+			restart()
+		}
+	}
+	t.Cleanup(func() {
+		once()
+		config.CheckDependencies(context.Background())
+	})
+	return once
+}
@@ -0,0 +1,64 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSearchSnippetEscaped(t *testing.T) {
+	r := newApp(t)
+
+	execSQL(t, `UPDATE products SET description = 'kopi <img src=x onerror=alert(1)> tubruk & gula' WHERE id = 1`)
+
+	var found []struct {
+		ID      int64  `json:"id"`
+		Snippet string `json:"snippet"`
+	}
+	w := callLocale(t, r, "/products?q=tubruk", "en")
+	assertStatus(t, w, 200)
+	decodeData(t, w, &found)
+
+	if len(found) == 0 || found[0].ID != 1 {
+		t.Fatalf("search tubruk = %+v", found)
+	}
+	snippet := found[0].Snippet
+	if strings.Contains(snippet, "<img") || !strings.Contains(snippet, "&lt;img") || !strings.Contains(snippet, "<mark>tubruk</mark>") {
+		t.Fatalf("snippet %q", snippet)
+	}
+}
+
+func TestSuggestTranslated(t *testing.T) {
+	r := newApp(t)
+
+	execSQL(t, `INSERT INTO product_translations (product_id, locale, name) VALUES (2, 'id', 'Kopi Tubruk Pandan')
+		ON CONFLICT (product_id, locale) DO UPDATE SET name = EXCLUDED.name`)
+
+	type suggestion struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+
+	// the english list is cached first, the indonesian one has its own key
+	var en, id []suggestion
+	w := callLocale(t, r, "/products/suggest?q=pandan", "en")
+	assertStatus(t, w, 200)
+	decodeData(t, w, &en)
+	w = callLocale(t, r, "/products/suggest?q=pandan", "id")
+	assertStatus(t, w, 200)
+	decodeData(t, w, &id)
+
+	if len(id) == 0 || id[0] != (suggestion{ID: 2, Name: "Kopi Tubruk Pandan"}) {
+		t.Fatalf("suggest id = %+v", id)
+	}
+	if len(en) == 0 || en[0].ID != 2 || en[0].Name == "Kopi Tubruk Pandan" {
+		t.Fatalf("suggest en = %+v", en)
+	}
+
+	// a typo only matches the translated name by similarity
+	w = callLocale(t, r, "/products/suggest?q=pandn", "id")
+	assertStatus(t, w, 200)
+	decodeData(t, w, &id)
+	if len(id) == 0 || id[0].ID != 2 {
+		t.Fatalf("suggest typo = %+v", id)
+	}
+}
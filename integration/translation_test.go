@@ -0,0 +1,81 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestProductTranslations(t *testing.T) {
+	r := newApp(t)
+
+	execSQL(t, `INSERT INTO product_translations (product_id, locale, name) VALUES (1, 'id', 'Kopi Gula Aren')`)
+	execSQL(t, `INSERT INTO category_translations (category_id, locale, name)
+		SELECT category_id, 'id', 'Minuman Kopi' FROM products WHERE id = 1 AND category_id IS NOT NULL`)
+
+	type detail struct {
+		Product struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			Category    string `json:"category"`
+		} `json:"product"`
+	}
+
+	var base, translated detail
+	w := callLocale(t, r, "/products/1", "en")
+	assertStatus(t, w, 200)
+	decodeData(t, w, &base)
+
+	// the english detail is cached by now, the indonesian one has its own key
+	w = callLocale(t, r, "/products/1", "id-ID,id;q=0.9")
+	assertStatus(t, w, 200)
+	decodeData(t, w, &translated)
+
+	if translated.Product.Name != "Kopi Gula Aren" || base.Product.Name == translated.Product.Name {
+		t.Fatalf("name en %q, id %q", base.Product.Name, translated.Product.Name)
+	}
+	// no indonesian description, it falls back to the product's own
+	if translated.Product.Description != base.Product.Description {
+		t.Fatalf("description id %q, want %q", translated.Product.Description, base.Product.Description)
+	}
+	if base.Product.Category != "" && translated.Product.Category != "Minuman Kopi" {
+		t.Fatalf("category id %q", translated.Product.Category)
+	}
+
+	type listed struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+
+	// the featured list is cached per locale too
+	execSQL(t, `UPDATE products SET is_favorite = TRUE, featured_from = NULL, featured_until = NULL WHERE id = 1`)
+	var featured []listed
+	assertStatus(t, callLocale(t, r, "/featured-products?limit=50", "en"), 200)
+	w = callLocale(t, r, "/featured-products?limit=50", "id")
+	assertStatus(t, w, 200)
+	decodeData(t, w, &featured)
+	if !slices.Contains(featured, listed{ID: 1, Name: "Kopi Gula Aren"}) {
+		t.Fatalf("featured id = %+v", featured)
+	}
+
+	// the search index covers the translated name
+	var found []listed
+	w = callLocale(t, r, "/products?q=aren", "id")
+	assertStatus(t, w, 200)
+	decodeData(t, w, &found)
+	if len(found) == 0 || found[0].ID != 1 || found[0].Name != "Kopi Gula Aren" {
+		t.Fatalf("search aren = %+v", found)
+	}
+}
+
+func callLocale(t *testing.T, r http.Handler, target, acceptLanguage string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, target, nil)
+	req.Header.Set("Accept-Language", acceptLanguage)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
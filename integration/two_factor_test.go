@@ -0,0 +1,41 @@
+package integration
+
+import (
+	"backend/lib"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestTwoFactorAttempts enrolls two factor and checks wrong codes are
+// limited per user, also on the enrollment and backup code routes
+func TestTwoFactorAttempts(t *testing.T) {
+	r := newApp(t)
+
+	userID := register(t, r, "totp@example.com")
+	verifyEmail(t, r, userID)
+	token := login(t, r, "totp@example.com")
+
+	w := call(t, r, http.MethodPost, "/user/2fa/enroll", token, "")
+	assertStatus(t, w, 200)
+	var enroll struct {
+		Secret string `json:"secret"`
+	}
+	decodeData(t, w, &enroll)
+
+	code, err := lib.TOTPCode(enroll.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := func(code string) string { return fmt.Sprintf(`{"code": %q}`, code) }
+
+	assertStatus(t, call(t, r, http.MethodPost, "/user/2fa/verify", token, body("not-a-code")), 400)
+	assertStatus(t, call(t, r, http.MethodPost, "/user/2fa/verify", token, body(code)), 200)
+
+	// a used code is not accepted twice, every wrong code counts
+	for range 5 {
+		assertStatus(t, call(t, r, http.MethodPost, "/user/2fa/backup-codes", token, body(code)), 400)
+	}
+	assertStatus(t, call(t, r, http.MethodPost, "/user/2fa/backup-codes", token, body("not-a-code")), 429)
+}
@@ -0,0 +1,39 @@
+package lib
+
+import "runtime/debug"
+
+// Version is set at build time:
+// go build -ldflags "-X backend/lib.Version=v1.2.0"
+var Version = "dev"
+
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+	Revision  string `json:"revision,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// GetBuildInfo combines Version with the vcs data go build embeds
+func GetBuildInfo() BuildInfo {
+	info := BuildInfo{Version: Version}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.GoVersion = build.GoVersion
+	for _, s := range build.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.time":
+			info.BuildTime = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,44 @@
+package lib
+
+import (
+	"backend/apperr"
+	"encoding/base64"
+	"encoding/json"
+)
+
+var ErrInvalidCursor = apperr.Validation("invalid cursor")
+
+// Cursor points at the first or last row of a page for keyset pagination.
+// Key is the sort value of that row and ID breaks ties, Sort guards against
+// reusing a cursor with another sort order.
+type Cursor struct {
+	Key      string `json:"k"`
+	ID       int64  `json:"id"`
+	Sort     string `json:"s,omitempty"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// EncodeCursor returns the opaque value clients send back as ?cursor=
+func EncodeCursor(c Cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses ?cursor=, an empty value means the first page
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
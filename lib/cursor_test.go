@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []Cursor{
+		{Key: "2026-01-02 03:04:05.123456", ID: 42},
+		{Key: "25000.5", ID: 7, Sort: "price_low", Backward: true},
+		{Key: "Kopi \"Susu\" & Gula/Aren", ID: 1, Sort: "name_asc"},
+		{Key: "", ID: 0},
+	}
+
+	for _, want := range tests {
+		encoded := EncodeCursor(want)
+		got, err := DecodeCursor(encoded)
+		if err != nil {
+			t.Fatalf("DecodeCursor(%q): %v", encoded, err)
+		}
+		if *got != want {
+			t.Errorf("round trip = %+v, want %+v", *got, want)
+		}
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantNil bool
+		wantErr error
+	}{
+		{"empty is the first page", "", true, nil},
+		{"not base64", "not a cursor!", true, ErrInvalidCursor},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"k":"1","id":1}`)), true, ErrInvalidCursor},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("kopi")), true, ErrInvalidCursor},
+		{"wrong type", base64.RawURLEncoding.EncodeToString([]byte(`{"k":"1","id":"x"}`)), true, ErrInvalidCursor},
+		{"valid", base64.RawURLEncoding.EncodeToString([]byte(`{"k":"1","id":1}`)), false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := DecodeCursor(tt.value)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if (c == nil) != tt.wantNil {
+				t.Fatalf("cursor = %+v, want nil %v", c, tt.wantNil)
+			}
+		})
+	}
+}
@@ -1,8 +1,6 @@
 package lib
 
 import (
-	"fmt"
-
 	"github.com/matthewhartstonge/argon2"
 )
 
@@ -14,7 +12,5 @@ func HashPassword(password string) []byte {
 
 func VerifyPassword(password string, hashedPassword string) bool {
 	ok, _ := argon2.VerifyEncoded([]byte(password), []byte(hashedPassword))
-	fmt.Println(hashedPassword)
-	fmt.Println(password)
 	return ok
 }
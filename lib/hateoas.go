@@ -35,3 +35,32 @@ func Hateoas(baseURL string, path string, page int, limit int, totalPage int, ex
 		"next":       nextURL,
 	}
 }
+
+func HateoasCursor(baseURL string, path string, limit int, nextCursor, prevCursor string, extraQuery url.Values) map[string]string {
+	makeQuery := func(cursor string) string {
+		q := url.Values{}
+		for key, val := range extraQuery {
+			q[key] = val
+		}
+		q.Del("page")
+		q.Set("cursor", cursor)
+		q.Set("limit", strconv.Itoa(limit))
+		return q.Encode()
+	}
+
+	var nextURL, prevURL string
+
+	if prevCursor != "" {
+		prevURL = fmt.Sprintf("%s%s?%s", baseURL, path, makeQuery(prevCursor))
+	}
+
+	if nextCursor != "" {
+		nextURL = fmt.Sprintf("%s%s?%s", baseURL, path, makeQuery(nextCursor))
+	}
+
+	return map[string]string{
+		"limit": strconv.Itoa(limit),
+		"prev":  prevURL,
+		"next":  nextURL,
+	}
+}
@@ -1,10 +1,15 @@
 package lib
 
 import (
+	"backend/config"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
 )
 
 type UserPayload struct {
@@ -43,3 +48,32 @@ func VerifyToken(token string) (UserPayload, error) {
 		return UserPayload{}, jwt.ErrTokenInvalidClaims
 	}
 }
+
+// RevokeUserTokens invalidates every token issued to the user until now
+func RevokeUserTokens(id int) error {
+	key := fmt.Sprintf("revoked:user:%d", id)
+	return config.Rdb.Set(context.Background(), key, time.Now().Unix(), 24*time.Hour).Err()
+}
+
+// ErrRevocationUnknown is returned by IsTokenRevoked while Redis is down, the
+// caller has to decide without the revocation list
+var ErrRevocationUnknown = errors.New("token revocation list is unavailable")
+
+func IsTokenRevoked(payload UserPayload) (bool, error) {
+	if !config.RedisReady() {
+		return false, ErrRevocationUnknown
+	}
+
+	key := fmt.Sprintf("revoked:user:%d", payload.Id)
+	revokedAt, err := config.Rdb.Get(context.Background(), key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrRevocationUnknown, err)
+	}
+	if payload.IssuedAt == nil {
+		return false, nil
+	}
+	return payload.IssuedAt.Unix() <= revokedAt, nil
+}
@@ -0,0 +1,80 @@
+package lib
+
+import (
+	"fmt"
+	"log/slog"
+	"net/smtp"
+	"os"
+	"sync"
+)
+
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPMailer sends mail through the server configured by SMTP_* env
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m SMTPMailer) Send(to, subject, body string) error {
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		m.From, to, subject, body)
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{to}, []byte(msg))
+}
+
+// LogMailer logs the mail instead of sending it (dev mode). The body is
+// logged at info level, it holds the verification link needed to finish
+// registration locally.
+type LogMailer struct{}
+
+func (LogMailer) Send(to, subject, body string) error {
+	slog.Info("mail not sent, SMTP_HOST is empty", "to", to, "subject", subject, "body", body)
+	return nil
+}
+
+var (
+	mailer     Mailer
+	mailerOnce sync.Once
+)
+
+// SetMailer replaces the mailer picked from env, ex: with a fake in tests
+func SetMailer(m Mailer) {
+	mailerOnce.Do(func() {})
+	mailer = m
+}
+
+func SendMail(to, subject, body string) error {
+	mailerOnce.Do(func() {
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			mailer = LogMailer{}
+			return
+		}
+
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+
+		mailer = SMTPMailer{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		}
+	})
+
+	return mailer.Send(to, subject, body)
+}
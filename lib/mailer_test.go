@@ -0,0 +1,25 @@
+package lib
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// without SMTP_HOST the verification link has to show up at the default level
+func TestLogMailerLogsBodyAtInfo(t *testing.T) {
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	var buf bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	link := "http://localhost:8080/auth/verify-email?token=abc123"
+	if err := (LogMailer{}).Send("dina@example.com", "Verify your email", "Open "+link); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), link) {
+		t.Fatalf("log %q does not contain the link", buf.String())
+	}
+}
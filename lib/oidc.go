@@ -0,0 +1,145 @@
+package lib
+
+import (
+	"backend/apperr"
+	"backend/config"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+type OIDCIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// OIDCProvider is one social login provider (google, microsoft, keycloak...)
+type OIDCProvider interface {
+	Name() string
+	AuthURL(state, nonce, codeVerifier string) string
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*OIDCIdentity, error)
+}
+
+var ErrOIDCProviderNotFound = apperr.NotFound("login provider not found")
+
+type oidcProvider struct {
+	name     string
+	oauth    oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDCProvider runs the issuer discovery and returns an authorization
+// code + PKCE provider
+func NewOIDCProvider(ctx context.Context, cfg config.OIDCProviderConfig) (OIDCProvider, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery %s: %w", cfg.Name, err)
+	}
+
+	return &oidcProvider{
+		name: cfg.Name,
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       cfg.Scopes,
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}, nil
+}
+
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+func (p *oidcProvider) AuthURL(state, nonce, codeVerifier string) string {
+	return p.oauth.AuthCodeURL(state,
+		oidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(codeVerifier),
+	)
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*OIDCIdentity, error) {
+	token, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("id_token missing from token response")
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("verify id_token: %w", err)
+	}
+
+	if idToken.Nonce != nonce {
+		return nil, errors.New("invalid nonce")
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified any    `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	// some providers send email_verified as a string
+	verified := claims.EmailVerified == true || claims.EmailVerified == "true"
+
+	return &OIDCIdentity{
+		Provider:      p.name,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: verified,
+		Name:          claims.Name,
+	}, nil
+}
+
+var (
+	oidcProviders   = map[string]OIDCProvider{}
+	oidcProvidersMu sync.Mutex
+)
+
+// GetOIDCProvider returns the provider configured in env, discovery is done
+// once and cached
+func GetOIDCProvider(ctx context.Context, name string) (OIDCProvider, error) {
+	oidcProvidersMu.Lock()
+	defer oidcProvidersMu.Unlock()
+
+	if p, ok := oidcProviders[name]; ok {
+		return p, nil
+	}
+
+	cfg, ok := config.OIDCProvider(name)
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	p, err := NewOIDCProvider(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	oidcProviders[name] = p
+	return p, nil
+}
+
+// RegisterOIDCProvider adds a provider that does not come from env
+func RegisterOIDCProvider(p OIDCProvider) {
+	oidcProvidersMu.Lock()
+	defer oidcProvidersMu.Unlock()
+	oidcProviders[p.Name()] = p
+}
@@ -0,0 +1,146 @@
+package lib
+
+import (
+	"backend/config"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// mockIssuer is a minimal OIDC issuer: discovery, jwks and a token endpoint
+// that checks the PKCE verifier against the challenge sent to /authorize
+type mockIssuer struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	clientID  string
+	challenge string
+	nonce     string
+}
+
+func newMockIssuer(t *testing.T, clientID string) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockIssuer{key: key, clientID: clientID}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                m.server.URL,
+			"authorization_endpoint":                m.server.URL + "/authorize",
+			"token_endpoint":                        m.server.URL + "/token",
+			"jwks_uri":                              m.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"alg": "RS256",
+				"use": "sig",
+				"kid": "test",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("code") != "good-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != m.challenge {
+			w.WriteHeader(400)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            m.server.URL,
+			"sub":            "user-123",
+			"aud":            m.clientID,
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"iat":            time.Now().Unix(),
+			"nonce":          m.nonce,
+			"email":          "oidc@example.com",
+			"email_verified": true,
+			"name":           "Oidc User",
+		})
+		token.Header["kid"] = "test"
+		idToken, _ := token.SignedString(key)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	})
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func TestOIDCProviderAuthorizationCodeWithPKCE(t *testing.T) {
+	issuer := newMockIssuer(t, "client-id")
+
+	provider, err := NewOIDCProvider(context.Background(), config.OIDCProviderConfig{
+		Name:        "mock",
+		Issuer:      issuer.server.URL,
+		ClientID:    "client-id",
+		RedirectURL: "http://localhost/auth/oidc/mock/callback",
+		Scopes:      []string{"openid", "email"},
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCProvider: %v", err)
+	}
+
+	verifier := oauth2.GenerateVerifier()
+	authURL, err := url.Parse(provider.AuthURL("state", "nonce-1", verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := authURL.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("auth url missing PKCE challenge: %s", authURL)
+	}
+	if query.Get("state") != "state" || query.Get("nonce") != "nonce-1" {
+		t.Fatalf("auth url missing state or nonce: %s", authURL)
+	}
+	issuer.challenge = query.Get("code_challenge")
+	issuer.nonce = query.Get("nonce")
+
+	identity, err := provider.Exchange(context.Background(), "good-code", verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if identity.Subject != "user-123" || identity.Email != "oidc@example.com" || !identity.EmailVerified {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+
+	if _, err := provider.Exchange(context.Background(), "good-code", "wrong-verifier", "nonce-1"); err == nil {
+		t.Fatal("expected exchange with wrong verifier to fail")
+	}
+
+	if _, err := provider.Exchange(context.Background(), "good-code", verifier, "other-nonce"); err == nil {
+		t.Fatal("expected exchange with wrong nonce to fail")
+	}
+}
@@ -17,3 +17,21 @@ func Pagination(page, limit, totalPage int, totalItems int64, links map[string]s
 		Links:      links,
 	}
 }
+
+// CursorPaginationData is used with ?cursor=, totals are not counted in
+// this mode. An empty cursor means there is no page in that direction.
+type CursorPaginationData struct {
+	Limit      int               `json:"limit"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	PrevCursor string            `json:"prev_cursor,omitempty"`
+	Links      map[string]string `json:"links"`
+}
+
+func CursorPagination(limit int, nextCursor, prevCursor string, links map[string]string) *CursorPaginationData {
+	return &CursorPaginationData{
+		Limit:      limit,
+		NextCursor: nextCursor,
+		PrevCursor: prevCursor,
+		Links:      links,
+	}
+}
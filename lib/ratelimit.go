@@ -0,0 +1,137 @@
+package lib
+
+import (
+	"backend/config"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Duration
+}
+
+// sliding window log: one sorted set member per request, scored by time in ms
+var slidingWindow = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+var fallbackLimiter = &memoryLimiter{hits: map[string]*memoryHits{}}
+
+// AllowRequest counts a request for key against limit per window. Redis is
+// used so every instance shares the same window; when redis is unavailable
+// the count falls back to this process memory.
+func AllowRequest(key string, limit int, window time.Duration) RateLimitResult {
+	if config.RedisReady() {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		now := time.Now()
+		member := fmt.Sprintf("%d-%d", now.UnixNano(), fallbackLimiter.next())
+		res, err := slidingWindow.Run(ctx, config.Rdb, []string{"ratelimit:" + key},
+			now.UnixMilli(), window.Milliseconds(), limit, member).Int64Slice()
+		if err == nil && len(res) == 3 {
+			return RateLimitResult{
+				Allowed:   res[0] == 1,
+				Limit:     limit,
+				Remaining: max(limit-int(res[1]), 0),
+				Reset:     time.Duration(res[2]) * time.Millisecond,
+			}
+		}
+	}
+
+	return fallbackLimiter.allow(key, limit, window)
+}
+
+type memoryLimiter struct {
+	mu      sync.Mutex
+	hits    map[string]*memoryHits
+	counter uint64
+}
+
+// memoryHits is the window log of one key. expires is the last request plus
+// the key's own window, like the PEXPIRE of the Redis key, so the cleanup
+// does not depend on the window of the request that triggers it.
+type memoryHits struct {
+	times   []time.Time
+	expires time.Time
+}
+
+func (m *memoryLimiter) next() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counter++
+	return m.counter
+}
+
+func (m *memoryLimiter) allow(key string, limit int, window time.Duration) RateLimitResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if len(m.hits) > 10000 {
+		for k, hits := range m.hits {
+			if !now.Before(hits.expires) {
+				delete(m.hits, k)
+			}
+		}
+	}
+
+	hits := m.hits[key]
+	if hits == nil {
+		hits = &memoryHits{}
+		m.hits[key] = hits
+	}
+
+	valid := hits.times[:0]
+	for _, t := range hits.times {
+		if now.Sub(t) < window {
+			valid = append(valid, t)
+		}
+	}
+
+	allowed := len(valid) < limit
+	if allowed {
+		valid = append(valid, now)
+	}
+	hits.times = valid
+	hits.expires = now.Add(window)
+
+	reset := window
+	if len(valid) > 0 {
+		reset = valid[0].Add(window).Sub(now)
+	}
+
+	return RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: max(limit-len(valid), 0),
+		Reset:     reset,
+	}
+}
@@ -0,0 +1,67 @@
+package lib
+
+import (
+	"testing"
+	"time"
+)
+
+// without redis AllowRequest counts in the in-memory fallback
+func TestAllowRequest(t *testing.T) {
+	key := "test:allow:" + t.Name()
+
+	for i, wantRemaining := range []int{2, 1, 0} {
+		res := AllowRequest(key, 3, time.Minute)
+		if !res.Allowed || res.Remaining != wantRemaining || res.Limit != 3 {
+			t.Fatalf("request %d = %+v", i+1, res)
+		}
+	}
+
+	res := AllowRequest(key, 3, time.Minute)
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("request over the limit = %+v", res)
+	}
+	if res.Reset <= 0 || res.Reset > time.Minute {
+		t.Fatalf("reset = %s", res.Reset)
+	}
+
+	// other keys have their own window
+	if res := AllowRequest(key+":other", 3, time.Minute); !res.Allowed {
+		t.Fatalf("other key = %+v", res)
+	}
+}
+
+func TestMemoryLimiterWindow(t *testing.T) {
+	m := &memoryLimiter{hits: map[string]*memoryHits{}}
+	window := 50 * time.Millisecond
+
+	if !m.allow("ip", 1, window).Allowed {
+		t.Fatal("first request denied")
+	}
+	if res := m.allow("ip", 1, window); res.Allowed || res.Reset > window {
+		t.Fatalf("second request = %+v", res)
+	}
+
+	time.Sleep(window + 10*time.Millisecond)
+	if res := m.allow("ip", 1, window); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("after the window = %+v", res)
+	}
+}
+
+func TestMemoryLimiterCleanup(t *testing.T) {
+	m := &memoryLimiter{hits: map[string]*memoryHits{}}
+	old := time.Now().Add(-time.Hour)
+	for i := range 10001 {
+		m.hits[string(rune(i))] = &memoryHits{times: []time.Time{old}, expires: old.Add(time.Minute)}
+	}
+	// a key with a longer window than the caller's is still counting
+	daily := time.Now().Add(-2 * time.Hour)
+	m.hits["daily"] = &memoryHits{times: []time.Time{daily}, expires: daily.Add(24 * time.Hour)}
+
+	m.allow("fresh", 1, time.Minute)
+	if len(m.hits) != 2 {
+		t.Fatalf("%d keys left, want the daily and the fresh one", len(m.hits))
+	}
+	if res := m.allow("daily", 1, 24*time.Hour); res.Allowed {
+		t.Fatalf("daily key was evicted, second request = %+v", res)
+	}
+}
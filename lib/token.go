@@ -0,0 +1,13 @@
+package lib
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// RandomToken returns a hex encoded random string of n bytes
+func RandomToken(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
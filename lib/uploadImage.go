@@ -2,6 +2,7 @@ package lib
 
 import (
 	"backend/config"
+	"backend/metrics"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -16,17 +17,24 @@ func UploadImage(file multipart.File) (string, error) {
 	cld, ctx := config.CloudinaryInit()
 
 	if cld == nil {
-		return UploadLocal(file)
+		return uploadLocalCounted(file)
 	}
 
 	uploadResult, err := cld.Upload.Upload(ctx, file, uploader.UploadParams{})
+	metrics.Upload("cloudinary", err)
 	if err != nil {
-		return UploadLocal(file)
+		return uploadLocalCounted(file)
 	}
 
 	return uploadResult.SecureURL, nil
 }
 
+func uploadLocalCounted(file multipart.File) (string, error) {
+	url, err := UploadLocal(file)
+	metrics.Upload("local", err)
+	return url, err
+}
+
 func UploadLocal(src multipart.File) (string, error) {
 	path := "uploads/"
 	os.MkdirAll(path, 0755)
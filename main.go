@@ -2,7 +2,9 @@ package main
 
 import (
 	"backend/config"
+	"backend/controllers"
 	"backend/routes"
+	"context"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -20,14 +22,20 @@ import (
 // @BasePath /
 func main() {
 	godotenv.Load()
+	config.SetupLogger()
+	shutdownTracing := config.SetupTracing(context.Background())
+	defer shutdownTracing(context.Background())
 	config.ConnectDb()
 	config.Redis()
-	r := gin.Default()
+	config.StartHealthMonitor(context.Background())
+	server := controllers.NewServer(config.Db, config.Rdb)
+	server.StartRankingRefresh(context.Background())
+	r := gin.New()
 
 
 	//akses ke gambar lokal
 	r.Static("/static", "./uploads")
-	routes.Routes(r)
+	routes.Routes(r, server)
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -0,0 +1,124 @@
+// Package metrics holds the Prometheus collectors served on /metrics.
+package metrics
+
+import (
+	"backend/cache"
+	"backend/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by route template and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route template and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	OrdersCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "orders_created_total",
+		Help: "Orders created.",
+	})
+
+	OrderRevenue = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "orders_revenue_total",
+		Help: "Total of created orders including tax and delivery, in IDR.",
+	})
+
+	Uploads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "uploads_total",
+		Help: "Image uploads by storage backend and result.",
+	}, []string{"backend", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		OrdersCreated,
+		OrderRevenue,
+		Uploads,
+		dbPoolCollector{},
+		cacheCollector{},
+	)
+}
+
+// OrderCreated counts an order and its total
+func OrderCreated(total float64) {
+	OrdersCreated.Inc()
+	OrderRevenue.Add(total)
+}
+
+// Upload counts an upload attempt on backend ("cloudinary" or "local")
+func Upload(backend string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	Uploads.WithLabelValues(backend, result).Inc()
+}
+
+var (
+	dbConns = prometheus.NewDesc("db_pool_connections",
+		"Connections of the pgx pool by state.", []string{"state"}, nil)
+	dbMaxConns = prometheus.NewDesc("db_pool_max_connections",
+		"Maximum size of the pgx pool.", nil, nil)
+	dbAcquires = prometheus.NewDesc("db_pool_acquires_total",
+		"Connection acquires from the pgx pool by result.", []string{"result"}, nil)
+	dbAcquireSeconds = prometheus.NewDesc("db_pool_acquire_duration_seconds_total",
+		"Time spent acquiring connections from the pgx pool.", nil, nil)
+)
+
+// dbPoolCollector reads config.Db statistics at scrape time
+type dbPoolCollector struct{}
+
+func (dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbConns
+	ch <- dbMaxConns
+	ch <- dbAcquires
+	ch <- dbAcquireSeconds
+}
+
+func (dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	if config.Db == nil {
+		return
+	}
+	stat := config.Db.Stat()
+
+	ch <- prometheus.MustNewConstMetric(dbConns, prometheus.GaugeValue, float64(stat.IdleConns()), "idle")
+	ch <- prometheus.MustNewConstMetric(dbConns, prometheus.GaugeValue, float64(stat.AcquiredConns()), "acquired")
+	ch <- prometheus.MustNewConstMetric(dbConns, prometheus.GaugeValue, float64(stat.ConstructingConns()), "constructing")
+	ch <- prometheus.MustNewConstMetric(dbMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(dbAcquires, prometheus.CounterValue, float64(stat.AcquireCount()), "success")
+	ch <- prometheus.MustNewConstMetric(dbAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()), "waited")
+	ch <- prometheus.MustNewConstMetric(dbAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()), "canceled")
+	ch <- prometheus.MustNewConstMetric(dbAcquireSeconds, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
+
+var cacheLookups = prometheus.NewDesc("cache_lookups_total",
+	"Cache lookups by cache name (products, featured, ...) and result.", []string{"cache", "result"}, nil)
+
+// cacheCollector exposes cache.Stats
+type cacheCollector struct{}
+
+func (cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheLookups
+}
+
+func (cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, c := range cache.Stats() {
+		ch <- prometheus.MustNewConstMetric(cacheLookups, prometheus.CounterValue, float64(c.Hits), name, "hit")
+		ch <- prometheus.MustNewConstMetric(cacheLookups, prometheus.CounterValue, float64(c.Misses), name, "miss")
+		ch <- prometheus.MustNewConstMetric(cacheLookups, prometheus.CounterValue, float64(c.Errors), name, "error")
+	}
+}
@@ -1,8 +1,8 @@
 package middleware
 
 import (
+	"backend/apperr"
 	"backend/lib"
-	"backend/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,22 +11,14 @@ func AdminOnly() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		user, exists := ctx.Get("user")
 		if !exists {
-			ctx.JSON(401, models.Response{
-				Success: false,
-				Message: "Unauthorized",
-			})
-			ctx.Abort()
+			abortWithError(ctx, apperr.Unauthorized("Unauthorized"))
 			return
 		}
 
 		payload := user.(lib.UserPayload)
 
 		if payload.Role != "admin" {
-			ctx.JSON(403, models.Response{
-				Success: false,
-				Message: "Forbidden: Admin only",
-			})
-			ctx.Abort()
+			abortWithError(ctx, apperr.Forbidden("Forbidden: Admin only"))
 			return
 		}
 
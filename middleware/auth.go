@@ -1,8 +1,11 @@
 package middleware
 
 import (
+	"backend/apperr"
+	"backend/config"
 	"backend/lib"
 	"backend/models"
+	"errors"
 	"os"
 	"strings"
 
@@ -10,16 +13,14 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func Auth() gin.HandlerFunc {
+// Auth checks the bearer token, users is asked whether the account still
+// exists, verified its email and enabled two factor when the policies want it
+func Auth(users models.UserRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authHeader := ctx.GetHeader("Authorization")
 
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			ctx.JSON(401, models.Response{
-				Success: false,
-				Message: "Unauthorized",
-			})
-			ctx.Abort()
+			abortWithError(ctx, apperr.Unauthorized("Unauthorized"))
 			return
 		}
 
@@ -27,11 +28,17 @@ func Auth() gin.HandlerFunc {
 
 		payload, err := lib.VerifyToken(token)
 		if err != nil {
-			ctx.JSON(401, models.Response{
-				Success: false,
-				Message: "Token invalid",
-			})
-			ctx.Abort()
+			abortWithError(ctx, apperr.Unauthorized("Token invalid"))
+			return
+		}
+
+		revoked, err := tokenRevoked(ctx, users, payload)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		if revoked {
+			abortWithError(ctx, apperr.Unauthorized("Token revoked"))
 			return
 		}
 
@@ -39,10 +46,69 @@ func Auth() gin.HandlerFunc {
 
 		ctx.Set("user_id", int64(payload.Id))
 		ctx.Set("role", payload.Role)
+
+		if config.EmailVerificationPolicy() == "login" && !requireVerifiedEmail(ctx, users) {
+			return
+		}
+
+		// enrollment routes stay open so the account can set up two factor
+		if config.TwoFactorRequired(payload.Role) && !strings.HasPrefix(ctx.Request.URL.Path, "/user/2fa") {
+			user, ok := currentUser(ctx, users)
+			if !ok {
+				return
+			}
+			if !user.TwoFactorEnabled {
+				abortWithError(ctx, apperr.Forbidden("Two factor authentication is required, enroll at /user/2fa/enroll"))
+				return
+			}
+		}
 		ctx.Next()
 
 	}
 }
+// OptionalAuth sets the user like Auth when a valid token is sent, but lets
+// anonymous requests through (public routes with per-user data)
+func OptionalAuth(users models.UserRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authHeader := ctx.GetHeader("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			ctx.Next()
+			return
+		}
+
+		payload, err := lib.VerifyToken(strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
+			ctx.Next()
+			return
+		}
+
+		// a token that cannot be checked is treated like no token
+		if revoked, err := tokenRevoked(ctx, users, payload); err == nil && !revoked {
+			ctx.Set("user", payload)
+			ctx.Set("user_id", int64(payload.Id))
+			ctx.Set("role", payload.Role)
+		}
+		ctx.Next()
+	}
+}
+
+// tokenRevoked checks the revocation list. While Redis is down the token is
+// only accepted when its user still exists, deleted accounts are revoked. A
+// database outage is answered by RequireDatabase before Auth runs.
+func tokenRevoked(ctx *gin.Context, users models.UserRepository, payload lib.UserPayload) (bool, error) {
+	revoked, err := lib.IsTokenRevoked(payload)
+	if !errors.Is(err, lib.ErrRevocationUnknown) {
+		return revoked, err
+	}
+
+	config.Logger(ctx).Warn("token revocation unknown, checking the user", "user_id", payload.Id, "error", err)
+	_, err = users.Get(ctx, int64(payload.Id))
+	if apperr.KindOf(err) == apperr.KindNotFound {
+		return true, nil
+	}
+	return false, err
+}
+
 func CorsMiddleware() gin.HandlerFunc {
 	frontEnd := os.Getenv("FRONTEND")
     return cors.New(cors.Config{
@@ -52,6 +118,7 @@ func CorsMiddleware() gin.HandlerFunc {
         },
         AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
         AllowHeaders: []string{"Content-Type", "Authorization"},
+        ExposeHeaders: []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"},
         AllowCredentials: true,
     })
 }
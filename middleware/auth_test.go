@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"backend/apperr"
+	"backend/lib"
+	"backend/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// downUsers is a user repository whose database does not answer
+type downUsers struct {
+	models.UserRepository
+}
+
+func (downUsers) Get(context.Context, int64) (*models.User, error) {
+	return nil, apperr.Unavailable("Database is unavailable, try again later")
+}
+
+// without Redis and the database a token cannot be checked against the
+// revocation list, Auth refuses it instead of letting it through
+func TestAuthFailsClosedWithoutRevocationList(t *testing.T) {
+	t.Setenv("APP_SECRET", "test-secret")
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(Errors())
+	r.GET("/private", Auth(downUsers{}), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	r.GET("/public", OptionalAuth(downUsers{}), func(ctx *gin.Context) {
+		if _, ok := ctx.Get("user_id"); ok {
+			ctx.Status(http.StatusOK)
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	})
+
+	token := lib.GeneratedTokens(7, "user")
+	tests := map[string]int{
+		"/private": http.StatusServiceUnavailable,
+		"/public":  http.StatusNoContent,
+	}
+
+	for target, want := range tests {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("%s = %d, want %d", target, w.Code, want)
+		}
+	}
+}
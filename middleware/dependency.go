@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"backend/apperr"
+	"backend/config"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireDatabase answers 503 while the database is down instead of letting
+// the handlers fail. Routes in skip (gin route patterns like "/") are always
+// served.
+func RequireDatabase(skip ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		path := ctx.FullPath()
+		if config.DbReady() || path == "" || slices.Contains(skip, path) {
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(config.HealthCheckInterval().Seconds()))))
+		abortWithError(ctx, apperr.Unavailable("Database is unavailable, try again later"))
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"backend/apperr"
+	"backend/i18n"
+	"backend/models"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const problemJSON = "application/problem+json"
+
+// Problem is an RFC 7807 error body, sent to clients that accept
+// application/problem+json
+type Problem struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail,omitempty"`
+	Instance string              `json:"instance,omitempty"`
+	Errors   []apperr.FieldError `json:"errors,omitempty"`
+}
+
+// Errors answers the last error a handler added with ctx.Error, when the
+// handler did not write a response itself. The status comes from the
+// apperr kind, causes are only logged (RequestLogger logs ctx.Errors).
+func Errors() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+		writeError(ctx, ctx.Errors.Last().Err)
+	}
+}
+
+// abortWithError answers err right away, for middleware that stops the chain
+func abortWithError(ctx *gin.Context, err error) {
+	ctx.Error(err)
+	writeError(ctx, err)
+}
+
+// writeError sends err as problem+json or as models.Response, depending on
+// the Accept header. The messages follow Accept-Language, see i18n.
+func writeError(ctx *gin.Context, err error) {
+	e := apperr.From(err).Localize(i18n.Locale(ctx.GetHeader("Accept-Language")))
+	status := e.Kind.Status()
+
+	if !strings.Contains(ctx.GetHeader("Accept"), problemJSON) {
+		ctx.AbortWithStatusJSON(status, models.Response{
+			Success: false,
+			Message: e.Message,
+			Errors:  e.Fields,
+		})
+		return
+	}
+
+	ctx.Header("Content-Type", problemJSON)
+	ctx.AbortWithStatusJSON(status, Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   e.Message,
+		Instance: ctx.Request.URL.Path,
+		Errors:   e.Fields,
+	})
+}
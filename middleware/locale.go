@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"backend/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale picks the response language from Accept-Language and stores it in
+// the request context, for the messages and the translated product content
+func Locale() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		locale := i18n.Locale(ctx.GetHeader("Accept-Language"))
+
+		ctx.Header("Content-Language", locale)
+		ctx.Writer.Header().Add("Vary", "Accept-Language")
+		ctx.Request = ctx.Request.WithContext(i18n.WithLocale(ctx.Request.Context(), locale))
+
+		ctx.Next()
+	}
+}
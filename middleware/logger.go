@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"backend/config"
+	"backend/lib"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestLogger reuses the caller's X-Request-ID (or creates one), echoes
+// it in the response and stores a logger with request_id in the request
+// context, then logs one line per request. The query string is left out,
+// it may carry tokens.
+func RequestLogger() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		requestID := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = lib.RandomToken(16)
+		}
+		ctx.Header(RequestIDHeader, requestID)
+		ctx.Set("request_id", requestID)
+
+		logger := slog.Default().With("request_id", requestID)
+		if traceID := config.TraceID(ctx.Request.Context()); traceID != "" {
+			logger = logger.With("trace_id", traceID)
+		}
+		ctx.Request = ctx.Request.WithContext(config.WithLogger(ctx.Request.Context(), logger))
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		attrs := []any{
+			"method", ctx.Request.Method,
+			"path", ctx.Request.URL.Path,
+			"route", ctx.FullPath(),
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"client_ip", ctx.ClientIP(),
+			"bytes", ctx.Writer.Size(),
+		}
+		if userID, ok := ctx.Get("user_id"); ok {
+			attrs = append(attrs, "user_id", userID)
+		}
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, "errors", ctx.Errors.String())
+		}
+
+		// a response served despite an error (a part left out) is a
+		// warning too
+		switch {
+		case status >= 500:
+			logger.Error("request", attrs...)
+		case status >= 400 || len(ctx.Errors) > 0:
+			logger.Warn("request", attrs...)
+		default:
+			logger.Info("request", attrs...)
+		}
+	}
+}
+
+// Recovery logs a panic with the request logger and answers 500
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(ctx *gin.Context, err any) {
+		config.Logger(ctx).Error("panic", "error", err, "stack", string(debug.Stack()))
+		writeError(ctx, fmt.Errorf("panic: %v", err))
+	})
+}
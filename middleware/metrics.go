@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"backend/apperr"
+	"backend/metrics"
+	"crypto/subtle"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records request count and latency per route template, requests
+// matching no route are grouped as "unmatched" to keep the label set small
+func Metrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(ctx.Writer.Status())
+
+		metrics.HTTPRequests.WithLabelValues(ctx.Request.Method, route, status).Inc()
+		metrics.HTTPDuration.WithLabelValues(ctx.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// MetricsToken protects /metrics with "Authorization: Bearer <METRICS_TOKEN>".
+// Without METRICS_TOKEN the route answers 404, it is never left open.
+func MetricsToken() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token := os.Getenv("METRICS_TOKEN")
+		if token == "" {
+			abortWithError(ctx, apperr.NotFound("Not found"))
+			return
+		}
+
+		given := ctx.GetHeader("Authorization")
+		if subtle.ConstantTimeCompare([]byte(given), []byte("Bearer "+token)) != 1 {
+			abortWithError(ctx, apperr.Unauthorized("Unauthorized"))
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// /metrics and /status are closed unless METRICS_TOKEN is set and sent
+func TestMetricsToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(Errors())
+	r.GET("/metrics", MetricsToken(), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{"not configured", "", "", http.StatusNotFound},
+		{"not configured, header sent", "", "Bearer ", http.StatusNotFound},
+		{"missing header", "rahasia", "", http.StatusUnauthorized},
+		{"wrong token", "rahasia", "Bearer salah", http.StatusUnauthorized},
+		{"right token", "rahasia", "Bearer rahasia", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("METRICS_TOKEN", tt.token)
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"backend/apperr"
+	"backend/config"
+	"backend/lib"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RateLimit(policy config.RateLimitPolicy) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !config.RateLimitEnabled() {
+			ctx.Next()
+			return
+		}
+
+		key := fmt.Sprintf("%s:ip:%s", policy.Name, ctx.ClientIP())
+		if policy.PerUser {
+			if userID, ok := rateLimitUser(ctx); ok {
+				key = fmt.Sprintf("%s:user:%d", policy.Name, userID)
+			}
+		}
+
+		result := lib.AllowRequest(key, policy.Limit, policy.Window)
+		resetSeconds := int(math.Ceil(result.Reset.Seconds()))
+
+		ctx.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.Header("RateLimit-Reset", strconv.Itoa(resetSeconds))
+		ctx.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds())))
+
+		if !result.Allowed {
+			ctx.Header("Retry-After", strconv.Itoa(resetSeconds))
+			abortWithError(ctx, apperr.TooManyRequests("Too many requests, try again later"))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// rateLimitUser reads the user id set by Auth, or from the bearer token when
+// the limiter runs before Auth (ex: on the global router)
+func rateLimitUser(ctx *gin.Context) (int64, bool) {
+	if userID, exists := ctx.Get("user_id"); exists {
+		return userID.(int64), true
+	}
+
+	authHeader := ctx.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return 0, false
+	}
+
+	payload, err := lib.VerifyToken(strings.TrimPrefix(authHeader, "Bearer "))
+	if err != nil {
+		return 0, false
+	}
+	return int64(payload.Id), true
+}
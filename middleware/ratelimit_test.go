@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"backend/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// a client rotating X-Forwarded-For still hits its own limit when no proxy
+// is trusted (TRUSTED_PROXIES unset)
+func TestRateLimitIgnoresForwardedFor(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "")
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	if err := r.SetTrustedProxies(config.TrustedProxies()); err != nil {
+		t.Fatal(err)
+	}
+	r.Use(Errors(), RateLimit(config.RateLimitPolicy{Name: "test-xff", Limit: 1, Window: time.Minute}))
+	r.GET("/", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "203.0.113.7:4000"
+		req.Header.Set("X-Forwarded-For", []string{"10.0.0.1", "10.0.0.2"}[i])
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("request %d = %d, want %d", i+1, w.Code, want)
+		}
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", " 10.0.0.0/8, 192.168.1.1 ,")
+	if got := config.TrustedProxies(); len(got) != 2 || got[0] != "10.0.0.0/8" || got[1] != "192.168.1.1" {
+		t.Fatalf("TrustedProxies = %q", got)
+	}
+}
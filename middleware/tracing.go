@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"backend/config"
+	"bytes"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+const TraceIDHeader = "X-Trace-ID"
+
+// probes and scrapes would only add noise to the traces
+var untracedPaths = []string{"/healthz", "/readyz", "/metrics"}
+
+// Tracing starts a server span per request, named after the route template
+func Tracing() gin.HandlerFunc {
+	return otelgin.Middleware(config.ServiceName(),
+		otelgin.WithFilter(func(r *http.Request) bool {
+			return !slices.Contains(untracedPaths, r.URL.Path)
+		}),
+	)
+}
+
+// TraceID returns the trace id in the X-Trace-ID header and adds "trace_id"
+// to JSON error bodies, so a failed request can be found in the traces. It
+// must run after Tracing.
+func TraceID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := config.TraceID(ctx.Request.Context())
+		if traceID == "" {
+			ctx.Next()
+			return
+		}
+
+		ctx.Header(TraceIDHeader, traceID)
+		ctx.Writer = &traceIDWriter{ResponseWriter: ctx.Writer, traceID: traceID}
+		ctx.Next()
+	}
+}
+
+type traceIDWriter struct {
+	gin.ResponseWriter
+	traceID string
+	written bool
+}
+
+func (w *traceIDWriter) Write(b []byte) (int, error) {
+	first := !w.written
+	w.written = true
+
+	contentType := w.Header().Get("Content-Type")
+	isJSON := strings.HasPrefix(contentType, "application/json") || strings.HasPrefix(contentType, problemJSON)
+	if !first || w.Status() < 400 || !isJSON || len(b) < 2 || b[0] != '{' {
+		return w.ResponseWriter.Write(b)
+	}
+
+	// gin renders the whole body in one Write, the id becomes its first field
+	body := bytes.TrimSpace(b[1:])
+	field := `{"trace_id":"` + w.traceID + `"`
+	if len(body) > 0 && body[0] != '}' {
+		field += ","
+	}
+
+	if _, err := w.ResponseWriter.Write(append([]byte(field), body...)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (w *traceIDWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"backend/apperr"
+	"backend/config"
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifiedEmail blocks accounts that have not verified their email yet.
+// It must run after Auth, which already checked it with the "login" policy.
+func VerifiedEmail(users models.UserRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if policy := config.EmailVerificationPolicy(); policy == "off" || policy == "login" {
+			ctx.Next()
+			return
+		}
+
+		if !requireVerifiedEmail(ctx, users) {
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func requireVerifiedEmail(ctx *gin.Context, users models.UserRepository) bool {
+	user, ok := currentUser(ctx, users)
+	if !ok {
+		return false
+	}
+
+	if !user.EmailVerified {
+		abortWithError(ctx, apperr.Forbidden("Email not verified, please verify your email first"))
+		return false
+	}
+
+	return true
+}
+
+// currentUser loads the account of the token, aborting when it cannot
+func currentUser(ctx *gin.Context, users models.UserRepository) (*models.User, bool) {
+	user, err := users.Get(ctx, ctx.MustGet("user_id").(int64))
+	if apperr.KindOf(err) == apperr.KindNotFound {
+		// the account was deleted after the token was issued
+		err = apperr.Unauthorized("Unauthorized")
+	}
+	if err != nil {
+		abortWithError(ctx, err)
+		return nil, false
+	}
+
+	return user, true
+}
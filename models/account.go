@@ -0,0 +1,116 @@
+package models
+
+import (
+	"backend/apperr"
+	"context"
+	"time"
+)
+
+var ErrUserNotFound = apperr.NotFound("user not found")
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type UserExport struct {
+	ExportedAt time.Time          `json:"exported_at"`
+	Profile    ListUserStruct     `json:"profile"`
+	Orders     []OrderDetail      `json:"orders"`
+	Cart       []CartItemResponse `json:"cart"`
+	Favorites  []Product          `json:"favorites"`
+}
+
+// ExportUserData collects everything stored about a user
+func ExportUserData(ctx context.Context, db DB, userID int64) (*UserExport, error) {
+	profile, err := GetUserProfile(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(ctx,
+		`SELECT id FROM orders WHERE users_id = $1 ORDER BY order_date DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		orderIDs = append(orderIDs, id)
+	}
+	rows.Close()
+
+	orders := make([]OrderDetail, 0, len(orderIDs))
+	for _, id := range orderIDs {
+		order, err := GetOrderDetail(ctx, db, id)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+
+	cart, err := GetCart(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	favorites, err := GetAllUserFavorites(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserExport{
+		ExportedAt: time.Now(),
+		Profile:    profile,
+		Orders:     orders,
+		Cart:       cart,
+		Favorites:  favorites,
+	}, nil
+}
+
+// DeleteUserAccount removes the user with profile and cart. Orders are kept
+// for accounting but detached from the user and stripped of personal data.
+func DeleteUserAccount(ctx context.Context, db DB, userID int64) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE orders
+		SET users_id = NULL,
+			customer_name = 'deleted user',
+			customer_phone = '',
+			customer_address = '',
+			updated_at = NOW()
+		WHERE users_id = $1
+	`, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM cart WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM profile WHERE users_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM users WHERE id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return tx.Commit(ctx)
+}
@@ -1,8 +1,10 @@
 package models
 
 import (
-	"backend/config"
+	"backend/lib"
 	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -15,18 +17,17 @@ type OrderListItem struct {
 }
 
 type Categories struct {
-	Id int `json:id`
+	Id int `json:"id"`
 	Name string `json:"name" binding:"required"`
+	// Translations per locale ("id"), left out on update keeps the saved ones
+	Translations map[string]CategoryTranslation `json:"translations,omitempty" binding:"omitempty,dive,keys,locale,endkeys"`
 }
 
 type UpdateOrderStatusRequest struct {
-	Status int `json:"status"`
+	Status int `json:"status" binding:"required,id"`
 }
-func GetAllOrders(page, limit int) ([]OrderListItem, int64, error) {
-	ctx := context.Background()
-
-	offset := (page - 1) * limit
-	query := `
+func allOrdersQuery(where, orderBy, limit string) string {
+	return `
 		SELECT
 		    o.id,
 		    o.order_date,
@@ -36,14 +37,16 @@ func GetAllOrders(page, limit int) ([]OrderListItem, int64, error) {
 		FROM orders o
 		LEFT JOIN order_items oi ON oi.order_id = o.id
 		LEFT JOIN shippings s ON s.id = o.shipping_id
+		` + where + `
 		GROUP BY o.id, s.name
-		ORDER BY o.order_date DESC
-		LIMIT $1 OFFSET $2;
-	`
+		ORDER BY ` + orderBy + `
+		` + limit
+}
 
-	rows, err := config.Db.Query(ctx, query, limit, offset)
+func scanOrderList(ctx context.Context, db DB, query string, args ...any) ([]OrderListItem, error) {
+	rows, err := db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -53,13 +56,25 @@ func GetAllOrders(page, limit int) ([]OrderListItem, int64, error) {
 		var o OrderListItem
 		err := rows.Scan(&o.ID, &o.Date, &o.Status, &o.Total, &o.Invoice)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 		orders = append(orders, o)
 	}
 
+	return orders, rows.Err()
+}
+
+func GetAllOrders(ctx context.Context, db DB, page, limit int) ([]OrderListItem, int64, error) {
+	offset := (page - 1) * limit
+	query := allOrdersQuery("", "o.order_date DESC", "LIMIT $1 OFFSET $2")
+
+	orders, err := scanOrderList(ctx, db, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	var totalItems int64
-	err = config.Db.QueryRow(ctx, `
+	err = db.QueryRow(ctx, `
 		SELECT COUNT(*) 
 		FROM orders;
 	`).Scan(&totalItems)
@@ -70,10 +85,38 @@ func GetAllOrders(page, limit int) ([]OrderListItem, int64, error) {
 	return orders, totalItems, nil
 }
 
-func UpdateOrderStatus(orderID int64, status int) error {
-	ctx := context.Background()
+func GetAllOrdersCursor(ctx context.Context, db DB, cursor *lib.Cursor, limit int) ([]OrderListItem, string, string, error) {
+	if limit < 1 {
+		limit = 10
+	}
+	if cursor != nil && cursor.Sort != "" {
+		return nil, "", "", lib.ErrInvalidCursor
+	}
+
+	key := keyset{Expr: "o.order_date", Cast: "timestamp", ID: "o.id", Desc: true}
+	where := ""
+	var args []any
+	if cursor != nil {
+		var cond string
+		cond, args = key.after(cursor, 1)
+		where = "WHERE " + cond
+	}
+
+	query := allOrdersQuery(where, key.orderBy(cursor != nil && cursor.Backward), fmt.Sprintf("LIMIT %d", limit+1))
+
+	orders, err := scanOrderList(ctx, db, query, args...)
+	if err != nil {
+		return nil, "", "", err
+	}
 
-	_, err := config.Db.Exec(ctx, `
+	orders, next, prev := cursorPage(orders, cursor, limit, "", func(o OrderListItem) (string, int64) {
+		return timeKey(o.Date), o.ID
+	})
+	return orders, next, prev, nil
+}
+
+func UpdateOrderStatus(ctx context.Context, db DB, orderID int64, status int) error {
+	_, err := db.Exec(ctx, `
 		UPDATE orders
 		SET shipping_id = $1
 		WHERE id = $2
@@ -87,28 +130,47 @@ func UpdateOrderStatus(orderID int64, status int) error {
 }
 
 
-func CreateCategory(name string) (Categories, error) {
-    ctx := context.Background()
-
+func CreateCategory(ctx context.Context, db DB, req Categories) (Categories, error) {
     var c Categories
 
-    err := config.Db.QueryRow(ctx,
-        "INSERT INTO categories (name) VALUES ($1) RETURNING name",
-        name,
-    ).Scan(&c.Name)
+    tx, err := db.Begin(ctx)
+    if err != nil {
+        return Categories{}, err
+    }
+    defer tx.Rollback(ctx)
+
+    err = tx.QueryRow(ctx,
+        "INSERT INTO categories (name) VALUES ($1) RETURNING id, name",
+        req.Name,
+    ).Scan(&c.Id, &c.Name)
 
     if err != nil {
         return Categories{}, err
     }
 
+    if err := saveCategoryTranslations(ctx, tx, c.Id, req.Translations); err != nil {
+        return Categories{}, err
+    }
+    if err := tx.Commit(ctx); err != nil {
+        return Categories{}, err
+    }
+    c.Translations = req.Translations
+
     return c, nil
 }
 
-func GetAllCategories() ([]Categories, error) {
-	ctx := context.Background()
-	query := "SELECT id,name FROM categories ORDER BY id DESC"
+func GetAllCategories(ctx context.Context, db DB) ([]Categories, error) {
+	query := `
+		SELECT c.id, c.name, COALESCE((
+			SELECT json_object_agg(locale, json_build_object('name', name))
+			FROM category_translations
+			WHERE category_id = c.id
+		), '{}')
+		FROM categories c
+		ORDER BY c.id DESC
+	`
 
-	rows, err := config.Db.Query(ctx, query)
+	rows, err := db.Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -118,19 +180,19 @@ func GetAllCategories() ([]Categories, error) {
 	var categories []Categories
 	for rows.Next(){
 		var c Categories
-		err = rows.Scan(&c.Id,&c.Name)
+		var translationsJSON []byte
+		err = rows.Scan(&c.Id,&c.Name,&translationsJSON)
 		if err != nil {
 			return nil, err
 		}
+		_ = json.Unmarshal(translationsJSON, &c.Translations)
 		categories = append(categories, c)
 	}
 	return categories,nil
 }
 
 
-func UpdateCategory(id int, name string) (Categories, error) {
-	ctx := context.Background()
-
+func UpdateCategory(ctx context.Context, db DB, id int, req Categories) (Categories, error) {
 	query := `
 		UPDATE categories 
 		SET name = $1, updated_at = NOW()
@@ -140,19 +202,31 @@ func UpdateCategory(id int, name string) (Categories, error) {
 
 	var c Categories
 
-	err := config.Db.QueryRow(ctx, query, name, id).Scan(&c.Id, &c.Name)
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return Categories{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, query, req.Name, id).Scan(&c.Id, &c.Name)
 	if err != nil {
 		return Categories{}, err
 	}
 
+	if err := saveCategoryTranslations(ctx, tx, id, req.Translations); err != nil {
+		return Categories{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return Categories{}, err
+	}
+	c.Translations = req.Translations
+
 	return c, nil
 }
 
-func DeleteCategory(id int) error {
-	ctx := context.Background()
-
+func DeleteCategory(ctx context.Context, db DB, id int) error {
 	query := `DELETE FROM categories WHERE id=$1`
 
-	_, err := config.Db.Exec(ctx, query, id)
+	_, err := db.Exec(ctx, query, id)
 	return err
 }
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"context"
+	"fmt"
+)
+
+// soldExpr is the quantity of the product sold in the last days (0 = all time)
+func soldExpr(days int) string {
+	window := ""
+	if days > 0 {
+		window = fmt.Sprintf(" AND o.order_date >= NOW() - INTERVAL '%d days'", days)
+	}
+	return `(SELECT COALESCE(SUM(oi.qty), 0) FROM order_items oi JOIN orders o ON o.id = oi.order_id WHERE oi.product_id = p.id` + window + `)`
+}
+
+// rankedProducts loads the products of a ranking query returning
+// (product id, sold) rows, sold is the quantity of the ranking window
+func rankedProducts(ctx context.Context, db DB, query string, args ...any) ([]Product, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	sold := map[int64]int64{}
+	for rows.Next() {
+		var id, qty int64
+		if err := rows.Scan(&id, &qty); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+		sold[id] = qty
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	products, err := productsByIDs(ctx, db, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range products {
+		products[i].Sold = sold[products[i].ID]
+	}
+
+	return products, nil
+}
+
+// GetBestSellers ranks products by quantity sold in the last days (0 = all
+// time), categoryID 0 means every category
+func GetBestSellers(ctx context.Context, db DB, days, categoryID, limit int) ([]Product, error) {
+	return rankedProducts(ctx, db, `
+		SELECT oi.product_id, SUM(oi.qty) AS sold
+		FROM order_items oi
+		JOIN orders o ON o.id = oi.order_id
+		JOIN products p ON p.id = oi.product_id
+		WHERE ($1 = 0 OR o.order_date >= NOW() - make_interval(days => $1))
+			AND ($2 = 0 OR p.category_id = $2)
+		GROUP BY oi.product_id
+		ORDER BY sold DESC, oi.product_id
+		LIMIT $3
+	`, days, categoryID, limit)
+}
+
+// GetTrending ranks products by how much more they sold in the last days
+// than in the same period before, sold is the quantity of the last days
+func GetTrending(ctx context.Context, db DB, days, categoryID, limit int) ([]Product, error) {
+	return rankedProducts(ctx, db, `
+		SELECT oi.product_id,
+			COALESCE(SUM(oi.qty) FILTER (WHERE o.order_date >= NOW() - make_interval(days => $1)), 0) AS recent
+		FROM order_items oi
+		JOIN orders o ON o.id = oi.order_id
+		JOIN products p ON p.id = oi.product_id
+		WHERE o.order_date >= NOW() - make_interval(days => $1 * 2)
+			AND ($2 = 0 OR p.category_id = $2)
+		GROUP BY oi.product_id
+		HAVING COALESCE(SUM(oi.qty) FILTER (WHERE o.order_date >= NOW() - make_interval(days => $1)), 0) > 0
+		ORDER BY
+			COALESCE(SUM(oi.qty) FILTER (WHERE o.order_date >= NOW() - make_interval(days => $1)), 0)
+			- COALESCE(SUM(oi.qty) FILTER (WHERE o.order_date < NOW() - make_interval(days => $1)), 0) DESC,
+			recent DESC,
+			oi.product_id
+		LIMIT $3
+	`, days, categoryID, limit)
+}
+
+// GetCategoryIDs lists every category id, used to warm per category caches
+func GetCategoryIDs(ctx context.Context, db DB) ([]int, error) {
+	rows, err := db.Query(ctx, `SELECT id FROM categories ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -1,16 +1,15 @@
 package models
 
 import (
-	"backend/config"
 	"context"
 )
 
 type ReqCart struct {
 	UserID    int64   `json:"user_id"`
-	ProductID int64   `json:"product_id"`
-	VariantID *int64  `json:"variant_id,omitempty"`
-	SizeID    *int64  `json:"size_id,omitempty"`
-	Qty       int     `json:"quantity"`
+	ProductID int64   `json:"product_id" binding:"required,id"`
+	VariantID *int64  `json:"variant_id,omitempty" binding:"omitempty,id"`
+	SizeID    *int64  `json:"size_id,omitempty" binding:"omitempty,id"`
+	Qty       int     `json:"quantity" binding:"gt=0"`
 	Subtotal  float64 `json:"subtotal"`
 }
 
@@ -25,15 +24,14 @@ type CartItemResponse struct {
 	Qty         int     `json:"quantity"`
 	Image       string  `json:"image"`
 }
-func AddToCart(req ReqCart) (int64, error) {
-	ctx := context.Background()
+func AddToCart(ctx context.Context, db DB, req ReqCart) (int64, error) {
 	var cartID int64
 
-	err := config.Db.QueryRow(ctx, `
+	err := db.QueryRow(ctx, `
 		SELECT id FROM cart WHERE user_id = $1
 	`, req.UserID).Scan(&cartID)
 	if err != nil {
-		err = config.Db.QueryRow(ctx, `
+		err = db.QueryRow(ctx, `
 			INSERT INTO cart (user_id) VALUES ($1) RETURNING id
 		`, req.UserID).Scan(&cartID)
 		if err != nil {
@@ -43,7 +41,7 @@ func AddToCart(req ReqCart) (int64, error) {
 
 	// cek
 	var existingID int64
-	checkErr := config.Db.QueryRow(ctx, `
+	checkErr := db.QueryRow(ctx, `
 		SELECT id FROM cart_items 
 		WHERE cart_id = $1
 		  AND product_id = $2
@@ -53,7 +51,7 @@ func AddToCart(req ReqCart) (int64, error) {
 
 	// update
 	if checkErr == nil {
-		_, err = config.Db.Exec(ctx, `
+		_, err = db.Exec(ctx, `
 			UPDATE cart_items
 			SET qty = qty + $1::INT
 			WHERE id = $2::BIGINT
@@ -65,7 +63,7 @@ func AddToCart(req ReqCart) (int64, error) {
 	}
 
 	// buat baru
-	_, err = config.Db.Exec(ctx, `
+	_, err = db.Exec(ctx, `
 		INSERT INTO cart_items (cart_id, product_id, variant_id, size_id, qty)
 		VALUES ($1::BIGINT, $2::BIGINT, $3::BIGINT, $4::BIGINT, $5::INT)
 	`, cartID, req.ProductID, req.VariantID, req.SizeID, req.Qty)
@@ -76,18 +74,16 @@ func AddToCart(req ReqCart) (int64, error) {
 	return cartID, nil
 }
 
-func GetCart(userID int64) ([]CartItemResponse, error) {
-	ctx := context.Background()
-
+func GetCart(ctx context.Context, db DB, userID int64) ([]CartItemResponse, error) {
 	var cartID int64
-	err := config.Db.QueryRow(ctx, `
+	err := db.QueryRow(ctx, `
 		SELECT id FROM cart WHERE user_id = $1
 	`, userID).Scan(&cartID)
 	if err != nil {
 		return []CartItemResponse{}, nil
 	}
 
-	rows, err := config.Db.Query(ctx, `
+	rows, err := db.Query(ctx, `
 	SELECT 
 		ci.id AS cart_item_id,
 		p.id AS product_id,
@@ -140,9 +136,7 @@ func GetCart(userID int64) ([]CartItemResponse, error) {
 	return carts, nil
 }
 
-func DeleteCartItem(userID, cartItemID int64) error {
-	ctx := context.Background()
-
+func DeleteCartItem(ctx context.Context, db DB, userID, cartItemID int64) error {
 	query := `
 		DELETE FROM cart_items 
 		WHERE id = $1 
@@ -150,6 +144,6 @@ func DeleteCartItem(userID, cartItemID int64) error {
 			SELECT id FROM cart WHERE user_id = $2
 		)
 	`
-	_, err := config.Db.Exec(ctx, query, cartItemID, userID)
+	_, err := db.Exec(ctx, query, cartItemID, userID)
 	return err
 }
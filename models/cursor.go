@@ -0,0 +1,73 @@
+package models
+
+import (
+	"backend/lib"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// keyset is the sort of a listing in cursor mode: the sort key expression
+// (usable in WHERE), its type for casting the cursor value and the id column
+// that breaks ties
+type keyset struct {
+	Expr string
+	Cast string
+	ID   string
+	Desc bool
+}
+
+// after filters rows past the cursor, flipped when paging backward
+func (k keyset) after(c *lib.Cursor, argIndex int) (string, []any) {
+	op := ">"
+	if k.Desc != c.Backward {
+		op = "<"
+	}
+	cond := fmt.Sprintf("(%s, %s) %s ($%d::%s, $%d)", k.Expr, k.ID, op, argIndex, k.Cast, argIndex+1)
+	return cond, []any{c.Key, c.ID}
+}
+
+func (k keyset) orderBy(backward bool) string {
+	dir := "ASC"
+	if k.Desc != backward {
+		dir = "DESC"
+	}
+	return fmt.Sprintf("%s %s, %s %s", k.Expr, dir, k.ID, dir)
+}
+
+func timeKey(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05.999999")
+}
+
+func floatKey(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// cursorPage takes the limit+1 rows of a cursor query, drops the extra row
+// and returns the cursors of the next and previous page ("" when none)
+func cursorPage[T any](rows []T, c *lib.Cursor, limit int, sort string, key func(T) (string, int64)) ([]T, string, string) {
+	backward := c != nil && c.Backward
+	hasMore := len(rows) > limit
+	if hasMore {
+		rows = rows[:limit]
+	}
+	if backward {
+		slices.Reverse(rows)
+	}
+	if len(rows) == 0 {
+		return rows, "", ""
+	}
+
+	var next, prev string
+	if hasMore || backward {
+		k, id := key(rows[len(rows)-1])
+		next = lib.EncodeCursor(lib.Cursor{Key: k, ID: id, Sort: sort})
+	}
+	if (backward && hasMore) || (!backward && c != nil) {
+		k, id := key(rows[0])
+		prev = lib.EncodeCursor(lib.Cursor{Key: k, ID: id, Sort: sort, Backward: true})
+	}
+
+	return rows, next, prev
+}
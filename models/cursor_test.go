@@ -0,0 +1,137 @@
+package models
+
+import (
+	"backend/lib"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestKeyset(t *testing.T) {
+	key := keyset{Expr: "p.created_at", Cast: "timestamp", ID: "p.id"}
+	desc := keyset{Expr: "p.created_at", Cast: "timestamp", ID: "p.id", Desc: true}
+
+	tests := []struct {
+		name      string
+		key       keyset
+		backward  bool
+		wantAfter string
+		wantOrder string
+	}{
+		{"asc forward", key, false, "(p.created_at, p.id) > ($3::timestamp, $4)", "p.created_at ASC, p.id ASC"},
+		{"asc backward", key, true, "(p.created_at, p.id) < ($3::timestamp, $4)", "p.created_at DESC, p.id DESC"},
+		{"desc forward", desc, false, "(p.created_at, p.id) < ($3::timestamp, $4)", "p.created_at DESC, p.id DESC"},
+		{"desc backward", desc, true, "(p.created_at, p.id) > ($3::timestamp, $4)", "p.created_at ASC, p.id ASC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := &lib.Cursor{Key: "2026-01-02 03:04:05", ID: 7, Backward: tt.backward}
+
+			cond, args := tt.key.after(cursor, 3)
+			if cond != tt.wantAfter {
+				t.Errorf("after = %q, want %q", cond, tt.wantAfter)
+			}
+			if len(args) != 2 || args[0] != cursor.Key || args[1] != cursor.ID {
+				t.Errorf("after args = %v", args)
+			}
+			if got := tt.key.orderBy(tt.backward); got != tt.wantOrder {
+				t.Errorf("orderBy = %q, want %q", got, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestCursorKeys(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	if got := timeKey(at); got != "2026-01-02 03:04:05.123456" {
+		t.Errorf("timeKey = %q", got)
+	}
+	if got := timeKey(at.Truncate(time.Second)); got != "2026-01-02 03:04:05" {
+		t.Errorf("timeKey without fraction = %q", got)
+	}
+	if got := floatKey(25000.5); got != "25000.5" {
+		t.Errorf("floatKey = %q", got)
+	}
+}
+
+func TestCursorPage(t *testing.T) {
+	forward := &lib.Cursor{Key: "2", ID: 2}
+	backward := &lib.Cursor{Key: "5", ID: 5, Backward: true}
+
+	// the rows are what the query returns, at most limit+1 in query order:
+	// reversed when paging backward
+	tests := []struct {
+		name     string
+		cursor   *lib.Cursor
+		rows     []int64
+		want     []int64
+		wantNext int64 // id the next cursor points at, 0 for none
+		wantPrev int64 // id the previous cursor points at, 0 for none
+	}{
+		{"first page with more", nil, []int64{1, 2, 3}, []int64{1, 2}, 2, 0},
+		{"only page", nil, []int64{1, 2}, []int64{1, 2}, 0, 0},
+		{"empty first page", nil, nil, nil, 0, 0},
+		{"forward with more", forward, []int64{3, 4, 5}, []int64{3, 4}, 4, 3},
+		{"forward last page", forward, []int64{3}, []int64{3}, 0, 3},
+		{"forward past the end", forward, nil, nil, 0, 0},
+		{"backward with more", backward, []int64{4, 3, 2}, []int64{3, 4}, 4, 3},
+		{"backward to the first page", backward, []int64{2, 1}, []int64{1, 2}, 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := slices.Clone(tt.rows)
+			got, next, prev := cursorPage(rows, tt.cursor, 2, "newest", func(id int64) (string, int64) {
+				return strconv.FormatInt(id, 10), id
+			})
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+			assertPageCursor(t, "next", next, tt.wantNext, false)
+			assertPageCursor(t, "prev", prev, tt.wantPrev, true)
+		})
+	}
+}
+
+func assertPageCursor(t *testing.T, name, encoded string, wantID int64, wantBackward bool) {
+	t.Helper()
+
+	if wantID == 0 {
+		if encoded != "" {
+			t.Errorf("%s = %q, want none", name, encoded)
+		}
+		return
+	}
+
+	c, err := lib.DecodeCursor(encoded)
+	if err != nil || c == nil {
+		t.Fatalf("%s cursor %q: %v", name, encoded, err)
+	}
+	want := lib.Cursor{Key: strconv.FormatInt(wantID, 10), ID: wantID, Sort: "newest", Backward: wantBackward}
+	if *c != want {
+		t.Errorf("%s = %+v, want %+v", name, *c, want)
+	}
+}
+
+// a cursor from another sort order is refused before any query runs
+func TestCursorSortMismatch(t *testing.T) {
+	ctx := t.Context()
+	cursor := &lib.Cursor{Key: "1", ID: 1, Sort: "price_low"}
+
+	_, _, _, err := GetProductsCursor(ctx, nil, cursor, 10, "price_high", ProductFilter{})
+	if !errors.Is(err, lib.ErrInvalidCursor) {
+		t.Errorf("products: err = %v, want ErrInvalidCursor", err)
+	}
+
+	// the other listings have one sort, a cursor with any sort is not theirs
+	if _, _, _, err := GetAllOrdersCursor(ctx, nil, cursor, 10); !errors.Is(err, lib.ErrInvalidCursor) {
+		t.Errorf("orders: err = %v, want ErrInvalidCursor", err)
+	}
+	if _, _, _, err := ListUserCursor(ctx, nil, cursor, 10); !errors.Is(err, lib.ErrInvalidCursor) {
+		t.Errorf("users: err = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"backend/config"
+	"context"
+	"strconv"
+)
+
+type FacetCount struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// PriceBucket matches min_price=Min&max_price=Max, Max is 0 for the last bucket
+type PriceBucket struct {
+	Min   int   `json:"min"`
+	Max   int   `json:"max,omitempty"`
+	Count int64 `json:"count"`
+}
+
+type ProductFacets struct {
+	Categories []FacetCount  `json:"categories"`
+	Sizes      []FacetCount  `json:"sizes"`
+	Variants   []FacetCount  `json:"variants"`
+	Prices     []PriceBucket `json:"prices"`
+}
+
+// facetCounts counts the matched products per option, the join goes from
+// the matched products (m) to the option table (o)
+func facetCounts(ctx context.Context, db DB, filter ProductFilter, join string) ([]FacetCount, error) {
+	matched, args := filter.matchedQuery()
+
+	rows, err := db.Query(ctx, `
+		WITH m AS (`+matched+`)
+		SELECT o.id, COALESCE(o.name, ''), COUNT(DISTINCT m.id)
+		FROM m
+		`+join+`
+		GROUP BY o.id, o.name
+		ORDER BY o.id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]FacetCount, 0)
+	for rows.Next() {
+		var c FacetCount
+		if err := rows.Scan(&c.ID, &c.Name, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
+
+func priceBuckets(ctx context.Context, db DB, filter ProductFilter) ([]PriceBucket, error) {
+	bounds := config.PriceBuckets()
+	matched, args := filter.matchedQuery()
+
+	buckets := make([]PriceBucket, len(bounds)+1)
+	for i := range buckets {
+		if i > 0 {
+			buckets[i].Min = bounds[i-1]
+		}
+		if i < len(bounds) {
+			buckets[i].Max = bounds[i] - 1
+		}
+	}
+
+	args = append(args, bounds)
+	rows, err := db.Query(ctx, `
+		WITH m AS (`+matched+`)
+		SELECT width_bucket(m.price, $`+strconv.Itoa(len(args))+`::numeric[]), COUNT(*)
+		FROM m
+		GROUP BY 1
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket int
+		var count int64
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, err
+		}
+		if bucket >= 0 && bucket < len(buckets) {
+			buckets[bucket].Count = count
+		}
+	}
+
+	return buckets, rows.Err()
+}
+
+// GetProductFacets counts the products per category, size, variant and price
+// bucket. Each facet ignores its own filter so other options stay selectable.
+func GetProductFacets(ctx context.Context, db DB, filter ProductFilter) (*ProductFacets, error) {
+	filter = filter.normalize()
+
+	var facets ProductFacets
+	var err error
+
+	byCategory := filter
+	byCategory.CategoryIDs = nil
+	facets.Categories, err = facetCounts(ctx, db, byCategory, `JOIN products p ON p.id = m.id
+		JOIN `+localizedCategories(ctx)+` o ON o.id = p.category_id`)
+	if err != nil {
+		return nil, err
+	}
+
+	bySize := filter
+	bySize.SizeIDs = nil
+	facets.Sizes, err = facetCounts(ctx, db, bySize, `JOIN product_size ps ON ps.product_id = m.id
+		JOIN size o ON o.id = ps.size_id`)
+	if err != nil {
+		return nil, err
+	}
+
+	byVariant := filter
+	byVariant.VariantIDs = nil
+	facets.Variants, err = facetCounts(ctx, db, byVariant, `JOIN product_variant pv ON pv.product_id = m.id
+		JOIN variant o ON o.id = pv.variant_id`)
+	if err != nil {
+		return nil, err
+	}
+
+	byPrice := filter
+	byPrice.MinPrice, byPrice.MaxPrice = 0, 0
+	facets.Prices, err = priceBuckets(ctx, db, byPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	return &facets, nil
+}
@@ -1,11 +1,27 @@
 package models
 
 import (
-	"backend/config"
+	"backend/apperr"
 	"context"
 	"encoding/json"
+	"time"
 )
 
+type FeatureProductRequest struct {
+	DisplayOrder  int        `json:"display_order" binding:"gte=0"`
+	FeaturedFrom  *time.Time `json:"featured_from"`
+	FeaturedUntil *time.Time `json:"featured_until"`
+}
+
+type FeaturedProduct struct {
+	ID            int64      `json:"id"`
+	Name          string     `json:"name"`
+	DisplayOrder  *int       `json:"display_order"`
+	FeaturedFrom  *time.Time `json:"featured_from"`
+	FeaturedUntil *time.Time `json:"featured_until"`
+	Active        bool       `json:"active"`
+}
+
 type FavoriteReq struct {
 	Id          int    `json:"id"`
 	Image       string `json:"image"`
@@ -14,9 +30,12 @@ type FavoriteReq struct {
 	Price       string `json:"price"`
 }
 
-func Favorite(page, limit int) ([]Product, int64, error) {
-	ctx := context.Background()
+// featured products are only listed inside their (optional) schedule window
+const featuredWindow = `
+	AND (p.featured_from IS NULL OR p.featured_from <= NOW())
+	AND (p.featured_until IS NULL OR p.featured_until > NOW())`
 
+func Favorite(ctx context.Context, db DB, page, limit int) ([]Product, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -29,11 +48,11 @@ func Favorite(page, limit int) ([]Product, int64, error) {
 	query := `
 SELECT
 	p.id,
-	p.name,
-	p.description,
+	` + productNameExpr + ` AS name,
+	` + productDescriptionExpr + ` AS description,
 	COALESCE(MIN(ps.price), 0) AS min_price,
 	p.stock,
-	COALESCE(c.name, '') AS category,
+	` + categoryNameExpr + ` AS category,
 
 	COALESCE(json_agg(DISTINCT pi.image) FILTER (WHERE pi.image IS NOT NULL), '[]') AS images,
 	COALESCE(json_agg(DISTINCT v.name) FILTER (WHERE v.name IS NOT NULL), '[]') AS variants,
@@ -47,6 +66,7 @@ SELECT
 		'[]'
 	) AS sizes,
 
+	` + ratingColumns + `
 	p.created_at,
 	p.updated_at
 
@@ -57,15 +77,16 @@ LEFT JOIN product_variant pv ON pv.product_id = p.id
 LEFT JOIN variant v ON v.id = pv.variant_id
 LEFT JOIN product_size ps ON ps.product_id = p.id
 LEFT JOIN size s ON s.id = ps.size_id
+` + translationJoins(ctx) + `
 
-WHERE p.is_favorite = TRUE
+WHERE p.is_favorite = TRUE` + featuredWindow + `
 
-GROUP BY p.id, c.name
-ORDER BY p.created_at DESC
+GROUP BY p.id, c.name, pt.name, pt.description, ct.name
+ORDER BY p.featured_order ASC NULLS LAST, p.created_at DESC
 LIMIT $1 OFFSET $2
 `
 
-	rows, err := config.Db.Query(ctx, query, limit, offset)
+	rows, err := db.Query(ctx, query, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -80,6 +101,7 @@ LIMIT $1 OFFSET $2
 		err := rows.Scan(
 			&p.ID, &p.Name, &p.Description, &p.MinPrice, &p.Stock,
 			&p.Category, &imagesJSON, &variantsJSON, &sizesJSON,
+			&p.AverageRating, &p.ReviewCount,
 			&p.CreatedAt, &p.UpdatedAt,
 		)
 		if err != nil {
@@ -94,10 +116,10 @@ LIMIT $1 OFFSET $2
 	}
 
 	var total int64
-	err = config.Db.QueryRow(ctx, `
+	err = db.QueryRow(ctx, `
 		SELECT COUNT(DISTINCT p.id)
 		FROM products p
-		WHERE p.is_favorite = TRUE`,
+		WHERE p.is_favorite = TRUE`+featuredWindow,
 	).Scan(&total)
 
 	if err != nil {
@@ -106,3 +128,271 @@ LIMIT $1 OFFSET $2
 
 	return products, total, nil
 }
+
+// NextFeaturedChange is how long until a scheduled product enters or leaves
+// the featured list (its featured_from or featured_until), ok is false when
+// nothing is scheduled
+func NextFeaturedChange(ctx context.Context, db DB) (next time.Duration, ok bool, err error) {
+	var seconds *float64
+	err = db.QueryRow(ctx, `
+		SELECT EXTRACT(EPOCH FROM MIN(t) - NOW())::float8 FROM (
+			SELECT featured_from AS t FROM products WHERE is_favorite AND featured_from > NOW()
+			UNION ALL
+			SELECT featured_until FROM products WHERE is_favorite AND featured_until > NOW()
+		) boundaries
+	`).Scan(&seconds)
+	if err != nil || seconds == nil {
+		return 0, false, err
+	}
+
+	return time.Duration(*seconds * float64(time.Second)), true, nil
+}
+
+var ErrProductNotFound = apperr.NotFound("product not found")
+
+// per user favorites (wishlist)
+func AddUserFavorite(ctx context.Context, db DB, userID, productID int64) error {
+	tag, err := db.Exec(ctx, `
+		INSERT INTO user_favorites (users_id, product_id)
+		SELECT $1, id FROM products WHERE id = $2
+		ON CONFLICT (users_id, product_id) DO NOTHING
+	`, userID, productID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		err = db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)`, productID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrProductNotFound
+		}
+	}
+
+	return nil
+}
+
+func RemoveUserFavorite(ctx context.Context, db DB, userID, productID int64) error {
+	_, err := db.Exec(ctx,
+		`DELETE FROM user_favorites WHERE users_id = $1 AND product_id = $2`,
+		userID, productID,
+	)
+	return err
+}
+
+func GetUserFavorites(ctx context.Context, db DB, userID int64, page, limit int) ([]Product, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	products, err := userFavorites(ctx, db, userID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	err = db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM user_favorites WHERE users_id = $1`, userID,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return products, total, nil
+}
+
+// GetAllUserFavorites lists every favorite of the user, for the data export
+func GetAllUserFavorites(ctx context.Context, db DB, userID int64) ([]Product, error) {
+	return userFavorites(ctx, db, userID, nil, 0)
+}
+
+// userFavorites lists the user's favorites newest first, a nil limit is
+// LIMIT NULL which returns all of them
+func userFavorites(ctx context.Context, db DB, userID int64, limit any, offset int) ([]Product, error) {
+	query := `
+SELECT
+	p.id,
+	` + productNameExpr + ` AS name,
+	` + productDescriptionExpr + ` AS description,
+	COALESCE(
+		(SELECT MIN(price) FROM product_size WHERE product_id = p.id),
+		p.price
+	) AS min_price,
+	p.stock,
+	` + categoryNameExpr + ` AS category,
+
+	COALESCE(json_agg(DISTINCT pi.image) FILTER (WHERE pi.image IS NOT NULL), '[]') AS images,
+	COALESCE(json_agg(DISTINCT jsonb_build_object(
+		'variant_id', v.id,
+		'name', v.name
+	)) FILTER (WHERE v.id IS NOT NULL), '[]') AS variants,
+
+	COALESCE(
+		json_agg(DISTINCT jsonb_build_object(
+			'size_id', s.id,
+			'size_name', s.name,
+			'price', ps.price
+		)) FILTER (WHERE s.id IS NOT NULL),
+		'[]'
+	) AS sizes,
+
+	` + ratingColumns + `
+	p.created_at,
+	p.updated_at
+
+FROM user_favorites uf
+JOIN products p ON p.id = uf.product_id
+LEFT JOIN categories c ON p.category_id = c.id
+LEFT JOIN product_img pi ON pi.product_id = p.id
+LEFT JOIN product_variant pv ON pv.product_id = p.id
+LEFT JOIN variant v ON v.id = pv.variant_id
+LEFT JOIN product_size ps ON ps.product_id = p.id
+LEFT JOIN size s ON s.id = ps.size_id
+` + translationJoins(ctx) + `
+
+WHERE uf.users_id = $1
+
+GROUP BY p.id, c.name, pt.name, pt.description, ct.name, uf.created_at
+ORDER BY uf.created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+	rows, err := db.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]Product, 0)
+
+	for rows.Next() {
+		var p Product
+		var imagesJSON, variantsJSON, sizesJSON []byte
+
+		err := rows.Scan(
+			&p.ID, &p.Name, &p.Description, &p.MinPrice, &p.Stock,
+			&p.Category, &imagesJSON, &variantsJSON, &sizesJSON,
+			&p.AverageRating, &p.ReviewCount,
+			&p.CreatedAt, &p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		json.Unmarshal(imagesJSON, &p.Images)
+		json.Unmarshal(variantsJSON, &p.Variants)
+		json.Unmarshal(sizesJSON, &p.Sizes)
+		p.IsFavorited = true
+
+		products = append(products, p)
+	}
+
+	return products, rows.Err()
+}
+
+// FavoritedProductIDs returns which of the given products the user saved
+func FavoritedProductIDs(ctx context.Context, db DB, userID int64, productIDs []int64) (map[int64]bool, error) {
+	favorited := map[int64]bool{}
+
+	if userID == 0 || len(productIDs) == 0 {
+		return favorited, nil
+	}
+
+	rows, err := db.Query(ctx,
+		`SELECT product_id FROM user_favorites WHERE users_id = $1 AND product_id = ANY($2)`,
+		userID, productIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		favorited[id] = true
+	}
+
+	return favorited, nil
+}
+
+// admin curated featured list (products.is_favorite)
+func FeatureProduct(ctx context.Context, db DB, productID int64, req FeatureProductRequest) error {
+	tag, err := db.Exec(ctx, `
+		UPDATE products
+		SET is_favorite = TRUE,
+			featured_order = $1,
+			featured_from = $2,
+			featured_until = $3,
+			updated_at = NOW()
+		WHERE id = $4
+	`, req.DisplayOrder, req.FeaturedFrom, req.FeaturedUntil, productID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrProductNotFound
+	}
+
+	return nil
+}
+
+func UnfeatureProduct(ctx context.Context, db DB, productID int64) error {
+	tag, err := db.Exec(ctx, `
+		UPDATE products
+		SET is_favorite = FALSE,
+			featured_order = NULL,
+			featured_from = NULL,
+			featured_until = NULL,
+			updated_at = NOW()
+		WHERE id = $1
+	`, productID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrProductNotFound
+	}
+
+	return nil
+}
+
+// GetFeaturedProductsAdmin lists every featured product, including the ones
+// outside their schedule window
+func GetFeaturedProductsAdmin(ctx context.Context, db DB) ([]FeaturedProduct, error) {
+	rows, err := db.Query(ctx, `
+		SELECT
+			p.id,
+			p.name,
+			p.featured_order,
+			p.featured_from,
+			p.featured_until,
+			(TRUE`+featuredWindow+`) AS active
+		FROM products p
+		WHERE p.is_favorite = TRUE
+		ORDER BY p.featured_order ASC NULLS LAST, p.created_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]FeaturedProduct, 0)
+	for rows.Next() {
+		var p FeaturedProduct
+		if err := rows.Scan(&p.ID, &p.Name, &p.DisplayOrder, &p.FeaturedFrom, &p.FeaturedUntil, &p.Active); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+
+	return products, nil
+}
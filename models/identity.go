@@ -0,0 +1,131 @@
+package models
+
+import (
+	"backend/apperr"
+	"backend/lib"
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrIdentityEmailNotVerified   = apperr.Conflict("email from provider is not verified, login with password first to link the account")
+	ErrIdentityAccountNotVerified = apperr.Conflict("an account with this email is not verified yet, verify it or reset its password first")
+)
+
+// canLinkIdentity decides whether a provider account may be linked to the
+// existing user with the same email. An unverified account with a password
+// may have been registered by someone else before the owner, linking it
+// would hand that person a verified account of the owner.
+func canLinkIdentity(identity lib.OIDCIdentity, user User, hasPassword bool) error {
+	if !identity.EmailVerified {
+		return ErrIdentityEmailNotVerified
+	}
+	if !user.EmailVerified && hasPassword {
+		return ErrIdentityAccountNotVerified
+	}
+	return nil
+}
+
+// LoginWithIdentity finds the user linked to a provider account. When there
+// is no link yet it links to the user with the same (verified) email, see
+// canLinkIdentity, or creates a new user and profile like Register does.
+func LoginWithIdentity(ctx context.Context, db DB, identity lib.OIDCIdentity) (*User, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var user User
+	err = tx.QueryRow(ctx,
+		`SELECT u.id, u.email, u.role, u.email_verified, u.totp_enabled
+		 FROM user_identities ui
+		 JOIN users u ON u.id = ui.users_id
+		 WHERE ui.provider = $1 AND ui.subject = $2`,
+		identity.Provider, identity.Subject,
+	).Scan(&user.ID, &user.Email, &user.Role, &user.EmailVerified, &user.TwoFactorEnabled)
+	if err == nil {
+		return &user, tx.Commit(ctx)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	if identity.Email == "" {
+		return nil, apperr.Validation("provider did not return an email")
+	}
+
+	var hasPassword bool
+	err = tx.QueryRow(ctx,
+		`SELECT id, email, role, email_verified, totp_enabled, COALESCE(password, '') <> ''
+		 FROM users WHERE email = $1`,
+		identity.Email,
+	).Scan(&user.ID, &user.Email, &user.Role, &user.EmailVerified, &user.TwoFactorEnabled, &hasPassword)
+
+	switch {
+	case err == nil:
+		if err := canLinkIdentity(identity, user, hasPassword); err != nil {
+			return nil, err
+		}
+		if !user.EmailVerified {
+			_, err = tx.Exec(ctx,
+				`UPDATE users SET email_verified = TRUE, email_verified_at = NOW() WHERE id = $1`,
+				user.ID,
+			)
+			if err != nil {
+				return nil, err
+			}
+			user.EmailVerified = true
+		}
+
+	case errors.Is(err, pgx.ErrNoRows):
+		err = tx.QueryRow(ctx,
+			`INSERT INTO users (email, role, email_verified, email_verified_at)
+			 VALUES ($1, 'user', $2, CASE WHEN $2 THEN NOW() END)
+			 RETURNING id`,
+			identity.Email, identity.EmailVerified,
+		).Scan(&user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		username := identity.Name
+		if username == "" {
+			username = strings.Split(identity.Email, "@")[0]
+		}
+
+		_, err = tx.Exec(ctx,
+			`INSERT INTO profile (users_id, username, phone, address)
+			 VALUES ($1, $2, '', '')`,
+			user.ID, username,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		user.Email = identity.Email
+		user.Role = "user"
+		user.EmailVerified = identity.EmailVerified
+
+	default:
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO user_identities (users_id, provider, subject, email)
+		 VALUES ($1, $2, $3, $4)`,
+		user.ID, identity.Provider, identity.Subject, identity.Email,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
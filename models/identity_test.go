@@ -0,0 +1,33 @@
+package models
+
+import (
+	"backend/lib"
+	"errors"
+	"testing"
+)
+
+func TestCanLinkIdentity(t *testing.T) {
+	tests := []struct {
+		name         string
+		providerOK   bool
+		userVerified bool
+		hasPassword  bool
+		want         error
+	}{
+		{"unverified provider email", false, true, true, ErrIdentityEmailNotVerified},
+		{"verified account", true, true, true, nil},
+		{"unverified account with password", true, false, true, ErrIdentityAccountNotVerified},
+		{"unverified account without password", true, false, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity := lib.OIDCIdentity{Email: "dina@example.com", EmailVerified: tt.providerOK}
+			user := User{Email: "dina@example.com", EmailVerified: tt.userVerified}
+
+			if err := canLinkIdentity(identity, user, tt.hasPassword); !errors.Is(err, tt.want) {
+				t.Fatalf("canLinkIdentity() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type MigrationStatus struct {
+	Version int64 `json:"version"`
+}
+
+// GetMigrationStatus reads the latest version recorded by cmd/migrations,
+// nil when the database was never migrated with version tracking
+func GetMigrationStatus(ctx context.Context, db DB) (*MigrationStatus, error) {
+	var version *int64
+	err := db.QueryRow(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "42P01" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if version == nil {
+		return nil, nil
+	}
+
+	return &MigrationStatus{Version: *version}, nil
+}
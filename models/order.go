@@ -1,19 +1,22 @@
 package models
 
 import (
-	"backend/config"
+	"backend/apperr"
+	"backend/lib"
 	"context"
 	"fmt"
 	"time"
 )
 
+var ErrCartEmpty = apperr.Validation("cart is empty")
+
 type CreateOrderRequest struct {
-	PaymentID       int64  `json:"payment_id"`
-	ShippingID      int64  `json:"shipping_id"`
-	MethodID        int64  `json:"method_id"`
-	CustomerName    string `json:"customer_name"`
-	CustomerPhone   string `json:"customer_phone"`
-	CustomerAddress string `json:"customer_address"`
+	PaymentID       int64  `json:"payment_id" binding:"required,id"`
+	ShippingID      int64  `json:"shipping_id" binding:"omitempty,id"`
+	MethodID        int64  `json:"method_id" binding:"required,id"`
+	CustomerName    string `json:"customer_name" binding:"max=150"`
+	CustomerPhone   string `json:"customer_phone" binding:"omitempty,phone"`
+	CustomerAddress string `json:"customer_address" binding:"max=255"`
 }
 
 type OrderDetail struct {
@@ -54,11 +57,9 @@ type OrderResponse struct {
     Status          string  `json:"status"`
 }
 
-func CreateOrder(userID int64, req CreateOrderRequest) (OrderResponse, error) {
-    ctx := context.Background()
-
+func CreateOrder(ctx context.Context, db DB, userID int64, req CreateOrderRequest) (OrderResponse, error) {
     var cartItemCount int
-    err := config.Db.QueryRow(ctx, `
+    err := db.QueryRow(ctx, `
         SELECT COUNT(ci.id)
         FROM cart_items ci
         JOIN cart c ON c.id = ci.cart_id
@@ -69,11 +70,11 @@ func CreateOrder(userID int64, req CreateOrderRequest) (OrderResponse, error) {
     }
 	
     if cartItemCount == 0 {
-        return OrderResponse{}, fmt.Errorf("cart is empty")
+        return OrderResponse{}, ErrCartEmpty
     }
 
     var cartTotal float64
-    err = config.Db.QueryRow(ctx, `
+    err = db.QueryRow(ctx, `
         SELECT COALESCE(SUM(
             CASE 
                 WHEN d.id IS NOT NULL 
@@ -96,7 +97,7 @@ func CreateOrder(userID int64, req CreateOrderRequest) (OrderResponse, error) {
 
     tax := cartTotal * 0.10
 
-    tx, err := config.Db.Begin(ctx)
+    tx, err := db.Begin(ctx)
     if err != nil {
         return OrderResponse{}, fmt.Errorf("transaction start error: %w", err)
     }
@@ -244,55 +245,52 @@ func CreateOrder(userID int64, req CreateOrderRequest) (OrderResponse, error) {
     }, nil
 }
 
-func GetOrderHistoryByUserID(userID int64, month, shippingID, page, limit int) ([]map[string]interface{}, int, error) {
-	ctx := context.Background()
-
-	offset := (page - 1) * limit
-	query := `
-	SELECT 
-		o.id AS order_id,
-		o.invoice,
-		o.order_date,
-		COALESCE(o.total, 0) AS total,
-		s.name AS shipping_status,
-		COALESCE(MIN(pi.image), '') AS image
-	FROM orders o
-	JOIN shippings s ON s.id = o.shipping_id
-	JOIN order_items oi ON oi.order_id = o.id
-	JOIN products p ON p.id = oi.product_id
-	LEFT JOIN product_img pi ON pi.product_id = p.id
-	WHERE o.users_id = $1
-	`
-
+// orderHistoryFilter returns the WHERE conditions of the order history, by
+// default the latest month with orders and status "On Progress"
+func orderHistoryFilter(userID int64, month, shippingID int) (string, []interface{}) {
+	where := " AND EXTRACT(MONTH FROM o.order_date) = (SELECT EXTRACT(MONTH FROM MAX(order_date)) FROM orders WHERE users_id = $1)"
 	args := []interface{}{userID}
 	argIndex := 2
 
 	if month > 0 {
-		query += fmt.Sprintf(" AND EXTRACT(MONTH FROM o.order_date) = $%d", argIndex)
+		where = fmt.Sprintf(" AND EXTRACT(MONTH FROM o.order_date) = $%d", argIndex)
 		args = append(args, month)
 		argIndex++
-	} else {
-		query += " AND EXTRACT(MONTH FROM o.order_date) = (SELECT EXTRACT(MONTH FROM MAX(order_date)) FROM orders WHERE users_id = $1)"
 	}
 
 	if shippingID == 0 {
 		shippingID = 3
 	}
-	query += fmt.Sprintf(" AND o.shipping_id = $%d", argIndex)
+	where += fmt.Sprintf(" AND o.shipping_id = $%d", argIndex)
 	args = append(args, shippingID)
-	argIndex++
 
-	query += `
+	return where, args
+}
+
+func orderHistoryQuery(where, orderBy, limit string) string {
+	return `
+	SELECT 
+		o.id AS order_id,
+		o.invoice,
+		o.order_date,
+		COALESCE(o.total, 0) AS total,
+		s.name AS shipping_status,
+		COALESCE(MIN(pi.image), '') AS image
+	FROM orders o
+	JOIN shippings s ON s.id = o.shipping_id
+	JOIN order_items oi ON oi.order_id = o.id
+	JOIN products p ON p.id = oi.product_id
+	LEFT JOIN product_img pi ON pi.product_id = p.id
+	WHERE o.users_id = $1` + where + `
 	GROUP BY o.id, o.invoice, o.order_date, o.total, s.name
-	ORDER BY o.order_date DESC
-	LIMIT $%d OFFSET $%d
-	`
-	query = fmt.Sprintf(query, argIndex, argIndex+1)
-	args = append(args, limit, offset)
+	ORDER BY ` + orderBy + `
+	` + limit
+}
 
-	rows, err := config.Db.Query(ctx, query, args...)
+func scanOrderHistory(ctx context.Context, db DB, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -309,7 +307,7 @@ func GetOrderHistoryByUserID(userID int64, month, shippingID, page, limit int) (
 		)
 
 		if err := rows.Scan(&orderID, &invoice, &orderDate, &total, &status, &image); err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 
 		history = append(history, map[string]interface{}{
@@ -322,27 +320,29 @@ func GetOrderHistoryByUserID(userID int64, month, shippingID, page, limit int) (
 		})
 	}
 
+	return history, rows.Err()
+}
+
+func GetOrderHistoryByUserID(ctx context.Context, db DB, userID int64, month, shippingID, page, limit int) ([]map[string]interface{}, int, error) {
+	offset := (page - 1) * limit
+	where, args := orderHistoryFilter(userID, month, shippingID)
+	argIndex := len(args) + 1
+
+	query := orderHistoryQuery(where, "o.order_date DESC", fmt.Sprintf("LIMIT $%d OFFSET $%d", argIndex, argIndex+1))
+
+	history, err := scanOrderHistory(ctx, db, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	countQuery := `
 		SELECT COUNT(DISTINCT o.id)
 		FROM orders o
 		WHERE o.users_id = $1
-	`
-	countArgs := []interface{}{userID}
-
-	argIndex2 := 2
-	if month > 0 {
-		countQuery += fmt.Sprintf(" AND EXTRACT(MONTH FROM o.order_date) = $%d", argIndex2)
-		countArgs = append(countArgs, month)
-		argIndex2++
-	} else {
-		countQuery += " AND EXTRACT(MONTH FROM o.order_date) = (SELECT EXTRACT(MONTH FROM MAX(order_date)) FROM orders WHERE users_id = $1)"
-	}
-
-	countQuery += fmt.Sprintf(" AND o.shipping_id = $%d", argIndex2)
-	countArgs = append(countArgs, shippingID)
+	` + where
 
 	var totalItems int
-	err = config.Db.QueryRow(ctx, countQuery, countArgs...).Scan(&totalItems)
+	err = db.QueryRow(ctx, countQuery, args...).Scan(&totalItems)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -350,11 +350,58 @@ func GetOrderHistoryByUserID(userID int64, month, shippingID, page, limit int) (
 	return history, totalItems, nil
 }
 
-func GetOrderDetail(orderID int64) (*OrderDetail, error) {
-	ctx := context.Background()
+func GetOrderHistoryCursor(ctx context.Context, db DB, userID int64, month, shippingID int, cursor *lib.Cursor, limit int) ([]map[string]interface{}, string, string, error) {
+	if limit < 1 {
+		limit = 4
+	}
+	if cursor != nil && cursor.Sort != "" {
+		return nil, "", "", lib.ErrInvalidCursor
+	}
+
+	key := keyset{Expr: "o.order_date", Cast: "timestamp", ID: "o.id", Desc: true}
+	where, args := orderHistoryFilter(userID, month, shippingID)
+	if cursor != nil {
+		cond, after := key.after(cursor, len(args)+1)
+		where += " AND " + cond
+		args = append(args, after...)
+	}
+
+	query := orderHistoryQuery(where, key.orderBy(cursor != nil && cursor.Backward), fmt.Sprintf("LIMIT %d", limit+1))
+
+	history, err := scanOrderHistory(ctx, db, query, args...)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	history, next, prev := cursorPage(history, cursor, limit, "", func(h map[string]interface{}) (string, int64) {
+		return timeKey(h["order_date"].(time.Time)), h["order_id"].(int64)
+	})
+	return history, next, prev, nil
+}
+
+// OrderContact is the profile data an order falls back to when the request
+// leaves the customer fields empty
+type OrderContact struct {
+	Name    string
+	Phone   string
+	Address string
+}
+
+func GetOrderContact(ctx context.Context, db DB, userID int64) (OrderContact, error) {
+	var contact OrderContact
+	err := db.QueryRow(ctx, `
+		SELECT COALESCE(username, ''), COALESCE(phone, ''), COALESCE(address, '')
+		FROM profile
+		WHERE users_id = $1
+	`, userID).Scan(&contact.Name, &contact.Phone, &contact.Address)
+
+	return contact, err
+}
+
+func GetOrderDetail(ctx context.Context, db DB, orderID int64) (*OrderDetail, error) {
 	order := OrderDetail{}
 
-	err := config.Db.QueryRow(ctx, `
+	err := db.QueryRow(ctx, `
 		SELECT 
 		o.id,
 		o.invoice,
@@ -388,7 +435,7 @@ func GetOrderDetail(orderID int64) (*OrderDetail, error) {
 		return nil, err
 	}
 
-	rows, err := config.Db.Query(ctx, `
+	rows, err := db.Query(ctx, `
 	SELECT 
 		pr.name,
 		COALESCE(v.name, '-') AS variant,
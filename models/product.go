@@ -2,14 +2,18 @@ package models
 
 import (
 	"backend/config"
+	"backend/lib"
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
 type ProductSize struct {
-	SizeID   int64   `json:"size_id" binding:"required,gt=0"`
+	SizeID   int64   `json:"size_id" binding:"required,id"`
 	SizeName string  `json:"size_name"`
 	Price    float64 `json:"price" binding:"required,gte=0"`
 }
@@ -23,6 +27,8 @@ type ProductAdmin struct {
 	Sizes       string `json:"sizes"`
 	Method      string `json:"method"`
 	Stock       int64  `json:"stock"`
+	Translations map[string]ProductTranslation `json:"translations"`
+	CreatedAt   time.Time `json:"-"`
 }
 
 type Variant struct {
@@ -40,6 +46,12 @@ type Product struct {
 	Images      []string      `json:"images"`
 	Sizes       []ProductSize `json:"sizes"`
 	Variants    []Variant      `json:"variants"`
+	IsFavorited bool          `json:"is_favorited"`
+	AverageRating float64     `json:"average_rating"`
+	ReviewCount int64         `json:"review_count"`
+	Snippet     string        `json:"snippet,omitempty"`
+	Relevance   float64       `json:"-"`
+	Sold        int64         `json:"sold,omitempty"`
 	CreatedAt   time.Time     `json:"created_at"`
 	UpdatedAt   time.Time     `json:"updated_at"`
 }
@@ -48,27 +60,18 @@ type CreateProductRequest struct {
 	Name        string        `json:"name" binding:"required,min=1,max=200"`
 	Description string        `json:"description"`
 	Stock       int           `json:"stock" binding:"required,gte=0"`
-	CategoryID  int           `json:"category_id"`
+	CategoryID  int           `json:"category_id" binding:"omitempty,id"`
 	Images      []string      `json:"images"`
-	Variants    []int         `json:"variants"`
-	Sizes       []ProductSize `json:"sizes"`
+	Variants    []int         `json:"variants" binding:"dive,id"`
+	Sizes       []ProductSize `json:"sizes" binding:"dive"`
 	Price int `json:"base_price"`
+	// Translations per locale ("id"), left out on update keeps the saved ones
+	Translations map[string]ProductTranslation `json:"translations" binding:"omitempty,dive,keys,locale,endkeys"`
 }
 
 // admin version
-func GetProductsAdmin(page, limit int, search string) ([]ProductAdmin, int64, error) {
-	ctx := context.Background()
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 {
-		limit = 5
-	}
-
-	offset := (page - 1) * limit
-
-	query := `
+func productsAdminQuery(where, orderBy, limit string) string {
+	return `
 	SELECT
     p.id,
     COALESCE(pi.image, '') AS image,
@@ -77,7 +80,13 @@ func GetProductsAdmin(page, limit int, search string) ([]ProductAdmin, int64, er
     COALESCE(p.price, 0) AS price,
     COALESCE(string_agg(DISTINCT s.name, ', '), '') AS sizes,
     COALESCE(string_agg(DISTINCT m.name, ', '), '') AS methods,
-    p.stock
+    p.stock,
+    COALESCE((
+        SELECT json_object_agg(locale, json_build_object('name', name, 'description', COALESCE(description, '')))
+        FROM product_translations
+        WHERE product_id = p.id
+    ), '{}') AS translations,
+    p.created_at
 FROM products p
 LEFT JOIN (
     SELECT DISTINCT ON (product_id) product_id, image
@@ -88,16 +97,16 @@ LEFT JOIN product_size ps ON ps.product_id = p.id
 LEFT JOIN size s ON s.id = ps.size_id
 LEFT JOIN product_method pm ON pm.product_id = p.id
 LEFT JOIN method m ON m.id = pm.method_id
-WHERE p.name ILIKE '%' || $1 || '%'
+` + where + `
 GROUP BY p.id, pi.image, p.name, p.description, p.stock
-ORDER BY p.created_at DESC
-LIMIT $2 OFFSET $3`
-
-
+ORDER BY ` + orderBy + `
+` + limit
+}
 
-	rows, err := config.Db.Query(ctx, query, search, limit, offset)
+func scanProductsAdmin(ctx context.Context, db DB, query string, args ...any) ([]ProductAdmin, error) {
+	rows, err := db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -105,15 +114,41 @@ LIMIT $2 OFFSET $3`
 
 	for rows.Next() {
 		var p ProductAdmin
-		err := rows.Scan(&p.ID, &p.Image, &p.Name, &p.Description, &p.Price, &p.Sizes, &p.Method, &p.Stock)
+		var translationsJSON []byte
+		err := rows.Scan(&p.ID, &p.Image, &p.Name, &p.Description, &p.Price, &p.Sizes, &p.Method, &p.Stock, &translationsJSON, &p.CreatedAt)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
+		_ = json.Unmarshal(translationsJSON, &p.Translations)
 		products = append(products, p)
 	}
 
+	return products, rows.Err()
+}
+
+func GetProductsAdmin(ctx context.Context, db DB, page, limit int, search string) ([]ProductAdmin, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 5
+	}
+
+	offset := (page - 1) * limit
+
+	query := productsAdminQuery(
+		`WHERE p.name ILIKE '%' || $1 || '%'`,
+		"p.created_at DESC",
+		"LIMIT $2 OFFSET $3",
+	)
+
+	products, err := scanProductsAdmin(ctx, db, query, search, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	var total int64
-	err = config.Db.QueryRow(ctx, `SELECT COUNT(*) FROM products WHERE name ILIKE '%' || $1 || '%'`, search).Scan(&total)
+	err = db.QueryRow(ctx, `SELECT COUNT(*) FROM products WHERE name ILIKE '%' || $1 || '%'`, search).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -121,43 +156,207 @@ LIMIT $2 OFFSET $3`
 	return products, total, nil
 }
 
-// user version
-func GetProducts(page, limit int, search, sort string, minPrice int, maxPrice int, categoryIDs []int) ([]Product, int64, error) {
-	ctx := context.Background()
+func GetProductsAdminCursor(ctx context.Context, db DB, cursor *lib.Cursor, limit int, search string) ([]ProductAdmin, string, string, error) {
+	if limit < 1 {
+		limit = 5
+	}
+	if cursor != nil && cursor.Sort != "" {
+		return nil, "", "", lib.ErrInvalidCursor
+	}
 
-	if page < 1 {
-		page = 1
+	key := keyset{Expr: "p.created_at", Cast: "timestamp", ID: "p.id", Desc: true}
+	where := `WHERE p.name ILIKE '%' || $1 || '%'`
+	args := []any{search}
+	if cursor != nil {
+		cond, after := key.after(cursor, 2)
+		where += " AND " + cond
+		args = append(args, after...)
 	}
-	if limit < 1 {
-		limit = 10
+
+	query := productsAdminQuery(where, key.orderBy(cursor != nil && cursor.Backward), fmt.Sprintf("LIMIT %d", limit+1))
+
+	products, err := scanProductsAdmin(ctx, db, query, args...)
+	if err != nil {
+		return nil, "", "", err
 	}
-	offset := (page - 1) * limit
 
-	orderByClause := "p.created_at DESC"
+	products, next, prev := cursorPage(products, cursor, limit, "", func(p ProductAdmin) (string, int64) {
+		return timeKey(p.CreatedAt), p.ID
+	})
+	return products, next, prev, nil
+}
+
+const minPriceExpr = `COALESCE(
+		(SELECT MIN(price) FROM product_size WHERE product_id = p.id),
+		p.price
+	  )`
+
+// productKeyset is the cursor mode sort key of each GetProducts sort option
+func productKeyset(sort string) keyset {
 	switch sort {
 	case "oldest":
-		orderByClause = "p.created_at ASC"
-	case "price_low":
-		orderByClause = "min_price ASC"
-	case "price_high":
-		orderByClause = "min_price DESC"
-	case "name_asc":
-		orderByClause = "p.name ASC"
-	case "name_desc":
-		orderByClause = "p.name DESC"
+		return keyset{Expr: "p.created_at", Cast: "timestamp", ID: "p.id"}
+	case "price_low", "price_high":
+		return keyset{Expr: minPriceExpr, Cast: "numeric", ID: "p.id", Desc: sort == "price_high"}
+	case "name_asc", "name_desc":
+		return keyset{Expr: "COALESCE(pt.name, p.name, '')", Cast: "text", ID: "p.id", Desc: sort == "name_desc"}
+	case "rating_high":
+		return keyset{Expr: averageRatingExpr, Cast: "numeric", ID: "p.id", Desc: true}
+	case "relevance":
+		return keyset{Expr: relevanceExpr("$1", "$2"), Cast: "float8", ID: "p.id", Desc: true}
+	case "best_selling":
+		return keyset{Expr: soldExpr(config.BestSellerDays()), Cast: "bigint", ID: "p.id", Desc: true}
 	}
+	return keyset{Expr: "p.created_at", Cast: "timestamp", ID: "p.id", Desc: true}
+}
 
-	query := `
+func productKey(sort string) func(Product) (string, int64) {
+	return func(p Product) (string, int64) {
+		switch sort {
+		case "price_low", "price_high":
+			return floatKey(p.MinPrice), p.ID
+		case "name_asc", "name_desc":
+			return p.Name, p.ID
+		case "rating_high":
+			return floatKey(p.AverageRating), p.ID
+		case "relevance":
+			return floatKey(p.Relevance), p.ID
+		case "best_selling":
+			return strconv.FormatInt(p.Sold, 10), p.ID
+		}
+		return timeKey(p.CreatedAt), p.ID
+	}
+}
+
+// ProductFilter holds the /products filters, the same filter builds the
+// list, count and facet queries
+type ProductFilter struct {
+	Search      string
+	MinPrice    int
+	MaxPrice    int
+	CategoryIDs []int
+	VariantIDs  []int
+	SizeIDs     []int
+	MethodIDs   []int
+	InStock     bool
+	OnSale      bool
+
+	tsq string
+}
+
+// normalize drops a search without any searchable word
+func (f ProductFilter) normalize() ProductFilter {
+	f.tsq = searchTSQuery(f.Search)
+	if f.tsq == "" {
+		f.Search = ""
+	}
+	return f
+}
+
+// onSaleCondition is true while the product has an active discount
+const onSaleCondition = `EXISTS (
+	SELECT 1 FROM product_discount pd
+	JOIN discount d ON d.id = pd.discount_id
+	WHERE pd.product_id = p.id AND d.is_active AND NOW() BETWEEN d.start_discount AND d.end_discount
+)`
+
+// where returns the WHERE and HAVING parts with args, search always takes $1 and $2
+func (f ProductFilter) where(extra func(argIndex int) (string, []any)) (string, string, []any) {
+	where := "WHERE 1 = 1"
+	args := []interface{}{}
+	if f.Search != "" {
+		where = "WHERE " + searchCondition("$1", "$2")
+		args = append(args, f.Search, f.tsq)
+	}
+	argIndex := len(args) + 1
+
+	if len(f.CategoryIDs) > 0 {
+		where += fmt.Sprintf(" AND p.category_id = ANY($%d)", argIndex)
+		args = append(args, f.CategoryIDs)
+		argIndex++
+	}
+
+	// a product matches when it has any of the selected options
+	for _, join := range []struct {
+		table  string
+		column string
+		ids    []int
+	}{
+		{"product_variant", "variant_id", f.VariantIDs},
+		{"product_size", "size_id", f.SizeIDs},
+		{"product_method", "method_id", f.MethodIDs},
+	} {
+		if len(join.ids) == 0 {
+			continue
+		}
+		where += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM %s f WHERE f.product_id = p.id AND f.%s = ANY($%d))", join.table, join.column, argIndex)
+		args = append(args, join.ids)
+		argIndex++
+	}
+
+	if f.InStock {
+		where += " AND p.stock > 0"
+	}
+	if f.OnSale {
+		where += " AND " + onSaleCondition
+	}
+
+	if extra != nil {
+		cond, extraArgs := extra(argIndex)
+		where += " AND " + cond
+		args = append(args, extraArgs...)
+		argIndex += len(extraArgs)
+	}
+
+	having := "HAVING 1 = 1"
+	if f.MinPrice != 0 {
+		having += fmt.Sprintf(" AND "+minPriceExpr+" >= $%d", argIndex)
+		args = append(args, f.MinPrice)
+		argIndex++
+	}
+	if f.MaxPrice != 0 {
+		having += fmt.Sprintf(" AND "+minPriceExpr+" <= $%d", argIndex)
+		args = append(args, f.MaxPrice)
+		argIndex++
+	}
+
+	return where, having, args
+}
+
+// matchedQuery selects the ids and min_price (minPriceExpr, the price the
+// list shows and the price filter uses) of the products passing the filter
+func (f ProductFilter) matchedQuery() (string, []any) {
+	where, having, args := f.where(nil)
+	return `
+		SELECT p.id, ` + minPriceExpr + ` AS price
+		FROM products p
+		LEFT JOIN categories c ON p.category_id = c.id
+		` + where + `
+		GROUP BY p.id
+		` + having, args
+}
+
+// selectQuery is the product list query. The sold subquery sums order items
+// per product, it only runs when sort is best_selling.
+func (f ProductFilter) selectQuery(ctx context.Context, sort, where, having, orderBy, limit string) string {
+	selectSearch := "0::float8 AS relevance,\n\t'' AS snippet,"
+	if f.Search != "" {
+		selectSearch = searchColumns("$1", "$2")
+	}
+
+	sold := "0::bigint"
+	if sort == "best_selling" {
+		sold = soldExpr(config.BestSellerDays())
+	}
+
+	return `
 SELECT
 	p.id,
-	p.name,
-	p.description,
-	COALESCE(
-		(SELECT MIN(price) FROM product_size WHERE product_id = p.id),
-		p.price
-	  ) AS min_price,
+	` + productNameExpr + ` AS name,
+	` + productDescriptionExpr + ` AS description,
+	` + minPriceExpr + ` AS min_price,
 	p.stock,
-	COALESCE(c.name, '') AS category,
+	` + categoryNameExpr + ` AS category,
 	COALESCE(json_agg(DISTINCT pi.image) FILTER (WHERE pi.image IS NOT NULL), '[]') AS images,
 	COALESCE(json_agg(DISTINCT v.name) FILTER (WHERE v.name IS NOT NULL), '[]') AS variants,
 	COALESCE(
@@ -168,6 +367,9 @@ SELECT
 		)) FILTER (WHERE s.id IS NOT NULL),
 		'[]'
 	) AS sizes,
+	` + ratingColumns + `
+	` + selectSearch + `
+	` + sold + ` AS sold,
 	p.created_at,
 	p.updated_at
 FROM products p
@@ -177,39 +379,18 @@ LEFT JOIN product_variant pv ON pv.product_id = p.id
 LEFT JOIN variant v ON v.id = pv.variant_id
 LEFT JOIN product_size ps ON ps.product_id = p.id
 LEFT JOIN size s ON s.id = ps.size_id
-WHERE (p.name ILIKE '%' || $1 || '%' OR c.name ILIKE '%' || $1 || '%')
-`
-
-	args := []interface{}{search}
-	argIndex := 2
-
-	if len(categoryIDs) > 0 {
-		query += fmt.Sprintf(" AND p.category_id = ANY($%d)", argIndex)
-		args = append(args, categoryIDs)
-		argIndex++
-	}
-
-	query += `
-GROUP BY p.id, c.name
-HAVING 1 = 1
-`
-
-	if minPrice != 0 {
-		query += fmt.Sprintf(" AND COALESCE(MIN(ps.price), 0) >= $%d", argIndex)
-		args = append(args, minPrice)
-		argIndex++
-	}
-	if maxPrice != 0 {
-		query += fmt.Sprintf(" AND COALESCE(MIN(ps.price), 0) <= $%d", argIndex)
-		args = append(args, maxPrice)
-		argIndex++
-	}
-
-	query += fmt.Sprintf(" ORDER BY %s LIMIT %d OFFSET %d", orderByClause, limit, offset)
+` + translationJoins(ctx) + `
+` + where + `
+GROUP BY p.id, c.name, pt.name, pt.description, ct.name
+` + having + `
+ORDER BY ` + orderBy + `
+` + limit
+}
 
-	rows, err := config.Db.Query(ctx, query, args...)
+func scanProducts(ctx context.Context, db DB, query string, args []any) ([]Product, error) {
+	rows, err := db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -221,10 +402,13 @@ HAVING 1 = 1
 		err := rows.Scan(
 			&p.ID, &p.Name, &p.Description, &p.MinPrice, &p.Stock,
 			&p.Category, &imagesJSON, &variantsJSON, &sizesJSON,
+			&p.AverageRating, &p.ReviewCount,
+			&p.Relevance, &p.Snippet,
+			&p.Sold,
 			&p.CreatedAt, &p.UpdatedAt,
 		)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 
 		json.Unmarshal(imagesJSON, &p.Images)
@@ -234,42 +418,67 @@ HAVING 1 = 1
 		products = append(products, p)
 	}
 
-	countQuery := `
-	SELECT COUNT(*)
-	FROM (
-		SELECT p.id
-		FROM products p
-		LEFT JOIN categories c ON p.category_id = c.id
-		LEFT JOIN product_size ps ON ps.product_id = p.id
-		WHERE (p.name ILIKE '%' || $1 || '%' OR c.name ILIKE '%' || $1 || '%')
-	`
-	countArgs := []interface{}{search}
-	countIndex := 2
+	return products, rows.Err()
+}
 
-	if len(categoryIDs) > 0 {
-		countQuery += fmt.Sprintf(" AND p.category_id = ANY($%d)", countIndex)
-		countArgs = append(countArgs, categoryIDs)
-		countIndex++
+// productSort drops relevance when nothing is searched and defaults to it when
+// searching without an explicit sort
+func productSort(f ProductFilter, sort string) string {
+	if f.Search == "" && sort == "relevance" {
+		return ""
 	}
+	if f.Search != "" && sort == "" {
+		return "relevance"
+	}
+	return sort
+}
+
+// user version
+func GetProducts(ctx context.Context, db DB, page, limit int, sort string, filter ProductFilter) ([]Product, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
 
-	countQuery += " GROUP BY p.id HAVING 1 = 1"
+	filter = filter.normalize()
+	sort = productSort(filter, sort)
 
-	if minPrice != 0 {
-		countQuery += fmt.Sprintf(" AND COALESCE(MIN(ps.price), 0) >= $%d", countIndex)
-		countArgs = append(countArgs, minPrice)
-		countIndex++
+	orderByClause := "p.created_at DESC"
+	switch sort {
+	case "oldest":
+		orderByClause = "p.created_at ASC"
+	case "price_low":
+		orderByClause = "min_price ASC"
+	case "price_high":
+		orderByClause = "min_price DESC"
+	case "name_asc":
+		orderByClause = productNameExpr + " ASC"
+	case "name_desc":
+		orderByClause = productNameExpr + " DESC"
+	case "rating_high":
+		orderByClause = "average_rating DESC, review_count DESC"
+	case "relevance":
+		orderByClause = "relevance DESC, p.created_at DESC"
+	case "best_selling":
+		orderByClause = "sold DESC, p.created_at DESC"
 	}
 
-	if maxPrice != 0 {
-		countQuery += fmt.Sprintf(" AND COALESCE(MIN(ps.price), 0) <= $%d", countIndex)
-		countArgs = append(countArgs, maxPrice)
-		countIndex++
+	where, having, args := filter.where(nil)
+	query := filter.selectQuery(ctx, sort, where, having, orderByClause, fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset))
+
+	products, err := scanProducts(ctx, db, query, args)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	countQuery += `) AS sub`
+	matched, args := filter.matchedQuery()
+	countQuery := `SELECT COUNT(*) FROM (` + matched + `) AS sub`
 
 	var total int64
-	err = config.Db.QueryRow(ctx, countQuery, countArgs...).Scan(&total)
+	err = db.QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -277,20 +486,50 @@ HAVING 1 = 1
 	return products, total, nil
 }
 
-func GetProductByID(productID int64) (*Product, error) {
-	ctx := context.Background()
+// GetProductsCursor is GetProducts with keyset pagination, it returns the
+// next and previous cursors instead of a total
+func GetProductsCursor(ctx context.Context, db DB, cursor *lib.Cursor, limit int, sort string, filter ProductFilter) ([]Product, string, string, error) {
+	if limit < 1 {
+		limit = 10
+	}
 
+	filter = filter.normalize()
+	sort = productSort(filter, sort)
+	if cursor != nil && cursor.Sort != sort {
+		return nil, "", "", lib.ErrInvalidCursor
+	}
+
+	key := productKeyset(sort)
+	var after func(int) (string, []any)
+	if cursor != nil {
+		after = func(argIndex int) (string, []any) { return key.after(cursor, argIndex) }
+	}
+
+	where, having, args := filter.where(after)
+	backward := cursor != nil && cursor.Backward
+	query := filter.selectQuery(ctx, sort, where, having, key.orderBy(backward), fmt.Sprintf("LIMIT %d", limit+1))
+
+	products, err := scanProducts(ctx, db, query, args)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	products, next, prev := cursorPage(products, cursor, limit, sort, productKey(sort))
+	return products, next, prev, nil
+}
+
+func GetProductByID(ctx context.Context, db DB, productID int64) (*Product, error) {
 	query := `
 SELECT
   p.id,
-  p.name,
-  p.description,
+  ` + productNameExpr + ` AS name,
+  ` + productDescriptionExpr + ` AS description,
   COALESCE(
     (SELECT MIN(price) FROM product_size WHERE product_id = p.id),
     p.price
   ) AS min_price,
   p.stock,
-  COALESCE(c.name, '') AS category,
+  ` + categoryNameExpr + ` AS category,
 
   COALESCE(
     json_agg(DISTINCT pi.image) FILTER (WHERE pi.image IS NOT NULL),
@@ -314,6 +553,7 @@ SELECT
     '[]'
   ) AS sizes,
 
+  ` + ratingColumns + `
   p.created_at,
   p.updated_at
 
@@ -324,19 +564,21 @@ LEFT JOIN product_variant pv ON pv.product_id = p.id
 LEFT JOIN variant v ON v.id = pv.variant_id
 LEFT JOIN product_size ps ON ps.product_id = p.id
 LEFT JOIN size s ON s.id = ps.size_id
+` + translationJoins(ctx) + `
 
 WHERE p.id = $1
 
-GROUP BY p.id, c.name
+GROUP BY p.id, c.name, pt.name, pt.description, ct.name
 LIMIT 1
 `
 
 	var p Product
 	var imagesJSON, variantsJSON, sizesJSON []byte
 
-	err := config.Db.QueryRow(ctx, query, productID).Scan(
+	err := db.QueryRow(ctx, query, productID).Scan(
 		&p.ID, &p.Name, &p.Description, &p.MinPrice, &p.Stock,
 		&p.Category, &imagesJSON, &variantsJSON, &sizesJSON,
+		&p.AverageRating, &p.ReviewCount,
 		&p.CreatedAt, &p.UpdatedAt,
 	)
 	if err != nil {
@@ -349,11 +591,15 @@ LIMIT 1
 
 	return &p, nil
 }
-func CreateProduct(req CreateProductRequest) (*Product, error) {
-	ctx := context.Background()
+func CreateProduct(ctx context.Context, db DB, req CreateProductRequest) (*Product, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
 
 	var productID int64
-	err := config.Db.QueryRow(ctx,
+	err = tx.QueryRow(ctx,
 		`INSERT INTO products (name, description, stock, category_id, price)
 		 VALUES ($1,$2,$3,$4,$5)
 		 RETURNING id`,
@@ -363,28 +609,27 @@ func CreateProduct(req CreateProductRequest) (*Product, error) {
 		return nil, err
 	}
 
-	for _, img := range req.Images {
-		_, _ = config.Db.Exec(ctx, `INSERT INTO product_img (image, product_id) VALUES ($1,$2)`,
-			img, productID)
+	if err := saveProductOptions(ctx, tx, productID, req); err != nil {
+		return nil, err
 	}
-
-	for _, v := range req.Variants {
-		_, _ = config.Db.Exec(ctx, `INSERT INTO product_variant (variant_id, product_id) VALUES ($1,$2)`,
-			v, productID)
+	if err := saveProductTranslations(ctx, tx, productID, req.Translations); err != nil {
+		return nil, err
 	}
-
-	for _, s := range req.Sizes {
-		_, _ = config.Db.Exec(ctx, `INSERT INTO product_size (product_id, size_id, price) VALUES ($1,$2,$3)`,
-			productID, s.SizeID, s.Price)
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
 	}
 
-	return GetProductByID(productID)
+	return GetProductByID(ctx, db, productID)
 }
 
-func UpdateProduct(id int64, req CreateProductRequest) (*Product, error) {
-	ctx := context.Background()
+func UpdateProduct(ctx context.Context, db DB, id int64, req CreateProductRequest) (*Product, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
 
-	_, err := config.Db.Exec(ctx,
+	tag, err := tx.Exec(ctx,
 		`UPDATE products SET name=$1, description=$2, stock=$3, category_id=$4, updated_at=now(), price=$5
 		 WHERE id=$6`,
 		req.Name, req.Description, req.Stock, req.CategoryID,req.Price, id,
@@ -392,58 +637,81 @@ func UpdateProduct(id int64, req CreateProductRequest) (*Product, error) {
 	if err != nil {
 		return nil, err
 	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrProductNotFound
+	}
+
+	for _, table := range []string{"product_img", "product_variant", "product_size"} {
+		if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE product_id=$1`, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := saveProductOptions(ctx, tx, id, req); err != nil {
+		return nil, err
+	}
+	if err := saveProductTranslations(ctx, tx, id, req.Translations); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
 
-	_, _ = config.Db.Exec(ctx, `DELETE FROM product_img WHERE product_id=$1`, id)
+	return GetProductByID(ctx, db, id)
+}
+
+// saveProductOptions inserts the images, variants and sizes of a product.
+// In the tx a failed insert fails the whole write instead of being skipped.
+func saveProductOptions(ctx context.Context, tx pgx.Tx, productID int64, req CreateProductRequest) error {
 	for _, img := range req.Images {
-		_, _ = config.Db.Exec(ctx, `INSERT INTO product_img (image, product_id) VALUES ($1,$2)`, img, id)
+		if _, err := tx.Exec(ctx, `INSERT INTO product_img (image, product_id) VALUES ($1,$2)`, img, productID); err != nil {
+			return err
+		}
 	}
 
-	_, _ = config.Db.Exec(ctx, `DELETE FROM product_variant WHERE product_id=$1`, id)
 	for _, v := range req.Variants {
-		_, _ = config.Db.Exec(ctx, `INSERT INTO product_variant (variant_id, product_id) VALUES ($1,$2)`, v, id)
+		if _, err := tx.Exec(ctx, `INSERT INTO product_variant (variant_id, product_id) VALUES ($1,$2)`, v, productID); err != nil {
+			return err
+		}
 	}
 
-	_, _ = config.Db.Exec(ctx, `DELETE FROM product_size WHERE product_id=$1`, id)
 	for _, s := range req.Sizes {
-		_, _ = config.Db.Exec(ctx,
+		_, err := tx.Exec(ctx,
 			`INSERT INTO product_size (product_id, size_id, price) VALUES ($1,$2,$3)`,
-			id, s.SizeID, s.Price,
+			productID, s.SizeID, s.Price,
 		)
+		if err != nil {
+			return err
+		}
 	}
-
-	return GetProductByID(id)
+	return nil
 }
-func DeleteProduct(id int64) error {
-    ctx := context.Background()
 
-    _, err := config.Db.Exec(ctx, `DELETE FROM products WHERE id=$1`, id)
+func DeleteProduct(ctx context.Context, db DB, id int64) error {
+    _, err := db.Exec(ctx, `DELETE FROM products WHERE id=$1`, id)
     if err != nil {
         return err
     }
     return nil
 }
 
-func UploadImgProduct(productID int64, imagePath string) error {
-	ctx := context.Background()
-
-	_, err := config.Db.Exec(ctx,
+func UploadImgProduct(ctx context.Context, db DB, productID int64, imagePath string) error {
+	_, err := db.Exec(ctx,
 		`INSERT INTO product_img (image, product_id) VALUES ($1, $2)`,
 		imagePath, productID,
 	)
 	return err
 }
 
-func GetRecommendationsByCategory(category string, excludeProductID int64) ([]Product, error) {
-	ctx := context.Background()
-
+func GetRecommendationsByCategory(ctx context.Context, db DB, category string, excludeProductID int64) ([]Product, error) {
 	query := `
 SELECT
   p.id,
-  p.name,
-  p.description,
+  ` + productNameExpr + ` AS name,
+  ` + productDescriptionExpr + ` AS description,
   COALESCE(MIN(ps.price), 0) AS min_price,
   p.stock,
-  COALESCE(c.name, '') AS category,
+  ` + categoryNameExpr + ` AS category,
 
   COALESCE(
     json_agg(DISTINCT pi.image) FILTER (WHERE pi.image IS NOT NULL),
@@ -464,6 +732,7 @@ SELECT
     '[]'
   ) AS sizes,
 
+  ` + ratingColumns + `
   p.created_at,
   p.updated_at
 
@@ -474,14 +743,15 @@ LEFT JOIN product_variant pv ON pv.product_id = p.id
 LEFT JOIN variant v ON v.id = pv.variant_id
 LEFT JOIN product_size ps ON ps.product_id = p.id
 LEFT JOIN size s ON s.id = ps.size_id
+` + translationJoins(ctx) + `
 
-WHERE c.name = $1 AND p.id != $2
-GROUP BY p.id, c.name
+WHERE ` + categoryNameExpr + ` = $1 AND p.id != $2
+GROUP BY p.id, c.name, pt.name, pt.description, ct.name
 ORDER BY p.created_at DESC
 LIMIT 3
 `
 
-	rows, err := config.Db.Query(ctx, query, category, excludeProductID)
+	rows, err := db.Query(ctx, query, category, excludeProductID)
 	if err != nil {
 		return nil, err
 	}
@@ -496,6 +766,7 @@ LIMIT 3
 		err := rows.Scan(
 			&p.ID, &p.Name, &p.Description, &p.MinPrice, &p.Stock,
 			&p.Category, &imagesJSON, &variantsJSON, &sizesJSON,
+			&p.AverageRating, &p.ReviewCount,
 			&p.CreatedAt, &p.UpdatedAt,
 		)
 		if err != nil {
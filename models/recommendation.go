@@ -0,0 +1,181 @@
+package models
+
+import (
+	"context"
+	"slices"
+)
+
+const productRecommendationLimit = 3
+
+// recommendationIDs runs a query returning product ids in ranking order.
+// $1 is always the list of ids to leave out and $2 the limit.
+func recommendationIDs(ctx context.Context, db DB, query string, exclude []int64, limit int, args ...any) ([]int64, error) {
+	rows, err := db.Query(ctx, query, append([]any{exclude, limit}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// alsoBought ranks products by how many orders contain them together with
+// any of the given products ("customers also bought")
+func alsoBought(ctx context.Context, db DB, productIDs, exclude []int64, limit int) ([]int64, error) {
+	return recommendationIDs(ctx, db, `
+		SELECT other.product_id
+		FROM order_items bought
+		JOIN order_items other ON other.order_id = bought.order_id
+		WHERE bought.product_id = ANY($3)
+			AND other.product_id <> ALL($1)
+		GROUP BY other.product_id
+		ORDER BY COUNT(DISTINCT other.order_id) DESC, other.product_id
+		LIMIT $2
+	`, exclude, limit, productIDs)
+}
+
+// popularInCategories ranks products of the categories of the given products
+// by quantity sold, newest first when nothing was sold
+func popularInCategories(ctx context.Context, db DB, productIDs, exclude []int64, limit int) ([]int64, error) {
+	return recommendationIDs(ctx, db, `
+		SELECT p.id
+		FROM products p
+		LEFT JOIN order_items oi ON oi.product_id = p.id
+		WHERE p.category_id IN (SELECT category_id FROM products WHERE id = ANY($3))
+			AND p.id <> ALL($1)
+		GROUP BY p.id
+		ORDER BY COALESCE(SUM(oi.qty), 0) DESC, p.created_at DESC
+		LIMIT $2
+	`, exclude, limit, productIDs)
+}
+
+// popularProducts ranks every product by quantity sold
+func popularProducts(ctx context.Context, db DB, exclude []int64, limit int) ([]int64, error) {
+	return recommendationIDs(ctx, db, `
+		SELECT p.id
+		FROM products p
+		LEFT JOIN order_items oi ON oi.product_id = p.id
+		WHERE p.id <> ALL($1)
+		GROUP BY p.id
+		ORDER BY COALESCE(SUM(oi.qty), 0) DESC, p.created_at DESC
+		LIMIT $2
+	`, exclude, limit)
+}
+
+// productsByIDs loads full products keeping the order of ids
+func productsByIDs(ctx context.Context, db DB, ids []int64) ([]Product, error) {
+	if len(ids) == 0 {
+		return []Product{}, nil
+	}
+
+	var filter ProductFilter
+	query := filter.selectQuery(ctx, "",
+		"WHERE p.id = ANY($1)",
+		"HAVING 1 = 1",
+		"array_position($1::bigint[], p.id::bigint)",
+		"",
+	)
+
+	return scanProducts(ctx, db, query, []any{ids})
+}
+
+// recommend fills the list source by source until limit is reached
+func recommend(exclude []int64, limit int, sources ...func(exclude []int64, limit int) ([]int64, error)) ([]int64, error) {
+	exclude = append([]int64{}, exclude...)
+
+	var ids []int64
+	for _, source := range sources {
+		if len(ids) >= limit {
+			break
+		}
+
+		found, err := source(exclude, limit-len(ids))
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range found {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+				exclude = append(exclude, id)
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// GetProductRecommendations is shown on the product detail: products bought
+// together with it, then popular products of its category. It falls back to
+// the newest products of the category.
+func GetProductRecommendations(ctx context.Context, db DB, product *Product) ([]Product, error) {
+	current := []int64{product.ID}
+
+	ids, err := recommend(current, productRecommendationLimit,
+		func(exclude []int64, limit int) ([]int64, error) {
+			return alsoBought(ctx, db, current, exclude, limit)
+		},
+		func(exclude []int64, limit int) ([]int64, error) {
+			return popularInCategories(ctx, db, current, exclude, limit)
+		},
+	)
+	if err != nil || len(ids) == 0 {
+		return GetRecommendationsByCategory(ctx, db, product.Category, product.ID)
+	}
+
+	return productsByIDs(ctx, db, ids)
+}
+
+// GetUserRecommendations uses the user's order history: products bought
+// together with what they ordered, popular products of the same categories,
+// then best sellers. Products they already ordered are left out.
+func GetUserRecommendations(ctx context.Context, db DB, userID int64, limit int) ([]Product, error) {
+	rows, err := db.Query(ctx, `
+		SELECT DISTINCT oi.product_id
+		FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		WHERE o.users_id = $1 AND oi.product_id IS NOT NULL
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	purchased := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		purchased = append(purchased, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ids, err := recommend(purchased, limit,
+		func(exclude []int64, limit int) ([]int64, error) {
+			return alsoBought(ctx, db, purchased, exclude, limit)
+		},
+		func(exclude []int64, limit int) ([]int64, error) {
+			return popularInCategories(ctx, db, purchased, exclude, limit)
+		},
+		func(exclude []int64, limit int) ([]int64, error) {
+			return popularProducts(ctx, db, exclude, limit)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return productsByIDs(ctx, db, ids)
+}
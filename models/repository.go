@@ -0,0 +1,171 @@
+package models
+
+import (
+	"backend/lib"
+	"context"
+	"time"
+)
+
+// ProductRepository is the product catalog used by the product handlers
+type ProductRepository interface {
+	List(ctx context.Context, page, limit int, sort string, filter ProductFilter) ([]Product, int64, error)
+	ListCursor(ctx context.Context, cursor *lib.Cursor, limit int, sort string, filter ProductFilter) ([]Product, string, string, error)
+	Facets(ctx context.Context, filter ProductFilter) (*ProductFacets, error)
+	Suggest(ctx context.Context, search string, limit int) ([]ProductSuggestion, error)
+	Get(ctx context.Context, id int64) (*Product, error)
+	Recommendations(ctx context.Context, product *Product) ([]Product, error)
+	// UserRecommendations is based on the user's order history
+	UserRecommendations(ctx context.Context, userID int64, limit int) ([]Product, error)
+	// BestSellers and Trending rank by quantity sold in the last days,
+	// categoryID 0 for all categories
+	BestSellers(ctx context.Context, days, categoryID, limit int) ([]Product, error)
+	Trending(ctx context.Context, days, categoryID, limit int) ([]Product, error)
+
+	ListAdmin(ctx context.Context, page, limit int, search string) ([]ProductAdmin, int64, error)
+	ListAdminCursor(ctx context.Context, cursor *lib.Cursor, limit int, search string) ([]ProductAdmin, string, string, error)
+	Create(ctx context.Context, req CreateProductRequest) (*Product, error)
+	Update(ctx context.Context, id int64, req CreateProductRequest) (*Product, error)
+	Delete(ctx context.Context, id int64) error
+	SetImage(ctx context.Context, id int64, url string) error
+}
+
+// OrderRepository stores orders, created from the user's cart
+type OrderRepository interface {
+	Create(ctx context.Context, userID int64, req CreateOrderRequest) (OrderResponse, error)
+	Contact(ctx context.Context, userID int64) (OrderContact, error)
+	History(ctx context.Context, userID int64, month, shippingID, page, limit int) ([]map[string]interface{}, int, error)
+	HistoryCursor(ctx context.Context, userID int64, month, shippingID int, cursor *lib.Cursor, limit int) ([]map[string]interface{}, string, string, error)
+	Detail(ctx context.Context, id int64) (*OrderDetail, error)
+
+	List(ctx context.Context, page, limit int) ([]OrderListItem, int64, error)
+	ListCursor(ctx context.Context, cursor *lib.Cursor, limit int) ([]OrderListItem, string, string, error)
+	UpdateStatus(ctx context.Context, id int64, status int) error
+}
+
+// UserRepository stores accounts and their profile
+type UserRepository interface {
+	Register(ctx context.Context, req RegisterRequest) (*User, error)
+	// FindByEmail returns the user with the password hash, for login
+	FindByEmail(ctx context.Context, email string) (*User, error)
+	Get(ctx context.Context, id int64) (*User, error)
+	Email(ctx context.Context, id int64) (string, error)
+	// PasswordHash is empty for accounts created with social login
+	PasswordHash(ctx context.Context, id int64) (string, error)
+	Profile(ctx context.Context, id int64) (ListUserStruct, error)
+	UpdateProfile(ctx context.Context, id int64, req UpdateUserRequest) (*User, error)
+	SetProfilePicture(ctx context.Context, id int64, path string) error
+	SetPassword(ctx context.Context, email, hashedPassword string) error
+	MarkEmailVerified(ctx context.Context, id int64) error
+	// LoginWithIdentity finds, links or creates the user of a provider account
+	LoginWithIdentity(ctx context.Context, identity lib.OIDCIdentity) (*User, error)
+
+	List(ctx context.Context, page, limit int) ([]ListUserStruct, int64, error)
+	ListCursor(ctx context.Context, cursor *lib.Cursor, limit int) ([]ListUserStruct, string, string, error)
+	AdminUpdate(ctx context.Context, id int64, req AdminUpdateUserRequest) (*User, error)
+	AdminSetProfilePicture(ctx context.Context, id int64, path string) error
+}
+
+// CartRepository stores the items a user is about to order
+type CartRepository interface {
+	Add(ctx context.Context, req ReqCart) (int64, error)
+	List(ctx context.Context, userID int64) ([]CartItemResponse, error)
+	Delete(ctx context.Context, userID, cartItemID int64) error
+}
+
+// CategoryRepository stores the product categories
+type CategoryRepository interface {
+	List(ctx context.Context) ([]Categories, error)
+	IDs(ctx context.Context) ([]int, error)
+	Create(ctx context.Context, req Categories) (Categories, error)
+	Update(ctx context.Context, id int, req Categories) (Categories, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// FavoriteRepository stores the user's favorites and the featured list
+// curated by admin
+type FavoriteRepository interface {
+	Featured(ctx context.Context, page, limit int) ([]Product, int64, error)
+	// NextFeaturedChange is the time until a scheduled product enters or
+	// leaves the featured list, ok is false when nothing is scheduled
+	NextFeaturedChange(ctx context.Context) (next time.Duration, ok bool, err error)
+	FeaturedAdmin(ctx context.Context) ([]FeaturedProduct, error)
+	Feature(ctx context.Context, productID int64, req FeatureProductRequest) error
+	Unfeature(ctx context.Context, productID int64) error
+
+	List(ctx context.Context, userID int64, page, limit int) ([]Product, int64, error)
+	// Favorited tells which of productIDs the user saved
+	Favorited(ctx context.Context, userID int64, productIDs []int64) (map[int64]bool, error)
+	Add(ctx context.Context, userID, productID int64) error
+	Remove(ctx context.Context, userID, productID int64) error
+}
+
+// ReviewRepository stores product reviews and their moderation status
+type ReviewRepository interface {
+	ListByProduct(ctx context.Context, productID int64, page, limit int) ([]Review, int64, error)
+	Create(ctx context.Context, userID, productID int64, req ReviewRequest, status string) (*Review, error)
+	Update(ctx context.Context, userID, reviewID int64, req ReviewRequest, status string) (*Review, error)
+	Delete(ctx context.Context, userID, reviewID int64) error
+
+	ListAdmin(ctx context.Context, status string, page, limit int) ([]Review, int64, error)
+	SetStatus(ctx context.Context, reviewID int64, status string) error
+}
+
+// TwoFactorRepository stores TOTP secrets and backup code hashes, the codes
+// themselves are checked by the handlers
+type TwoFactorRepository interface {
+	// TOTP returns the stored secret, which may still be pending (enabled false)
+	TOTP(ctx context.Context, userID int64) (secret string, enabled bool, err error)
+	SetPendingSecret(ctx context.Context, userID int64, secret string) error
+	Enable(ctx context.Context, userID int64, backupCodeHashes []string) error
+	Disable(ctx context.Context, userID int64) error
+	RegenerateBackupCodes(ctx context.Context, userID int64, backupCodeHashes []string) error
+	// UseBackupCode consumes an unused backup code, false when there is none
+	UseBackupCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+}
+
+// AccountRepository exports and deletes everything stored about a user
+type AccountRepository interface {
+	Export(ctx context.Context, userID int64) (*UserExport, error)
+	Delete(ctx context.Context, userID int64) error
+}
+
+// TokenStore keeps the short lived state of the auth flows and the ranking
+// refresh lease, Redis in production. Lookups return ok false for a missing
+// or expired entry, an error means the store cannot be reached.
+type TokenStore interface {
+	// SaveOTP replaces the password reset OTP of email
+	SaveOTP(ctx context.Context, email, otp string, ttl time.Duration) error
+	// FindOTP returns the email an OTP was issued for
+	FindOTP(ctx context.Context, otp string) (email string, ok bool, err error)
+	DeleteOTP(ctx context.Context, email string) error
+
+	SaveVerification(ctx context.Context, token string, userID int64, ttl time.Duration) error
+	VerificationUser(ctx context.Context, token string) (userID int64, ok bool, err error)
+	DeleteVerification(ctx context.Context, token string) error
+
+	// SaveChallenge stores the challenge of a login waiting for its second
+	// factor
+	SaveChallenge(ctx context.Context, challenge string, userID int64, ttl time.Duration) error
+	ChallengeUser(ctx context.Context, challenge string) (userID int64, ok bool, err error)
+	// FailChallenge counts a wrong code sent with the challenge and returns
+	// the count so far
+	FailChallenge(ctx context.Context, challenge string, ttl time.Duration) (int64, error)
+	// DeleteChallenge removes the challenge and its count
+	DeleteChallenge(ctx context.Context, challenge string) error
+
+	// CodeFailures counts the wrong second factor codes of a user, on every
+	// route, until ResetCodeFailures or ttl after the last one
+	CodeFailures(ctx context.Context, userID int64) (int64, error)
+	FailCode(ctx context.Context, userID int64, ttl time.Duration) error
+	ResetCodeFailures(ctx context.Context, userID int64) error
+	// UseTOTP marks a TOTP code as used, false when it already was
+	UseTOTP(ctx context.Context, userID int64, code string, ttl time.Duration) (bool, error)
+
+	SaveOIDCState(ctx context.Context, state string, data []byte, ttl time.Duration) error
+	// TakeOIDCState returns the state data and removes it
+	TakeOIDCState(ctx context.Context, state string) (data []byte, ok bool, err error)
+
+	// AcquireLease is true when nobody else holds name, the lease is then
+	// held for ttl
+	AcquireLease(ctx context.Context, name string, ttl time.Duration) (bool, error)
+}
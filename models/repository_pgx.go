@@ -0,0 +1,385 @@
+package models
+
+import (
+	"backend/lib"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DB is what the queries of this package run on, a *pgxpool.Pool in
+// production. Each pgx repository owns the one it was created with.
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type PgProductRepository struct {
+	db DB
+}
+
+func NewPgProductRepository(db DB) PgProductRepository {
+	return PgProductRepository{db: db}
+}
+
+var _ ProductRepository = PgProductRepository{}
+
+func (r PgProductRepository) List(ctx context.Context, page, limit int, sort string, filter ProductFilter) ([]Product, int64, error) {
+	return GetProducts(ctx, r.db, page, limit, sort, filter)
+}
+
+func (r PgProductRepository) ListCursor(ctx context.Context, cursor *lib.Cursor, limit int, sort string, filter ProductFilter) ([]Product, string, string, error) {
+	return GetProductsCursor(ctx, r.db, cursor, limit, sort, filter)
+}
+
+func (r PgProductRepository) Facets(ctx context.Context, filter ProductFilter) (*ProductFacets, error) {
+	return GetProductFacets(ctx, r.db, filter)
+}
+
+func (r PgProductRepository) Suggest(ctx context.Context, search string, limit int) ([]ProductSuggestion, error) {
+	return SuggestProducts(ctx, r.db, search, limit)
+}
+
+func (r PgProductRepository) Get(ctx context.Context, id int64) (*Product, error) {
+	return GetProductByID(ctx, r.db, id)
+}
+
+func (r PgProductRepository) Recommendations(ctx context.Context, product *Product) ([]Product, error) {
+	return GetProductRecommendations(ctx, r.db, product)
+}
+
+func (r PgProductRepository) UserRecommendations(ctx context.Context, userID int64, limit int) ([]Product, error) {
+	return GetUserRecommendations(ctx, r.db, userID, limit)
+}
+
+func (r PgProductRepository) BestSellers(ctx context.Context, days, categoryID, limit int) ([]Product, error) {
+	return GetBestSellers(ctx, r.db, days, categoryID, limit)
+}
+
+func (r PgProductRepository) Trending(ctx context.Context, days, categoryID, limit int) ([]Product, error) {
+	return GetTrending(ctx, r.db, days, categoryID, limit)
+}
+
+func (r PgProductRepository) ListAdmin(ctx context.Context, page, limit int, search string) ([]ProductAdmin, int64, error) {
+	return GetProductsAdmin(ctx, r.db, page, limit, search)
+}
+
+func (r PgProductRepository) ListAdminCursor(ctx context.Context, cursor *lib.Cursor, limit int, search string) ([]ProductAdmin, string, string, error) {
+	return GetProductsAdminCursor(ctx, r.db, cursor, limit, search)
+}
+
+func (r PgProductRepository) Create(ctx context.Context, req CreateProductRequest) (*Product, error) {
+	return CreateProduct(ctx, r.db, req)
+}
+
+func (r PgProductRepository) Update(ctx context.Context, id int64, req CreateProductRequest) (*Product, error) {
+	return UpdateProduct(ctx, r.db, id, req)
+}
+
+func (r PgProductRepository) Delete(ctx context.Context, id int64) error {
+	return DeleteProduct(ctx, r.db, id)
+}
+
+func (r PgProductRepository) SetImage(ctx context.Context, id int64, url string) error {
+	return UploadImgProduct(ctx, r.db, id, url)
+}
+
+type PgOrderRepository struct {
+	db DB
+}
+
+func NewPgOrderRepository(db DB) PgOrderRepository {
+	return PgOrderRepository{db: db}
+}
+
+var _ OrderRepository = PgOrderRepository{}
+
+func (r PgOrderRepository) Create(ctx context.Context, userID int64, req CreateOrderRequest) (OrderResponse, error) {
+	return CreateOrder(ctx, r.db, userID, req)
+}
+
+func (r PgOrderRepository) Contact(ctx context.Context, userID int64) (OrderContact, error) {
+	return GetOrderContact(ctx, r.db, userID)
+}
+
+func (r PgOrderRepository) History(ctx context.Context, userID int64, month, shippingID, page, limit int) ([]map[string]interface{}, int, error) {
+	return GetOrderHistoryByUserID(ctx, r.db, userID, month, shippingID, page, limit)
+}
+
+func (r PgOrderRepository) HistoryCursor(ctx context.Context, userID int64, month, shippingID int, cursor *lib.Cursor, limit int) ([]map[string]interface{}, string, string, error) {
+	return GetOrderHistoryCursor(ctx, r.db, userID, month, shippingID, cursor, limit)
+}
+
+func (r PgOrderRepository) Detail(ctx context.Context, id int64) (*OrderDetail, error) {
+	return GetOrderDetail(ctx, r.db, id)
+}
+
+func (r PgOrderRepository) List(ctx context.Context, page, limit int) ([]OrderListItem, int64, error) {
+	return GetAllOrders(ctx, r.db, page, limit)
+}
+
+func (r PgOrderRepository) ListCursor(ctx context.Context, cursor *lib.Cursor, limit int) ([]OrderListItem, string, string, error) {
+	return GetAllOrdersCursor(ctx, r.db, cursor, limit)
+}
+
+func (r PgOrderRepository) UpdateStatus(ctx context.Context, id int64, status int) error {
+	return UpdateOrderStatus(ctx, r.db, id, status)
+}
+
+type PgUserRepository struct {
+	db DB
+}
+
+func NewPgUserRepository(db DB) PgUserRepository {
+	return PgUserRepository{db: db}
+}
+
+var _ UserRepository = PgUserRepository{}
+
+func (r PgUserRepository) Register(ctx context.Context, req RegisterRequest) (*User, error) {
+	return Register(ctx, r.db, req)
+}
+
+func (r PgUserRepository) FindByEmail(ctx context.Context, email string) (*User, error) {
+	return Login(ctx, r.db, email)
+}
+
+func (r PgUserRepository) Get(ctx context.Context, id int64) (*User, error) {
+	return GetUserByID(ctx, r.db, id)
+}
+
+func (r PgUserRepository) Email(ctx context.Context, id int64) (string, error) {
+	return GetUserEmailByID(ctx, r.db, id)
+}
+
+func (r PgUserRepository) PasswordHash(ctx context.Context, id int64) (string, error) {
+	return GetUserPasswordByID(ctx, r.db, id)
+}
+
+func (r PgUserRepository) Profile(ctx context.Context, id int64) (ListUserStruct, error) {
+	return GetUserProfile(ctx, r.db, id)
+}
+
+func (r PgUserRepository) UpdateProfile(ctx context.Context, id int64, req UpdateUserRequest) (*User, error) {
+	return UpdateUserByID(ctx, r.db, id, req)
+}
+
+func (r PgUserRepository) SetProfilePicture(ctx context.Context, id int64, path string) error {
+	return UpdateUserProfilePicture(ctx, r.db, id, path)
+}
+
+func (r PgUserRepository) SetPassword(ctx context.Context, email, hashedPassword string) error {
+	return UpdateUserPassword(ctx, r.db, email, hashedPassword)
+}
+
+func (r PgUserRepository) MarkEmailVerified(ctx context.Context, id int64) error {
+	return MarkEmailVerified(ctx, r.db, id)
+}
+
+func (r PgUserRepository) LoginWithIdentity(ctx context.Context, identity lib.OIDCIdentity) (*User, error) {
+	return LoginWithIdentity(ctx, r.db, identity)
+}
+
+func (r PgUserRepository) List(ctx context.Context, page, limit int) ([]ListUserStruct, int64, error) {
+	return ListUser(ctx, r.db, page, limit)
+}
+
+func (r PgUserRepository) ListCursor(ctx context.Context, cursor *lib.Cursor, limit int) ([]ListUserStruct, string, string, error) {
+	return ListUserCursor(ctx, r.db, cursor, limit)
+}
+
+func (r PgUserRepository) AdminUpdate(ctx context.Context, id int64, req AdminUpdateUserRequest) (*User, error) {
+	return AdminUpdateUserByID(ctx, r.db, id, req)
+}
+
+func (r PgUserRepository) AdminSetProfilePicture(ctx context.Context, id int64, path string) error {
+	return AdminUpdateUserProfilePicture(ctx, r.db, id, path)
+}
+
+type PgCartRepository struct {
+	db DB
+}
+
+func NewPgCartRepository(db DB) PgCartRepository {
+	return PgCartRepository{db: db}
+}
+
+var _ CartRepository = PgCartRepository{}
+
+func (r PgCartRepository) Add(ctx context.Context, req ReqCart) (int64, error) {
+	return AddToCart(ctx, r.db, req)
+}
+
+func (r PgCartRepository) List(ctx context.Context, userID int64) ([]CartItemResponse, error) {
+	return GetCart(ctx, r.db, userID)
+}
+
+func (r PgCartRepository) Delete(ctx context.Context, userID, cartItemID int64) error {
+	return DeleteCartItem(ctx, r.db, userID, cartItemID)
+}
+
+type PgCategoryRepository struct {
+	db DB
+}
+
+func NewPgCategoryRepository(db DB) PgCategoryRepository {
+	return PgCategoryRepository{db: db}
+}
+
+var _ CategoryRepository = PgCategoryRepository{}
+
+func (r PgCategoryRepository) List(ctx context.Context) ([]Categories, error) {
+	return GetAllCategories(ctx, r.db)
+}
+
+func (r PgCategoryRepository) IDs(ctx context.Context) ([]int, error) {
+	return GetCategoryIDs(ctx, r.db)
+}
+
+func (r PgCategoryRepository) Create(ctx context.Context, req Categories) (Categories, error) {
+	return CreateCategory(ctx, r.db, req)
+}
+
+func (r PgCategoryRepository) Update(ctx context.Context, id int, req Categories) (Categories, error) {
+	return UpdateCategory(ctx, r.db, id, req)
+}
+
+func (r PgCategoryRepository) Delete(ctx context.Context, id int) error {
+	return DeleteCategory(ctx, r.db, id)
+}
+
+type PgFavoriteRepository struct {
+	db DB
+}
+
+func NewPgFavoriteRepository(db DB) PgFavoriteRepository {
+	return PgFavoriteRepository{db: db}
+}
+
+var _ FavoriteRepository = PgFavoriteRepository{}
+
+func (r PgFavoriteRepository) Featured(ctx context.Context, page, limit int) ([]Product, int64, error) {
+	return Favorite(ctx, r.db, page, limit)
+}
+
+func (r PgFavoriteRepository) NextFeaturedChange(ctx context.Context) (time.Duration, bool, error) {
+	return NextFeaturedChange(ctx, r.db)
+}
+
+func (r PgFavoriteRepository) FeaturedAdmin(ctx context.Context) ([]FeaturedProduct, error) {
+	return GetFeaturedProductsAdmin(ctx, r.db)
+}
+
+func (r PgFavoriteRepository) Feature(ctx context.Context, productID int64, req FeatureProductRequest) error {
+	return FeatureProduct(ctx, r.db, productID, req)
+}
+
+func (r PgFavoriteRepository) Unfeature(ctx context.Context, productID int64) error {
+	return UnfeatureProduct(ctx, r.db, productID)
+}
+
+func (r PgFavoriteRepository) List(ctx context.Context, userID int64, page, limit int) ([]Product, int64, error) {
+	return GetUserFavorites(ctx, r.db, userID, page, limit)
+}
+
+func (r PgFavoriteRepository) Favorited(ctx context.Context, userID int64, productIDs []int64) (map[int64]bool, error) {
+	return FavoritedProductIDs(ctx, r.db, userID, productIDs)
+}
+
+func (r PgFavoriteRepository) Add(ctx context.Context, userID, productID int64) error {
+	return AddUserFavorite(ctx, r.db, userID, productID)
+}
+
+func (r PgFavoriteRepository) Remove(ctx context.Context, userID, productID int64) error {
+	return RemoveUserFavorite(ctx, r.db, userID, productID)
+}
+
+type PgReviewRepository struct {
+	db DB
+}
+
+func NewPgReviewRepository(db DB) PgReviewRepository {
+	return PgReviewRepository{db: db}
+}
+
+var _ ReviewRepository = PgReviewRepository{}
+
+func (r PgReviewRepository) ListByProduct(ctx context.Context, productID int64, page, limit int) ([]Review, int64, error) {
+	return GetProductReviews(ctx, r.db, productID, page, limit)
+}
+
+func (r PgReviewRepository) Create(ctx context.Context, userID, productID int64, req ReviewRequest, status string) (*Review, error) {
+	return CreateReview(ctx, r.db, userID, productID, req, status)
+}
+
+func (r PgReviewRepository) Update(ctx context.Context, userID, reviewID int64, req ReviewRequest, status string) (*Review, error) {
+	return UpdateReview(ctx, r.db, userID, reviewID, req, status)
+}
+
+func (r PgReviewRepository) Delete(ctx context.Context, userID, reviewID int64) error {
+	return DeleteReview(ctx, r.db, userID, reviewID)
+}
+
+func (r PgReviewRepository) ListAdmin(ctx context.Context, status string, page, limit int) ([]Review, int64, error) {
+	return GetReviewsAdmin(ctx, r.db, status, page, limit)
+}
+
+func (r PgReviewRepository) SetStatus(ctx context.Context, reviewID int64, status string) error {
+	return SetReviewStatus(ctx, r.db, reviewID, status)
+}
+
+type PgTwoFactorRepository struct {
+	db DB
+}
+
+func NewPgTwoFactorRepository(db DB) PgTwoFactorRepository {
+	return PgTwoFactorRepository{db: db}
+}
+
+var _ TwoFactorRepository = PgTwoFactorRepository{}
+
+func (r PgTwoFactorRepository) TOTP(ctx context.Context, userID int64) (string, bool, error) {
+	return GetTOTP(ctx, r.db, userID)
+}
+
+func (r PgTwoFactorRepository) SetPendingSecret(ctx context.Context, userID int64, secret string) error {
+	return SetPendingTOTPSecret(ctx, r.db, userID, secret)
+}
+
+func (r PgTwoFactorRepository) Enable(ctx context.Context, userID int64, backupCodeHashes []string) error {
+	return EnableTOTP(ctx, r.db, userID, backupCodeHashes)
+}
+
+func (r PgTwoFactorRepository) Disable(ctx context.Context, userID int64) error {
+	return DisableTOTP(ctx, r.db, userID)
+}
+
+func (r PgTwoFactorRepository) RegenerateBackupCodes(ctx context.Context, userID int64, backupCodeHashes []string) error {
+	return RegenerateBackupCodes(ctx, r.db, userID, backupCodeHashes)
+}
+
+func (r PgTwoFactorRepository) UseBackupCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	return UseBackupCode(ctx, r.db, userID, codeHash)
+}
+
+type PgAccountRepository struct {
+	db DB
+}
+
+func NewPgAccountRepository(db DB) PgAccountRepository {
+	return PgAccountRepository{db: db}
+}
+
+var _ AccountRepository = PgAccountRepository{}
+
+func (r PgAccountRepository) Export(ctx context.Context, userID int64) (*UserExport, error) {
+	return ExportUserData(ctx, r.db, userID)
+}
+
+func (r PgAccountRepository) Delete(ctx context.Context, userID int64) error {
+	return DeleteUserAccount(ctx, r.db, userID)
+}
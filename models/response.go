@@ -1,10 +1,13 @@
 package models
 
-import "backend/lib"
+import "backend/apperr"
 
 type Response struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
-	Pagination *lib.PaginationData `json:"pagination,omitempty"`
+	// Pagination is a *lib.PaginationData, or *lib.CursorPaginationData with ?cursor=
+	Pagination any    `json:"pagination,omitempty"`
 	Data    any    `json:"data,omitempty"`
+	Facets  any    `json:"facets,omitempty"`
+	Errors  []apperr.FieldError `json:"errors,omitempty"`
 }
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"backend/apperr"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const averageRatingExpr = `COALESCE((SELECT ROUND(AVG(r.rating)::numeric, 1) FROM product_reviews r WHERE r.product_id = p.id AND r.status = 'approved'), 0)`
+
+// ratingColumns adds average_rating and review_count (approved reviews only)
+// to the product select queries
+const ratingColumns = averageRatingExpr + ` AS average_rating,
+	(SELECT COUNT(*) FROM product_reviews r WHERE r.product_id = p.id AND r.status = 'approved') AS review_count,`
+
+var (
+	ErrReviewNotAllowed = apperr.Forbidden("only customers with a completed order of this product can review it")
+	ErrReviewExists     = apperr.Conflict("you already reviewed this product")
+	ErrReviewNotFound   = apperr.NotFound("review not found")
+)
+
+type Review struct {
+	ID        int64     `json:"id"`
+	ProductID int64     `json:"product_id"`
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	Rating    int       `json:"rating"`
+	Review    string    `json:"review"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ReviewRequest struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Review string `json:"review" binding:"max=1000"`
+}
+
+type ReviewStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=approved hidden"`
+}
+
+const reviewSelect = `
+	SELECT
+		r.id,
+		r.product_id,
+		r.users_id,
+		COALESCE(pf.username, ''),
+		r.rating,
+		COALESCE(r.review, ''),
+		r.status,
+		r.created_at,
+		r.updated_at
+	FROM product_reviews r
+	LEFT JOIN profile pf ON pf.users_id = r.users_id
+`
+
+func scanReview(row pgx.Row) (*Review, error) {
+	var r Review
+	err := row.Scan(&r.ID, &r.ProductID, &r.UserID, &r.Username, &r.Rating, &r.Review, &r.Status, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// CanReviewProduct is true when the user has a finished ("Done") order with the product
+func CanReviewProduct(ctx context.Context, db DB, userID, productID int64) (bool, error) {
+	var ok bool
+	err := db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			JOIN shippings s ON s.id = o.shipping_id
+			WHERE o.users_id = $1 AND oi.product_id = $2 AND s.name = 'Done'
+		)
+	`, userID, productID).Scan(&ok)
+
+	return ok, err
+}
+
+func CreateReview(ctx context.Context, db DB, userID, productID int64, req ReviewRequest, status string) (*Review, error) {
+	allowed, err := CanReviewProduct(ctx, db, userID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrReviewNotAllowed
+	}
+
+	var id int64
+	err = db.QueryRow(ctx, `
+		INSERT INTO product_reviews (product_id, users_id, rating, review, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, productID, userID, req.Rating, req.Review, status).Scan(&id)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrReviewExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return scanReview(db.QueryRow(ctx, reviewSelect+` WHERE r.id = $1`, id))
+}
+
+// UpdateReview edits the user's own review, a review hidden by admin stays hidden
+func UpdateReview(ctx context.Context, db DB, userID, reviewID int64, req ReviewRequest, status string) (*Review, error) {
+	tag, err := db.Exec(ctx, `
+		UPDATE product_reviews
+		SET rating = $1,
+			review = $2,
+			status = CASE WHEN status = 'hidden' THEN 'hidden' ELSE $3 END,
+			updated_at = NOW()
+		WHERE id = $4 AND users_id = $5
+	`, req.Rating, req.Review, status, reviewID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrReviewNotFound
+	}
+
+	return scanReview(db.QueryRow(ctx, reviewSelect+` WHERE r.id = $1`, reviewID))
+}
+
+func DeleteReview(ctx context.Context, db DB, userID, reviewID int64) error {
+	tag, err := db.Exec(ctx,
+		`DELETE FROM product_reviews WHERE id = $1 AND users_id = $2`,
+		reviewID, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrReviewNotFound
+	}
+
+	return nil
+}
+
+func listReviews(ctx context.Context, db DB, where string, args []any, page, limit int) ([]Review, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	query := reviewSelect + where + fmt.Sprintf(" ORDER BY r.created_at DESC LIMIT %d OFFSET %d", limit, offset)
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	reviews := make([]Review, 0)
+	for rows.Next() {
+		r, err := scanReview(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		reviews = append(reviews, *r)
+	}
+
+	var total int64
+	err = db.QueryRow(ctx, `SELECT COUNT(*) FROM product_reviews r `+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, total, nil
+}
+
+func GetProductReviews(ctx context.Context, db DB, productID int64, page, limit int) ([]Review, int64, error) {
+	return listReviews(ctx, db, ` WHERE r.product_id = $1 AND r.status = 'approved'`, []any{productID}, page, limit)
+}
+
+// admin, status empty lists every review
+func GetReviewsAdmin(ctx context.Context, db DB, status string, page, limit int) ([]Review, int64, error) {
+	if status == "" {
+		return listReviews(ctx, db, "", nil, page, limit)
+	}
+	return listReviews(ctx, db, ` WHERE r.status = $1`, []any{status}, page, limit)
+}
+
+func SetReviewStatus(ctx context.Context, db DB, reviewID int64, status string) error {
+	tag, err := db.Exec(ctx,
+		`UPDATE product_reviews SET status = $1, updated_at = NOW() WHERE id = $2`,
+		status, reviewID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrReviewNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// searchTSQuery turns user input into a prefix tsquery, "caf lat" -> "caf:* & lat:*",
+// so partially typed words still match. Returns "" when nothing searchable is left.
+func searchTSQuery(search string) string {
+	words := strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		terms = append(terms, w+":*")
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// searchCondition matches the full text index or, for typos, trigram word
+// similarity on the name. $q is the raw search and $tsq the searchTSQuery.
+func searchCondition(q, tsq string) string {
+	return `(p.search_vector @@ to_tsquery('simple', ` + tsq + `) OR ` + q + ` <% p.name)`
+}
+
+func relevanceExpr(q, tsq string) string {
+	return `(ts_rank_cd(p.search_vector, to_tsquery('simple', ` + tsq + `)) + word_similarity(` + q + `, p.name))::float8`
+}
+
+// escapeHTMLExpr escapes text for HTML in SQL, & first so the other
+// entities are not escaped twice
+func escapeHTMLExpr(expr string) string {
+	return `replace(replace(replace(` + expr + `, '&', '&amp;'), '<', '&lt;'), '>', '&gt;')`
+}
+
+// escapeLike makes user input match literally in a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// searchColumns selects relevance and a highlighted description snippet, the
+// description in the request locale (pt from translationJoins). The snippet
+// is HTML, the description is escaped so only the <mark> tags are markup.
+func searchColumns(q, tsq string) string {
+	return relevanceExpr(q, tsq) + ` AS relevance,
+	ts_headline('simple', ` + escapeHTMLExpr(`COALESCE(pt.description, p.description, '')`) + `, to_tsquery('simple', ` + tsq + `),
+		'StartSel=<mark>, StopSel=</mark>, MaxWords=20, MinWords=5') AS snippet,`
+}
+
+type ProductSuggestion struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// SuggestProducts is the autocomplete list in the request locale, names
+// starting with the query first, then the closest matches
+func SuggestProducts(ctx context.Context, db DB, search string, limit int) ([]ProductSuggestion, error) {
+	suggestions := make([]ProductSuggestion, 0)
+
+	tsq := searchTSQuery(search)
+	if tsq == "" {
+		return suggestions, nil
+	}
+	if limit < 1 {
+		limit = 5
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT p.id, `+productNameExpr+`, `+categoryNameExpr+`
+		FROM products p
+		LEFT JOIN categories c ON c.id = p.category_id`+translationJoins(ctx)+`
+		WHERE p.search_vector @@ to_tsquery('simple', $2) OR $1 <% `+productNameExpr+`
+		ORDER BY
+			`+productNameExpr+` ILIKE $4 DESC,
+			word_similarity($1, `+productNameExpr+`) DESC,
+			ts_rank_cd(p.search_vector, to_tsquery('simple', $2)) DESC,
+			`+productNameExpr+` ASC
+		LIMIT $3
+	`, search, tsq, limit, escapeLike(search)+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s ProductSuggestion
+		if err := rows.Scan(&s.ID, &s.Name, &s.Category); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, s)
+	}
+
+	return suggestions, rows.Err()
+}
@@ -0,0 +1,33 @@
+package models
+
+import "testing"
+
+func TestSearchTSQuery(t *testing.T) {
+	tests := map[string]string{
+		"caf lat":      "caf:* & lat:*",
+		"Kopi, Susu!":  "kopi:* & susu:*",
+		"  ' & | ! ":   "",
+		"es-kopi 2024": "es:* & kopi:* & 2024:*",
+	}
+
+	for search, want := range tests {
+		if got := searchTSQuery(search); got != want {
+			t.Errorf("searchTSQuery(%q) = %q, want %q", search, got, want)
+		}
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := map[string]string{
+		"kopi":     "kopi",
+		"100%":     `100\%`,
+		"es_teh":   `es\_teh`,
+		`c:\kopi%`: `c:\\kopi\%`,
+	}
+
+	for search, want := range tests {
+		if got := escapeLike(search); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", search, got, want)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"backend/config"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// errRedisDown is returned without asking Redis while the health check
+// reports it down, like the cache does
+var errRedisDown = errors.New("redis is unavailable")
+
+// RedisTokenStore keeps the tokens on its own client. Keys:
+//
+//	otp:<email>                 password reset OTP
+//	verify:<token>              user id of an email verification link
+//	2fa:<challenge>             user id of a login waiting for its second factor
+//	2fa:<challenge>:attempts    wrong codes sent with the challenge
+//	2fa-attempts:<user id>      wrong second factor codes of the user
+//	2fa-used:<user id>:<code>   TOTP codes already used
+//	oidc:<state>                JSON state of a social login
+//	lease:<name>                lease of a background job
+type RedisTokenStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisTokenStore(rdb *redis.Client) RedisTokenStore {
+	return RedisTokenStore{rdb: rdb}
+}
+
+var _ TokenStore = RedisTokenStore{}
+
+func (s RedisTokenStore) client() (*redis.Client, error) {
+	if s.rdb == nil || !config.RedisReady() {
+		return nil, errRedisDown
+	}
+	return s.rdb, nil
+}
+
+// get reads a key, ok is false when it does not exist
+func (s RedisTokenStore) get(ctx context.Context, key string) (string, bool, error) {
+	rdb, err := s.client()
+	if err != nil {
+		return "", false, err
+	}
+
+	val, err := rdb.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return val, true, nil
+}
+
+func (s RedisTokenStore) getInt(ctx context.Context, key string) (int64, bool, error) {
+	val, ok, err := s.get(ctx, key)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+
+	n, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, false, nil
+	}
+	return n, true, nil
+}
+
+func (s RedisTokenStore) set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	rdb, err := s.client()
+	if err != nil {
+		return err
+	}
+	return rdb.Set(ctx, key, value, ttl).Err()
+}
+
+func (s RedisTokenStore) del(ctx context.Context, keys ...string) error {
+	rdb, err := s.client()
+	if err != nil {
+		return err
+	}
+	return rdb.Del(ctx, keys...).Err()
+}
+
+func (s RedisTokenStore) setNX(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	rdb, err := s.client()
+	if err != nil {
+		return false, err
+	}
+	return rdb.SetNX(ctx, key, 1, ttl).Result()
+}
+
+func (s RedisTokenStore) SaveOTP(ctx context.Context, email, otp string, ttl time.Duration) error {
+	return s.set(ctx, "otp:"+email, otp, ttl)
+}
+
+func (s RedisTokenStore) FindOTP(ctx context.Context, otp string) (string, bool, error) {
+	rdb, err := s.client()
+	if err != nil {
+		return "", false, err
+	}
+
+	keys, err := rdb.Keys(ctx, "otp:*").Result()
+	if err != nil {
+		return "", false, err
+	}
+
+	for _, key := range keys {
+		val, _ := rdb.Get(ctx, key).Result()
+		if val == otp {
+			return strings.TrimPrefix(key, "otp:"), true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func (s RedisTokenStore) DeleteOTP(ctx context.Context, email string) error {
+	return s.del(ctx, "otp:"+email)
+}
+
+func (s RedisTokenStore) SaveVerification(ctx context.Context, token string, userID int64, ttl time.Duration) error {
+	return s.set(ctx, "verify:"+token, userID, ttl)
+}
+
+func (s RedisTokenStore) VerificationUser(ctx context.Context, token string) (int64, bool, error) {
+	return s.getInt(ctx, "verify:"+token)
+}
+
+func (s RedisTokenStore) DeleteVerification(ctx context.Context, token string) error {
+	return s.del(ctx, "verify:"+token)
+}
+
+func (s RedisTokenStore) SaveChallenge(ctx context.Context, challenge string, userID int64, ttl time.Duration) error {
+	return s.set(ctx, "2fa:"+challenge, userID, ttl)
+}
+
+func (s RedisTokenStore) ChallengeUser(ctx context.Context, challenge string) (int64, bool, error) {
+	return s.getInt(ctx, "2fa:"+challenge)
+}
+
+func (s RedisTokenStore) FailChallenge(ctx context.Context, challenge string, ttl time.Duration) (int64, error) {
+	rdb, err := s.client()
+	if err != nil {
+		return 0, err
+	}
+
+	key := "2fa:" + challenge + ":attempts"
+	var incr *redis.IntCmd
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (s RedisTokenStore) DeleteChallenge(ctx context.Context, challenge string) error {
+	return s.del(ctx, "2fa:"+challenge, "2fa:"+challenge+":attempts")
+}
+
+func (s RedisTokenStore) CodeFailures(ctx context.Context, userID int64) (int64, error) {
+	n, _, err := s.getInt(ctx, fmt.Sprintf("2fa-attempts:%d", userID))
+	return n, err
+}
+
+func (s RedisTokenStore) FailCode(ctx context.Context, userID int64, ttl time.Duration) error {
+	rdb, err := s.client()
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("2fa-attempts:%d", userID)
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+func (s RedisTokenStore) ResetCodeFailures(ctx context.Context, userID int64) error {
+	return s.del(ctx, fmt.Sprintf("2fa-attempts:%d", userID))
+}
+
+func (s RedisTokenStore) UseTOTP(ctx context.Context, userID int64, code string, ttl time.Duration) (bool, error) {
+	return s.setNX(ctx, fmt.Sprintf("2fa-used:%d:%s", userID, code), ttl)
+}
+
+func (s RedisTokenStore) SaveOIDCState(ctx context.Context, state string, data []byte, ttl time.Duration) error {
+	return s.set(ctx, "oidc:"+state, data, ttl)
+}
+
+func (s RedisTokenStore) TakeOIDCState(ctx context.Context, state string) ([]byte, bool, error) {
+	rdb, err := s.client()
+	if err != nil {
+		return nil, false, err
+	}
+
+	data, err := rdb.GetDel(ctx, "oidc:"+state).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func (s RedisTokenStore) AcquireLease(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	return s.setNX(ctx, "lease:"+name, ttl)
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"backend/i18n"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ProductTranslation is the name and description of a product in one
+// locale, the products columns are used for locales without one
+type ProductTranslation struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+}
+
+// CategoryTranslation is the name of a category in one locale
+type CategoryTranslation struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// the product and category columns in the request locale, pt and ct come
+// from translationJoins
+const (
+	productNameExpr        = "COALESCE(pt.name, p.name)"
+	productDescriptionExpr = "COALESCE(pt.description, p.description)"
+	categoryNameExpr       = "COALESCE(ct.name, c.name, '')"
+)
+
+// localeLiteral quotes the request locale for SQL, i18n.FromContext only
+// returns one of i18n.Locales so it never needs escaping
+func localeLiteral(ctx context.Context) string {
+	return "'" + i18n.FromContext(ctx) + "'"
+}
+
+// translationJoins joins the translations (pt, ct) of the product p and
+// its category c in the request locale
+func translationJoins(ctx context.Context) string {
+	locale := localeLiteral(ctx)
+	return `
+LEFT JOIN product_translations pt ON pt.product_id = p.id AND pt.locale = ` + locale + `
+LEFT JOIN category_translations ct ON ct.category_id = c.id AND ct.locale = ` + locale
+}
+
+// localizedCategories selects id and name of every category in the
+// request locale
+func localizedCategories(ctx context.Context) string {
+	return `(
+		SELECT c.id, COALESCE(ct.name, c.name) AS name
+		FROM categories c
+		LEFT JOIN category_translations ct ON ct.category_id = c.id AND ct.locale = ` + localeLiteral(ctx) + `
+	)`
+}
+
+// saveProductTranslations replaces the translations of a product in the tx
+// writing the product, a nil map (translations left out of the request)
+// keeps them
+func saveProductTranslations(ctx context.Context, tx pgx.Tx, productID int64, translations map[string]ProductTranslation) error {
+	if translations == nil {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM product_translations WHERE product_id = $1`, productID); err != nil {
+		return err
+	}
+	for locale, t := range translations {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO product_translations (product_id, locale, name, description) VALUES ($1, $2, $3, NULLIF($4, ''))`,
+			productID, locale, t.Name, t.Description,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveCategoryTranslations is saveProductTranslations for a category
+func saveCategoryTranslations(ctx context.Context, tx pgx.Tx, categoryID int, translations map[string]CategoryTranslation) error {
+	if translations == nil {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM category_translations WHERE category_id = $1`, categoryID); err != nil {
+		return err
+	}
+	for locale, t := range translations {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO category_translations (category_id, locale, name) VALUES ($1, $2, $3)`,
+			categoryID, locale, t.Name,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
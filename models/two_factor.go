@@ -0,0 +1,169 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+func GetUserByID(ctx context.Context, db DB, id int64) (*User, error) {
+	var user User
+	err := db.QueryRow(ctx,
+		`SELECT id, email, role, email_verified, totp_enabled FROM users WHERE id = $1`,
+		id,
+	).Scan(&user.ID, &user.Email, &user.Role, &user.EmailVerified, &user.TwoFactorEnabled)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func GetUserPasswordByID(ctx context.Context, db DB, id int64) (string, error) {
+	var password *string
+	err := db.QueryRow(ctx, `SELECT password FROM users WHERE id = $1`, id).Scan(&password)
+	if err != nil {
+		return "", err
+	}
+	if password == nil {
+		return "", nil
+	}
+
+	return *password, nil
+}
+
+// GetTOTP returns the stored secret, which may still be pending (enabled false)
+func GetTOTP(ctx context.Context, db DB, userID int64) (string, bool, error) {
+	var secret *string
+	var enabled bool
+	err := db.QueryRow(ctx,
+		`SELECT totp_secret, totp_enabled FROM users WHERE id = $1`, userID,
+	).Scan(&secret, &enabled)
+	if err != nil {
+		return "", false, err
+	}
+	if secret == nil {
+		return "", enabled, nil
+	}
+
+	return *secret, enabled, nil
+}
+
+func SetPendingTOTPSecret(ctx context.Context, db DB, userID int64, secret string) error {
+	_, err := db.Exec(ctx,
+		`UPDATE users SET totp_secret = $1, updated_at = NOW()
+		 WHERE id = $2 AND totp_enabled = FALSE`,
+		secret, userID,
+	)
+	return err
+}
+
+func EnableTOTP(ctx context.Context, db DB, userID int64, backupCodeHashes []string) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`UPDATE users SET totp_enabled = TRUE, updated_at = NOW() WHERE id = $1`, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := replaceBackupCodes(ctx, tx, userID, backupCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func DisableTOTP(ctx context.Context, db DB, userID int64) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`UPDATE users SET totp_enabled = FALSE, totp_secret = NULL, updated_at = NOW() WHERE id = $1`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM user_backup_codes WHERE users_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func RegenerateBackupCodes(ctx context.Context, db DB, userID int64, backupCodeHashes []string) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := replaceBackupCodes(ctx, tx, userID, backupCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func replaceBackupCodes(ctx context.Context, tx pgx.Tx, userID int64, hashes []string) error {
+	_, err := tx.Exec(ctx, `DELETE FROM user_backup_codes WHERE users_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range hashes {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO user_backup_codes (users_id, code_hash) VALUES ($1, $2)`,
+			userID, hash,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseBackupCode marks an unused backup code as used, false when it does not match
+func UseBackupCode(ctx context.Context, db DB, userID int64, codeHash string) (bool, error) {
+	var id int64
+	err := db.QueryRow(ctx,
+		`UPDATE user_backup_codes SET used_at = NOW()
+		 WHERE users_id = $1 AND code_hash = $2 AND used_at IS NULL
+		 RETURNING id`,
+		userID, codeHash,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -1,10 +1,11 @@
 package models
 
 import (
-	"backend/config"
+	"backend/apperr"
 	"backend/lib"
 	"context"
-	"errors"
+	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -16,21 +17,24 @@ type ListUserStruct struct {
 	Phone          string `json:"phone"`
 	Address        string `json:"address"`
 	ProfilePicture string `json:"profile_picture"`
+	EmailVerified  bool   `json:"email_verified"`
 	CreatedAt time.Time `json:"since"`
 }
 
 type User struct {
-	ID       int64  `json:"id"`
-	Email    string `json:"email"`
-	Password string `json:"-"`
-	Role     string `json:"role"`
+	ID               int64  `json:"id"`
+	Email            string `json:"email"`
+	Password         string `json:"-"`
+	Role             string `json:"role"`
+	EmailVerified    bool   `json:"email_verified"`
+	TwoFactorEnabled bool   `json:"two_factor_enabled"`
 }
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password,omitempty"`
-	Username string `json:"username" binding:"required"`
-	Phone    string `json:"phone" binding:"omitempty"`
-	Address  string `json:"address" binding:"omitempty"`
+	Password string `json:"password,omitempty" binding:"required,password"`
+	Username string `json:"username" binding:"required,min=3,max=20"`
+	Phone    string `json:"phone" binding:"omitempty,phone"`
+	Address  string `json:"address" binding:"omitempty,max=100"`
 	Role     string `json:"-"`
 }
 
@@ -41,30 +45,28 @@ type LoginRequest struct {
 
 type UpdateUserRequest struct {
     Username string `json:"username" binding:"required,min=3,max=20"`
-    Phone    string `json:"phone" binding:"omitempty,min=10,max=15"`
+    Phone    string `json:"phone" binding:"omitempty,phone"`
     Address  string `json:"address" binding:"omitempty,max=100"`
-    Password string `json:"password" binding:"omitempty,min=6,max=32"`
+    Password string `json:"password" binding:"omitempty,password"`
 }
 
 
 type AdminUpdateUserRequest struct {
     Username string `json:"username" binding:"required,min=3,max=20"`
-    Phone    string `json:"phone" binding:"omitempty,min=10,max=15"`
+    Phone    string `json:"phone" binding:"omitempty,phone"`
     Address  string `json:"address" binding:"omitempty,max=100"`
-    Password string `json:"password" binding:"omitempty,min=6,max=32"`
-    Role     string `json:"role" binding:"omitempty"`
+    Password string `json:"password" binding:"omitempty,password"`
+    Role     string `json:"role" binding:"omitempty,oneof=user admin"`
 }
 
 
 
 
-func Register(req RegisterRequest) (*User, error) {
-	ctx := context.Background()
-
+func Register(ctx context.Context, db DB, req RegisterRequest) (*User, error) {
 	hashedPassword := lib.HashPassword(req.Password)
 
 	var userID int64
-	err := config.Db.QueryRow(ctx,
+	err := db.QueryRow(ctx,
 		`INSERT INTO users (email, password, role)
          VALUES ($1, $2, 'user')
          RETURNING id`,
@@ -74,7 +76,7 @@ func Register(req RegisterRequest) (*User, error) {
 		return nil, err
 	}
 
-	_, err = config.Db.Exec(ctx,
+	_, err = db.Exec(ctx,
 		`INSERT INTO profile (users_id, username, phone, address)
          VALUES ($1, $2, $3, $4)`,
 		userID, req.Username, req.Phone, req.Address,
@@ -84,21 +86,20 @@ func Register(req RegisterRequest) (*User, error) {
 	}
 
 	return &User{
-		ID:    userID,
-		Email: req.Email,
-		Role:  "user",
+		ID:            userID,
+		Email:         req.Email,
+		Role:          "user",
+		EmailVerified: false,
 	}, nil
 }
-func Login(email string) (*User, error) {
-	ctx := context.Background()
-
+func Login(ctx context.Context, db DB, email string) (*User, error) {
 	var user User
-	err := config.Db.QueryRow(ctx,
-		`SELECT id, email, password, role
+	err := db.QueryRow(ctx,
+		`SELECT id, email, password, role, email_verified, totp_enabled
 		 FROM users
 		 WHERE email = $1`,
 		email,
-	).Scan(&user.ID, &user.Email, &user.Password, &user.Role)
+	).Scan(&user.ID, &user.Email, &user.Password, &user.Role, &user.EmailVerified, &user.TwoFactorEnabled)
 
 	if err != nil {
 		return nil, err
@@ -107,13 +108,11 @@ func Login(email string) (*User, error) {
 	return &user, nil
 }
 //admin
-func AdminUpdateUserByID(id int64, req AdminUpdateUserRequest) (*User, error) {
-	ctx := context.Background()
-
+func AdminUpdateUserByID(ctx context.Context, db DB, id int64, req AdminUpdateUserRequest) (*User, error) {
 	if req.Password != "" {
 		hashedPassword := lib.HashPassword(req.Password)
 
-		_, err := config.Db.Exec(ctx,
+		_, err := db.Exec(ctx,
 			`UPDATE users SET password=$1 WHERE id=$2`,
 			hashedPassword, id,
 		)
@@ -123,7 +122,7 @@ func AdminUpdateUserByID(id int64, req AdminUpdateUserRequest) (*User, error) {
 	}
 
 	if req.Role != "" {
-		_, err := config.Db.Exec(ctx,
+		_, err := db.Exec(ctx,
 			`UPDATE users SET role=$1 WHERE id=$2`,
 			req.Role, id,
 		)
@@ -132,7 +131,7 @@ func AdminUpdateUserByID(id int64, req AdminUpdateUserRequest) (*User, error) {
 		}
 	}
 
-	_, err := config.Db.Exec(ctx,
+	_, err := db.Exec(ctx,
 		`UPDATE profile
 		 SET username=$1, phone=$2, address=$3
 		 WHERE users_id=$4`,
@@ -143,7 +142,7 @@ func AdminUpdateUserByID(id int64, req AdminUpdateUserRequest) (*User, error) {
 	}
 
 	var user User
-	err = config.Db.QueryRow(ctx,
+	err = db.QueryRow(ctx,
 		`SELECT id, email, role FROM users WHERE id=$1`,
 		id,
 	).Scan(&user.ID, &user.Email, &user.Role)
@@ -155,11 +154,10 @@ func AdminUpdateUserByID(id int64, req AdminUpdateUserRequest) (*User, error) {
 	return &user, nil
 }
 
-func GetUserEmailByID(id int64) (string, error) {
-	ctx := context.Background()
+func GetUserEmailByID(ctx context.Context, db DB, id int64) (string, error) {
 	var email string
 
-	err := config.Db.QueryRow(ctx, `SELECT email FROM users WHERE id=$1`, id).Scan(&email)
+	err := db.QueryRow(ctx, `SELECT email FROM users WHERE id=$1`, id).Scan(&email)
 	if err != nil {
 		return "", err
 	}
@@ -168,13 +166,11 @@ func GetUserEmailByID(id int64) (string, error) {
 }
 
 //user
-func UpdateUserByID(id int64, req UpdateUserRequest) (*User, error) {
-    ctx := context.Background()
-
+func UpdateUserByID(ctx context.Context, db DB, id int64, req UpdateUserRequest) (*User, error) {
     if req.Password != "" {
         hashedPassword := lib.HashPassword(req.Password)
 
-        _, err := config.Db.Exec(ctx,
+        _, err := db.Exec(ctx,
             `UPDATE users SET password=$1 WHERE id=$2`,
             hashedPassword, id,
         )
@@ -183,7 +179,7 @@ func UpdateUserByID(id int64, req UpdateUserRequest) (*User, error) {
         }
     }
 
-    _, err := config.Db.Exec(ctx,
+    _, err := db.Exec(ctx,
         `UPDATE profile 
          SET username=$1, phone=$2, address=$3
          WHERE users_id=$4`,
@@ -194,7 +190,7 @@ func UpdateUserByID(id int64, req UpdateUserRequest) (*User, error) {
     }
 
     var user User
-    err = config.Db.QueryRow(ctx,
+    err = db.QueryRow(ctx,
         `SELECT id, email, role FROM users WHERE id=$1`,
         id,
     ).Scan(&user.ID, &user.Email, &user.Role)
@@ -206,24 +202,8 @@ func UpdateUserByID(id int64, req UpdateUserRequest) (*User, error) {
 }
 
 
-func Forgot(email string) (*User, error) {
-	ctx := context.Background()
-
-	var user User
-	err := config.Db.QueryRow(ctx,
-		`SELECT id,email,role FROM users WHERE email=$1`, email).Scan(&user.ID, &user.Email, &user.Role)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return &user, nil
-}
-
-func UpdateUserPassword(email, hashedPassword string) error {
-	ctx := context.Background()
-
-	_, err := config.Db.Exec(ctx,
+func UpdateUserPassword(ctx context.Context, db DB, email, hashedPassword string) error {
+	_, err := db.Exec(ctx,
 		`UPDATE users SET password=$1 WHERE email=$2`,
 		hashedPassword, email,
 	)
@@ -233,9 +213,8 @@ func UpdateUserPassword(email, hashedPassword string) error {
 
 
 // user
-func UpdateUserProfilePicture(userID int64, path string) error {
-	ctx := context.Background()
-	_, err := config.Db.Exec(ctx,
+func UpdateUserProfilePicture(ctx context.Context, db DB, userID int64, path string) error {
+	_, err := db.Exec(ctx,
 		`UPDATE profile 
 		 SET profile_picture = $1, updated_at = NOW()
 		 WHERE users_id = $2`,
@@ -245,13 +224,12 @@ func UpdateUserProfilePicture(userID int64, path string) error {
 }
 
 // admin
-func AdminUpdateUserProfilePicture(targetUserID int64, path string) error {
+func AdminUpdateUserProfilePicture(ctx context.Context, db DB, targetUserID int64, path string) error {
 	if targetUserID <= 0 {
-		return errors.New("invalid target user id")
+		return apperr.Validation("invalid target user id")
 	}
 
-	ctx := context.Background()
-	_, err := config.Db.Exec(ctx,
+	_, err := db.Exec(ctx,
 		`UPDATE profile 
 		 SET profile_picture = $1, updated_at = NOW()
 		 WHERE users_id = $2`,
@@ -261,11 +239,8 @@ func AdminUpdateUserProfilePicture(targetUserID int64, path string) error {
 }
 
 //admin
-func ListUser(page, limit int) ([]ListUserStruct, int64, error) {
-	ctx := context.Background()
-
-	offset := (page - 1) * limit
-	query := `
+func listUserQuery(where, orderBy, limit string) string {
+	return `
 	SELECT 
 		u.id,
 		u.email,
@@ -273,16 +248,19 @@ func ListUser(page, limit int) ([]ListUserStruct, int64, error) {
 		p.username,
 		p.phone,
 		p.address,
-		COALESCE(p.profile_picture, '') AS profile_picture
+		COALESCE(p.profile_picture, '') AS profile_picture,
+		u.email_verified
 	FROM users u
 	JOIN profile p ON p.users_id = u.id
-	ORDER BY u.id DESC
-	LIMIT $1 OFFSET $2;
-	`
+	` + where + `
+	ORDER BY ` + orderBy + `
+	` + limit
+}
 
-	rows, err := config.Db.Query(ctx, query, limit, offset)
+func scanListUser(ctx context.Context, db DB, query string, args ...any) ([]ListUserStruct, error) {
+	rows, err := db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -298,16 +276,29 @@ func ListUser(page, limit int) ([]ListUserStruct, int64, error) {
 			&u.Phone,
 			&u.Address,
 			&u.ProfilePicture,
+			&u.EmailVerified,
 		)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 
 		users = append(users, u)
 	}
 
+	return users, rows.Err()
+}
+
+func ListUser(ctx context.Context, db DB, page, limit int) ([]ListUserStruct, int64, error) {
+	offset := (page - 1) * limit
+	query := listUserQuery("", "u.id DESC", "LIMIT $1 OFFSET $2")
+
+	users, err := scanListUser(ctx, db, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	var totalItems int64
-	err = config.Db.QueryRow(ctx, `
+	err = db.QueryRow(ctx, `
 		SELECT COUNT(*) 
 		FROM users u
 		JOIN profile p ON p.users_id = u.id;
@@ -319,9 +310,39 @@ func ListUser(page, limit int) ([]ListUserStruct, int64, error) {
 	return users, totalItems, nil
 }
 
+func ListUserCursor(ctx context.Context, db DB, cursor *lib.Cursor, limit int) ([]ListUserStruct, string, string, error) {
+	if limit < 1 {
+		limit = 10
+	}
+	if cursor != nil && cursor.Sort != "" {
+		return nil, "", "", lib.ErrInvalidCursor
+	}
+
+	// ids are unique, the key is the id itself
+	key := keyset{Expr: "u.id", Cast: "bigint", ID: "u.id", Desc: true}
+	where := ""
+	var args []any
+	if cursor != nil {
+		var cond string
+		cond, args = key.after(cursor, 1)
+		where = "WHERE " + cond
+	}
+
+	query := listUserQuery(where, key.orderBy(cursor != nil && cursor.Backward), fmt.Sprintf("LIMIT %d", limit+1))
+
+	users, err := scanListUser(ctx, db, query, args...)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	users, next, prev := cursorPage(users, cursor, limit, "", func(u ListUserStruct) (string, int64) {
+		return strconv.FormatInt(u.ID, 10), u.ID
+	})
+	return users, next, prev, nil
+}
+
 //user
-func GetUserProfile(userId int64)(ListUserStruct, error){
-	ctx := context.Background()
+func GetUserProfile(ctx context.Context, db DB, userId int64)(ListUserStruct, error){
 	query := `
 	SELECT 
 	u.id,
@@ -330,13 +351,14 @@ func GetUserProfile(userId int64)(ListUserStruct, error){
 	p.username,
 	p.phone,
 	p.address,
-	COALESCE(p.profile_picture, '') AS profile_picture
+	COALESCE(p.profile_picture, '') AS profile_picture,
+	u.email_verified
 	FROM users u
 	LEFT JOIN profile p ON p.users_id = u.id
 	WHERE u.id = $1`
 
 	var u ListUserStruct
-	err := config.Db.QueryRow(ctx, query, userId).Scan(
+	err := db.QueryRow(ctx, query, userId).Scan(
 		&u.ID,
 		&u.CreatedAt,
 		&u.Email,
@@ -344,9 +366,20 @@ func GetUserProfile(userId int64)(ListUserStruct, error){
 		&u.Phone,
 		&u.Address,
 		&u.ProfilePicture,
+		&u.EmailVerified,
 	)
 	if err != nil{
 		return ListUserStruct{}, err
 	}
 	return u, nil
-}
\ No newline at end of file
+}
+
+func MarkEmailVerified(ctx context.Context, db DB, userID int64) error {
+	_, err := db.Exec(ctx,
+		`UPDATE users
+		 SET email_verified = TRUE, email_verified_at = NOW(), updated_at = NOW()
+		 WHERE id = $1`,
+		userID,
+	)
+	return err
+}
@@ -7,31 +7,40 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AdminRoutes(r *gin.Engine) {
+func AdminRoutes(r *gin.Engine, server *controllers.Server) {
 	admin := r.Group("/admin")
-	admin.Use(middleware.Auth(), middleware.AdminOnly())
+	admin.Use(middleware.Auth(server.Users), middleware.AdminOnly())
 
 	//auth
-	admin.POST("/user/:id/profile/upload", controllers.AdminUploadUserPicture)
-	admin.PUT("/:id/update", controllers.AdminUpdateUser)
-	admin.GET("/user", controllers.ListUser)
+	admin.POST("/user/:id/profile/upload", server.AdminUploadUserPicture)
+	admin.PUT("/:id/update", server.AdminUpdateUser)
+	admin.GET("/user", server.ListUser)
+	admin.GET("/user/:id/export", server.AdminExportUserData)
+	admin.DELETE("/user/:id", server.AdminDeleteUser)
 
 	//products
-	admin.GET("/product", controllers.AdminProductList)
-	admin.POST("/product-create", controllers.CreateProduct)
-	admin.PUT("/product/:id", controllers.UpdateProduct)
-	admin.DELETE("/product/:id", controllers.DeleteProduct)
-	admin.POST("/product/:id/pictures", controllers.UploadProductImages)
+	admin.GET("/product", server.AdminProductList)
+	admin.POST("/product-create", server.CreateProduct)
+	admin.PUT("/product/:id", server.UpdateProduct)
+	admin.DELETE("/product/:id", server.DeleteProduct)
+	admin.POST("/product/:id/pictures", server.UploadProductImages)
+	admin.GET("/featured", server.AdminFeaturedList)
+	admin.PUT("/product/:id/featured", server.FeatureProduct)
+	admin.DELETE("/product/:id/featured", server.UnfeatureProduct)
+
+	//reviews
+	admin.GET("/reviews", server.AdminReviewList)
+	admin.PUT("/reviews/:id/status", server.SetReviewStatus)
 
 	//order
-	admin.GET("/orders", controllers.AdminOrderList)
-	admin.PUT("/orders/:id/status", controllers.UpdateOrderStatus)
-	admin.GET("/order/:id", controllers.OrderDetail)
+	admin.GET("/orders", server.AdminOrderList)
+	admin.PUT("/orders/:id/status", server.UpdateOrderStatus)
+	admin.GET("/order/:id", server.OrderDetail)
 
 
 	//category
-	admin.POST("/category", controllers.CreateCategoryController)
-	admin.GET("/category", controllers.GetAllCategoriesController)
-	admin.PUT("/category/:id", controllers.UpdateCategoryController)
-	admin.DELETE("/category/:id", controllers.DeleteCategoryController)
+	admin.POST("/category", server.CreateCategoryController)
+	admin.GET("/category", server.GetAllCategoriesController)
+	admin.PUT("/category/:id", server.UpdateCategoryController)
+	admin.DELETE("/category/:id", server.DeleteCategoryController)
 }
@@ -1,22 +1,38 @@
 package routes
 
 import (
+	"backend/config"
 	"backend/controllers"
 	"backend/middleware"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRoutes(r *gin.Engine) {
+func AuthRoutes(r *gin.Engine, server *controllers.Server) {
 	auth := r.Group("/auth")
-	auth.POST("/register", controllers.RegisterUser)
-	auth.POST("/login", controllers.LoginUser)
-    auth.POST("/forgot-password", controllers.ForgotPassword)
-    auth.POST("/reset-password", controllers.ResetPassword)
+	auth.Use(middleware.RateLimit(config.RateLimit("auth", 10, time.Minute, false)))
+	auth.POST("/register", server.RegisterUser)
+	auth.POST("/login", server.LoginUser)
+	auth.POST("/login/2fa", server.LoginTwoFactor)
+    auth.POST("/forgot-password", server.ForgotPassword)
+    auth.POST("/reset-password", server.ResetPassword)
+	auth.GET("/verify-email", server.VerifyEmail)
+	auth.POST("/verify-email", server.VerifyEmail)
+	auth.POST("/resend-verification", server.ResendVerification)
+	auth.GET("/oidc/:provider/login", server.OIDCLogin)
+	auth.GET("/oidc/:provider/callback", server.OIDCCallback)
 
 	user := r.Group("/user")
-	user.Use(middleware.Auth())
-	user.GET("/profile", controllers.UserProfile)
-	user.PUT("/profile/update", controllers.UpdateProfile)
-	user.POST("/profile/upload", controllers.UploadUserPicture)
+	user.Use(middleware.Auth(server.Users))
+	user.GET("/profile", server.UserProfile)
+	user.PUT("/profile/update", server.UpdateProfile)
+	user.POST("/profile/upload", server.UploadUserPicture)
+	user.GET("/export", server.ExportUserData)
+	user.DELETE("/account", server.DeleteAccount)
+
+	user.POST("/2fa/enroll", server.EnrollTwoFactor)
+	user.POST("/2fa/verify", server.VerifyTwoFactor)
+	user.POST("/2fa/backup-codes", server.RegenerateBackupCodes)
+	user.POST("/2fa/disable", server.DisableTwoFactor)
 }
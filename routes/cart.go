@@ -7,11 +7,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func CartRoutes(r *gin.Engine){
+func CartRoutes(r *gin.Engine, server *controllers.Server){
 	cart:= r.Group("/cart")
-	cart.Use(middleware.Auth())
+	cart.Use(middleware.Auth(server.Users))
 
-	cart.POST("", controllers.AddToCart)
-	cart.GET("", controllers.GetCart)
-	cart.DELETE("/delete/:id", controllers.DeleteCart)
+	cart.POST("", server.AddToCart)
+	cart.GET("", server.GetCart)
+	cart.DELETE("/delete/:id", server.DeleteCart)
 }
\ No newline at end of file
@@ -1,11 +1,24 @@
 package routes
 
 import (
+	"backend/config"
 	"backend/controllers"
+	"backend/middleware"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func FavoriteRouter(r *gin.Engine){
-	r.GET("/favorite-product", controllers.FavoriteProduct)
+func FavoriteRouter(r *gin.Engine, server *controllers.Server){
+	limit := middleware.RateLimit(config.RateLimit("products", 60, time.Minute, false))
+
+	// admin curated list (products.is_favorite)
+	r.GET("/favorite-product", limit, middleware.OptionalAuth(server.Users), server.FavoriteProduct)
+	r.GET("/featured-products", limit, middleware.OptionalAuth(server.Users), server.FavoriteProduct)
+
+	user := r.Group("/user/favorites")
+	user.Use(middleware.Auth(server.Users))
+	user.GET("", server.UserFavorites)
+	user.POST("/:productId", server.AddFavorite)
+	user.DELETE("/:productId", server.RemoveFavorite)
 }
\ No newline at end of file
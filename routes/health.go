@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"backend/controllers"
+	"backend/metrics"
+	"backend/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// HealthRoutes are registered after the rate limit but before the database
+// check, probes and scrapes still answer while the database is down. /status
+// shares the /metrics token since it exposes pool and cache internals.
+func HealthRoutes(r *gin.Engine) {
+	r.GET("/healthz", controllers.Healthz)
+	r.GET("/readyz", controllers.Readyz)
+	r.GET("/status", middleware.MetricsToken(), controllers.Status)
+	r.GET("/metrics", middleware.MetricsToken(), gin.WrapH(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})))
+}
@@ -1,13 +1,35 @@
 package routes
 
 import (
+	"backend/config"
+	"backend/controllers"
 	"backend/middleware"
+	"log/slog"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Routes(r *gin.Engine) {
+func Routes(r *gin.Engine, server *controllers.Server) {
+	// handlers pass ctx to config.Logger, it needs the request context values
+	r.ContextWithFallback = true
+	// ClientIP (rate limit, logs) only believes X-Forwarded-For from these
+	if err := r.SetTrustedProxies(config.TrustedProxies()); err != nil {
+		slog.Error("invalid TRUSTED_PROXIES, trusting no proxy", "error", err)
+		r.SetTrustedProxies(nil)
+	}
+	r.TrustedPlatform = config.TrustedPlatform()
+	r.Use(middleware.Tracing(), middleware.TraceID())
+	r.Use(middleware.RequestLogger(), middleware.Recovery())
+	// handlers answer errors with ctx.Error, see apperr
+	r.Use(middleware.Errors())
+	r.Use(middleware.Metrics())
 	r.Use(middleware.CorsMiddleware())
+	// after cors, it replaces the Vary header
+	r.Use(middleware.Locale())
+	r.Use(middleware.RateLimit(config.RateLimit("global", 300, time.Minute, true)))
+	HealthRoutes(r)
+	r.Use(middleware.RequireDatabase("/", "/swagger/*any"))
 	r.MaxMultipartMemory = 25 << 20
 	r.GET("/", func(ctx *gin.Context) {
 		ctx.JSON(200, gin.H{
@@ -15,10 +37,10 @@ func Routes(r *gin.Engine) {
 			"message": "backend is running",
 		})
 	})
-	AuthRoutes(r)
-	ProductRouter(r)
-	AdminRoutes(r)
-	OrderRouter(r)
-	FavoriteRouter(r)
-	CartRoutes(r)
+	AuthRoutes(r, server)
+	ProductRouter(r, server)
+	AdminRoutes(r, server)
+	OrderRouter(r, server)
+	FavoriteRouter(r, server)
+	CartRoutes(r, server)
 }
@@ -7,11 +7,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func OrderRouter(r *gin.Engine) {
+func OrderRouter(r *gin.Engine, server *controllers.Server) {
 	user := r.Group("/user")
-	user.Use(middleware.Auth())
+	user.Use(middleware.Auth(server.Users))
 
-	user.POST("/order", controllers.CreateOrder)
-	user.GET("/history", controllers.OrderHistory)
-	user.GET("/order/:id", controllers.OrderDetail)
+	user.POST("/order", middleware.VerifiedEmail(server.Users), server.CreateOrder)
+	user.GET("/history", server.OrderHistory)
+	user.GET("/order/:id", server.OrderDetail)
 }
@@ -1,13 +1,29 @@
 package routes
 
 import (
+	"backend/config"
 	"backend/controllers"
+	"backend/middleware"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func ProductRouter(r *gin.Engine) {
+func ProductRouter(r *gin.Engine, server *controllers.Server) {
+	limit := middleware.RateLimit(config.RateLimit("products", 60, time.Minute, false))
 
-	r.GET("/products", controllers.Product)
-	r.GET("/products/:id", controllers.ProductDetail)
+	r.GET("/products", limit, middleware.OptionalAuth(server.Users), server.Product)
+	r.GET("/products/suggest", limit, server.ProductSuggest)
+	r.GET("/products/best-sellers", limit, middleware.OptionalAuth(server.Users), server.BestSellers)
+	r.GET("/products/trending", limit, middleware.OptionalAuth(server.Users), server.TrendingProducts)
+	r.GET("/products/:id", limit, middleware.OptionalAuth(server.Users), server.ProductDetail)
+	r.GET("/products/:id/reviews", limit, server.ProductReviews)
+	r.POST("/products/:id/reviews", middleware.Auth(server.Users), server.CreateReview)
+
+	r.GET("/user/recommendations", middleware.Auth(server.Users), server.UserRecommendations)
+
+	user := r.Group("/user/reviews")
+	user.Use(middleware.Auth(server.Users))
+	user.PUT("/:id", server.UpdateReview)
+	user.DELETE("/:id", server.DeleteReview)
 }
@@ -0,0 +1,79 @@
+package validation
+
+import (
+	"backend/i18n"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// messages per tag, in English for the i18n catalog. min, max, len get a
+// ".string" or ".slice" variant since "at least 3" reads differently for
+// a name.
+var messages = map[string]string{
+	"required":   "is required",
+	"email":      "must be a valid email",
+	"phone":      "must be a valid phone number, e.g. 081234567890",
+	"password":   "must be 8 to 72 characters with letters and numbers",
+	"id":         "must be a valid id",
+	"locale":     "must be a supported language (en, id)",
+	"oneof":      "must be one of %s",
+	"min":        "must be at least %s",
+	"min.string": "must be at least %s characters",
+	"min.slice":  "must have at least %s items",
+	"max":        "must be at most %s",
+	"max.string": "must be at most %s characters",
+	"max.slice":  "must have at most %s items",
+	"len":        "must be %s",
+	"len.string": "must be %s characters",
+	"len.slice":  "must have %s items",
+	"gt":         "must be greater than %s",
+	"gte":        "must be %s or more",
+	"lt":         "must be less than %s",
+	"lte":        "must be %s or less",
+}
+
+// InvalidRequest is the top message of a response with field errors
+const InvalidRequest = "Invalid request"
+
+// TypeMessage is for a JSON value that does not fit the Go type of the
+// field ("must be a number")
+func TypeMessage(locale string, t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return i18n.T(locale, "must be a number")
+	case reflect.String:
+		return i18n.T(locale, "must be text")
+	case reflect.Bool:
+		return i18n.T(locale, "must be true or false")
+	case reflect.Slice, reflect.Array:
+		return i18n.T(locale, "must be a list")
+	}
+	return i18n.T(locale, "must be an object")
+}
+
+// Message words one failed binding tag
+func Message(locale string, fe validator.FieldError) string {
+	tag := fe.Tag()
+	switch tag {
+	case "min", "max", "len":
+		switch fe.Kind() {
+		case reflect.String:
+			tag += ".string"
+		case reflect.Slice, reflect.Map, reflect.Array:
+			tag += ".slice"
+		}
+	}
+
+	message, ok := messages[tag]
+	if !ok {
+		return i18n.T(locale, "is not valid")
+	}
+	if !strings.Contains(message, "%s") {
+		return i18n.T(locale, message)
+	}
+	return i18n.T(locale, message, strings.ReplaceAll(fe.Param(), " ", ", "))
+}
@@ -0,0 +1,81 @@
+// Package validation adds the project binding tags to gin's validator and
+// words the field errors, translated by the i18n catalog.
+//
+//	phone     Indonesian mobile number, 08xx / 628xx / +628xx
+//	password  8 to 72 characters with at least one letter and one digit
+//	id        a positive database id
+//	locale    one of i18n.Locales, for translations keyed by locale
+//
+// The tags are registered when the package is loaded, it is imported by
+// apperr so every binding in the app sees them.
+package validation
+
+import (
+	"backend/i18n"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var phonePattern = regexp.MustCompile(`^(\+62|62|0)8[1-9][0-9]{6,11}$`)
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	// field errors name the json field ("customer_phone"), not the Go one
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+
+	v.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		return phonePattern.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return StrongPassword(fl.Field().String())
+	})
+	v.RegisterValidation("id", func(fl validator.FieldLevel) bool {
+		switch f := fl.Field(); f.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return f.Int() > 0
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return f.Uint() > 0
+		}
+		return false
+	})
+	v.RegisterValidation("locale", func(fl validator.FieldLevel) bool {
+		return i18n.Supported(fl.Field().String())
+	})
+}
+
+// StrongPassword is the "password" tag, argon2 has no length limit but 72
+// keeps the door open for bcrypt
+func StrongPassword(password string) bool {
+	if len(password) < 8 || len(password) > 72 {
+		return false
+	}
+
+	var letter, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return letter && digit
+}
@@ -0,0 +1,85 @@
+package validation
+
+import (
+	"backend/i18n"
+	"errors"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestTags(t *testing.T) {
+	type request struct {
+		Phone     string `json:"phone" binding:"omitempty,phone"`
+		Password  string `json:"password" binding:"required,password"`
+		ProductID int64  `json:"product_id" binding:"required,id"`
+		Name      string `json:"name" binding:"min=3"`
+	}
+
+	valid := request{Phone: "081234567890", Password: "rahasia123", ProductID: 1, Name: "Kopi"}
+	if err := binding.Validator.ValidateStruct(&valid); err != nil {
+		t.Fatalf("valid request: %v", err)
+	}
+	for _, phone := range []string{"+6281234567890", "6281234567890"} {
+		valid.Phone = phone
+		if err := binding.Validator.ValidateStruct(&valid); err != nil {
+			t.Fatalf("phone %s: %v", phone, err)
+		}
+	}
+
+	err := binding.Validator.ValidateStruct(&request{Phone: "12345", Password: "password", ProductID: -2, Name: "Ko"})
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 4 {
+		t.Fatalf("err = %v", err)
+	}
+
+	want := []struct{ field, en, id string }{
+		{"phone", "must be a valid phone number, e.g. 081234567890", "harus berupa nomor HP yang valid, contoh 081234567890"},
+		{"password", "must be 8 to 72 characters with letters and numbers", "harus 8 sampai 72 karakter dan berisi huruf serta angka"},
+		{"product_id", "must be a valid id", "harus berupa id yang valid"},
+		{"name", "must be at least 3 characters", "minimal 3 karakter"},
+	}
+	for i, w := range want {
+		if errs[i].Field() != w.field || Message(i18n.English, errs[i]) != w.en || Message(i18n.Indonesian, errs[i]) != w.id {
+			t.Errorf("error %d = %s %q / %q", i, errs[i].Field(), Message(i18n.English, errs[i]), Message(i18n.Indonesian, errs[i]))
+		}
+	}
+}
+
+func TestLocaleKeys(t *testing.T) {
+	type translation struct {
+		Name string `json:"name" binding:"required"`
+	}
+	type request struct {
+		Translations map[string]translation `json:"translations" binding:"omitempty,dive,keys,locale,endkeys"`
+	}
+
+	if err := binding.Validator.ValidateStruct(&request{Translations: map[string]translation{"id": {Name: "Kopi Susu"}}}); err != nil {
+		t.Fatalf("valid translations: %v", err)
+	}
+
+	var errs validator.ValidationErrors
+	err := binding.Validator.ValidateStruct(&request{Translations: map[string]translation{"fr": {Name: "Café"}}})
+	if !errors.As(err, &errs) || errs[0].Tag() != "locale" {
+		t.Fatalf("unsupported locale err = %v", err)
+	}
+	err = binding.Validator.ValidateStruct(&request{Translations: map[string]translation{"id": {}}})
+	if !errors.As(err, &errs) || errs[0].Tag() != "required" {
+		t.Fatalf("missing name err = %v", err)
+	}
+}
+
+func TestStrongPassword(t *testing.T) {
+	for password, want := range map[string]bool{
+		"rahasia123": true,
+		"rahasia":    false,
+		"12345678":   false,
+		"abcdefgh":   false,
+		"a1":         false,
+	} {
+		if got := StrongPassword(password); got != want {
+			t.Errorf("StrongPassword(%q) = %v", password, got)
+		}
+	}
+}